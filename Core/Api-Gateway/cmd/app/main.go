@@ -4,6 +4,7 @@ import (
 	"apigateway/internal/app"
 	"apigateway/pkg/config"
 	"apigateway/pkg/lib/logger"
+	"context"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -28,5 +29,10 @@ func main() {
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
 
 	<-stop
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.API.Timeout)
+	defer cancel()
+	application.Stop(ctx)
+
 	log.Info("Gracefully stopped")
 }
@@ -6,9 +6,21 @@ import (
 	authcontroller "apigateway/internal/controllers/auth"
 	commentsmanagercontroller "apigateway/internal/controllers/commentController"
 	favoritescontroller "apigateway/internal/controllers/favorites"
+	favoritesmock "apigateway/internal/controllers/favorites/mock"
+	mecontroller "apigateway/internal/controllers/me"
 	"apigateway/internal/controllers/middleware"
 	statscontroller "apigateway/internal/controllers/statsController"
 	userscontroller "apigateway/internal/controllers/usersManager"
+	"apigateway/internal/docs"
+	"apigateway/internal/domain/models"
+	"apigateway/internal/lib/apierror"
+	"apigateway/internal/lib/apps"
+	"apigateway/internal/lib/caller"
+	"apigateway/internal/lib/clientip"
+	"apigateway/internal/lib/jwt/jwks"
+	tokenparser "apigateway/internal/lib/jwt/tokenParser"
+	"apigateway/internal/lib/ratelimit"
+	"apigateway/internal/lib/sessions"
 	articlemanageservice "apigateway/internal/services/articleManager"
 	authservice "apigateway/internal/services/auth"
 	commentsmanagerservice "apigateway/internal/services/comments"
@@ -18,16 +30,29 @@ import (
 	commentsmanagerstorage "apigateway/internal/storage/real/comments"
 	usersmanagerstorage "apigateway/internal/storage/real/usersManager"
 	"apigateway/pkg/config"
+	"apigateway/pkg/lib/logger/sl"
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
 
+	"github.com/chas3air/shared/grpcconn"
+	"github.com/chas3air/shared/mtls"
+	"github.com/chas3air/shared/resilience"
 	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 )
 
 type App struct {
 	log *slog.Logger
 	cfg *config.Config
+
+	mu     sync.Mutex
+	server *http.Server
+	conns  []*grpcconn.Conn
 }
 
 func New(log *slog.Logger, cfg *config.Config) *App {
@@ -37,24 +62,76 @@ func New(log *slog.Logger, cfg *config.Config) *App {
 	}
 }
 
-func (a *App) Start() {
+// dial describes a connection to one of the gRPC services. It is shared by
+// every request and closed by Stop.
+func (a *App) dial(host string, port int, opts ...grpc.DialOption) *grpcconn.Conn {
+	conn := grpcconn.New(a.log,
+		fmt.Sprintf("%s:%d", host, port),
+		keepalive.ClientParameters{
+			Time:                a.cfg.GrpcClient.KeepaliveTime,
+			Timeout:             a.cfg.GrpcClient.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		},
+		opts...,
+	)
+
+	a.mu.Lock()
+	a.conns = append(a.conns, conn)
+	a.mu.Unlock()
+
+	return conn
+}
+
+// resilient retries the reads to the service called name and keeps its
+// circuit breaker, as policy says.
+func (a *App) resilient(name string, policy config.ResiliencePolicy, reads []string) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(resilience.New(a.log, name, policy, reads...).UnaryClientInterceptor)
+}
+
+// Router wires the controllers to the gRPC services and registers every
+// route of the API. Connections are only made by the first request.
+func (a *App) Router() *mux.Router {
+	creds, err := mtls.ClientCredentials(a.cfg.TLS)
+	if err != nil {
+		panic(err)
+	}
+	withCreds := grpc.WithTransportCredentials(creds)
+	withCaller := grpc.WithUnaryInterceptor(caller.UnaryClientInterceptor)
+
 	// Пачка для микросервиса авторизации
-	authStorage := authstorage.New(a.log, a.cfg.AuthHost, a.cfg.AuthPort)
+	authConn := a.dial(a.cfg.AuthHost, a.cfg.AuthPort, withCreds,
+		a.resilient("auth", a.cfg.Resilience.Auth, authstorage.ReadMethods),
+	)
+	authStorage := authstorage.New(a.log, authConn)
 	authService := authservice.New(a.log, authStorage)
-	authController := authcontroller.New(a.log, authService)
+	// Адреса клиентов за доверенными прокси
+	clients, err := clientip.New(a.cfg.RateLimit.TrustedProxies)
+	if err != nil {
+		panic(err)
+	}
+	authController := authcontroller.New(a.log, authService, clients)
 
 	// Пачка для микросервиса пользователей
-	usersManagerStorage := usersmanagerstorage.New(a.log, a.cfg.UsersStorageHost, a.cfg.UsersStoragePort)
+	usersManagerConn := a.dial(a.cfg.UsersStorageHost, a.cfg.UsersStoragePort, withCreds, withCaller,
+		a.resilient("user_service", a.cfg.Resilience.Users, usersmanagerstorage.ReadMethods),
+	)
+	usersManagerStorage := usersmanagerstorage.New(a.log, usersManagerConn)
 	usersManagerService := usersmanagerservice.New(a.log, usersManagerStorage)
-	usersController := userscontroller.New(a.log, usersManagerService)
+	usersController := userscontroller.New(a.log, usersManagerService, authService)
 
 	// Пачка для микросервиса постов
-	articleManagerStorage := articlesmanagerstorage.New(a.log, a.cfg.ArticlesStorageHost, a.cfg.ArticlesStoragePort)
+	articleManagerConn := a.dial(a.cfg.ArticlesStorageHost, a.cfg.ArticlesStoragePort, withCreds, withCaller,
+		a.resilient("article_service", a.cfg.Resilience.Articles, articlesmanagerstorage.ReadMethods),
+	)
+	articleManagerStorage := articlesmanagerstorage.New(a.log, articleManagerConn)
 	articleManagerService := articlemanageservice.New(a.log, articleManagerStorage)
 	articleController := articlecontroller.New(a.log, articleManagerService)
 
 	// Пачка для микросервиса комментариев
-	commentManagerStorage := commentsmanagerstorage.New(a.log, a.cfg.CommentsStorageHost, a.cfg.CommentsStoragePort)
+	commentManagerConn := a.dial(a.cfg.CommentsStorageHost, a.cfg.CommentsStoragePort, withCreds, withCaller,
+		a.resilient("comment_service", a.cfg.Resilience.Comments, commentsmanagerstorage.ReadMethods),
+	)
+	commentManagerStorage := commentsmanagerstorage.New(a.log, commentManagerConn)
 	commentsManagerService := commentsmanagerservice.New(a.log, commentManagerStorage)
 	commentsManagerController := commentsmanagercontroller.New(a.log, commentsManagerService)
 
@@ -65,77 +142,216 @@ func (a *App) Start() {
 	moderationController := agreement.New(a.log)
 
 	// Избранное
-	favoritesController := favoritescontroller.New(a.log)
+	favoritesStorage := favoritesmock.New(a.log)
+	favoritesController := favoritescontroller.New(a.log, favoritesStorage)
+
+	// Профиль текущего пользователя
+	meController := mecontroller.New(a.log, usersManagerService, authService, articleManagerService, commentsManagerService, favoritesStorage)
+
+	// Ключи Auth для проверки подписи токенов
+	jwksCache := jwks.New(a.log, authService, a.cfg.Jwt.JwksRefreshInterval)
+	tokenParser := tokenparser.New(jwksCache, a.cfg.Jwt.Issuer, a.cfg.Jwt.Audience)
+
+	// Зарегистрированные клиентские приложения
+	appsCache := apps.New(a.log, authService, a.cfg.Apps.CacheTTL)
+
+	// Сессии, завершённые выходом из аккаунта
+	sessionsCache := sessions.New(a.log, authService, a.cfg.Jwt.SessionCacheTTL)
+
+	// Ограничение частоты запросов
+	limiter, err := ratelimit.New(a.log, ratelimit.NewMemoryStore(), a.cfg.RateLimit)
+	if err != nil {
+		panic(err)
+	}
 
 	// Создание объекта middleware
-	middleware := middleware.New()
+	middleware := middleware.New(tokenParser, authService, appsCache, sessionsCache, a.cfg.Apps.Required)
 
 	r := mux.NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apierror.Write(w, "Not found", http.StatusNotFound)
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apierror.Write(w, "Method not allowed", http.StatusMethodNotAllowed)
+	})
+	r.Use(middleware.RequestId)
+	r.Use(middleware.Deadline(a.cfg.API.Timeout, a.cfg.API.RouteTimeouts))
 	r.Use(middleware.CORS)
+	// The default budget is spent before any token is checked, so it is
+	// counted per client IP; per-user budgets are mounted after ValidateToken.
+	r.Use(middleware.RateLimit(limiter, "default"))
+	r.Use(middleware.ValidateApp)
 	r.HandleFunc("/api/v1/health-check", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
+	r.HandleFunc("/.well-known/jwks.json", authController.JWKS).Methods(http.MethodGet, http.MethodOptions)
+
+	// Описание API в формате OpenAPI и страница документации
+	r.HandleFunc("/api/v1/openapi.json", docs.Spec).Methods(http.MethodGet, http.MethodOptions)
+	r.HandleFunc("/api/v1/docs", docs.Page).Methods(http.MethodGet, http.MethodOptions)
 
 	// Группа для авторизации, не пропускает если пользователь уже существует
+	limitAuth := middleware.RateLimit(limiter, "auth")
 	authRouter := r.PathPrefix("/api/v1").Subrouter()
 	authRouter.Use(middleware.CORS)
 	authRouter.Use(middleware.PreventAccessIfLoggedIn)
+	authRouter.Use(limitAuth)
 	authRouter.HandleFunc("/login", authController.Login).Methods(http.MethodPost, http.MethodOptions)
 	authRouter.HandleFunc("/register", authController.Register).Methods(http.MethodPost, http.MethodOptions)
+	authRouter.HandleFunc("/login/2fa", authController.LoginTotp).Methods(http.MethodPost, http.MethodOptions)
+	authRouter.HandleFunc("/login/2fa/enroll", authController.BeginLoginTotpEnrollment).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/api/v1/refresh", limitAuth(http.HandlerFunc(authController.Refresh))).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/api/v1/verify-email", limitAuth(http.HandlerFunc(authController.VerifyEmail))).Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
+	r.Handle("/api/v1/verify-email/resend", limitAuth(http.HandlerFunc(authController.ResendVerificationEmail))).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/api/v1/password-reset", limitAuth(http.HandlerFunc(authController.RequestPasswordReset))).Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/api/v1/password-reset/confirm", limitAuth(http.HandlerFunc(authController.ConfirmPasswordReset))).Methods(http.MethodPost, http.MethodOptions)
+
+	// Группа для управления сессиями текущего пользователя
+	route_for_sessions := r.PathPrefix("/api/v1").Subrouter()
+	route_for_sessions.Use(middleware.ValidateToken)
+	route_for_sessions.Use(middleware.RequireSession)
+	route_for_sessions.HandleFunc("/logout", authController.Logout).Methods(http.MethodPost, http.MethodOptions)
+	route_for_sessions.HandleFunc("/logout-all", authController.LogoutAll).Methods(http.MethodPost, http.MethodOptions)
+	route_for_sessions.HandleFunc("/sessions", authController.GetSessions).Methods(http.MethodGet, http.MethodOptions)
+	route_for_sessions.HandleFunc("/sessions/{id}", authController.RevokeSession).Methods(http.MethodDelete, http.MethodOptions)
+
+	// Группа для двухфакторной аутентификации текущего пользователя
+	route_for_2fa := r.PathPrefix("/api/v1/2fa").Subrouter()
+	route_for_2fa.Use(middleware.ValidateToken)
+	route_for_2fa.Use(middleware.RequireSession)
+	route_for_2fa.HandleFunc("/totp", authController.BeginTotpEnrollment).Methods(http.MethodPost, http.MethodOptions)
+	route_for_2fa.HandleFunc("/totp/confirm", authController.ConfirmTotpEnrollment).Methods(http.MethodPost, http.MethodOptions)
+	route_for_2fa.HandleFunc("/totp/disable", authController.DisableTotp).Methods(http.MethodPost, http.MethodOptions)
+
+	// Группа для профиля текущего пользователя, менять и удалять аккаунт можно только из сессии
+	route_for_me := r.PathPrefix("/api/v1/me").Subrouter()
+	route_for_me.Use(middleware.ValidateToken)
+	route_for_me.HandleFunc("", meController.Get).Methods(http.MethodGet, http.MethodOptions)
+	route_for_me.Handle("", middleware.RequireSession(http.HandlerFunc(meController.Update))).Methods(http.MethodPatch, http.MethodOptions)
+	route_for_me.Handle("", middleware.RequireSession(http.HandlerFunc(meController.Delete))).Methods(http.MethodDelete, http.MethodOptions)
+
+	// Группа для персональных токенов доступа, создавать их можно только из сессии
+	route_for_tokens := r.PathPrefix("/api/v1/tokens").Subrouter()
+	route_for_tokens.Use(middleware.ValidateToken)
+	route_for_tokens.Use(middleware.RequireSession)
+	route_for_tokens.HandleFunc("", authController.CreatePersonalAccessToken).Methods(http.MethodPost, http.MethodOptions)
+	route_for_tokens.HandleFunc("", authController.ListPersonalAccessTokens).Methods(http.MethodGet, http.MethodOptions)
+	route_for_tokens.HandleFunc("/{id}", authController.RevokePersonalAccessToken).Methods(http.MethodDelete, http.MethodOptions)
+
+	// Группа для регистрации клиентских приложений
+	route_for_apps := r.PathPrefix("/api/v1/apps").Subrouter()
+	route_for_apps.Use(middleware.ValidateToken)
+	route_for_apps.Use(middleware.RequireSession)
+	route_for_apps.Use(middleware.RequirePermission(models.PermAppsManage))
+	route_for_apps.HandleFunc("", authController.CreateApp).Methods(http.MethodPost, http.MethodOptions)
+	route_for_apps.HandleFunc("", authController.ListApps).Methods(http.MethodGet, http.MethodOptions)
+	route_for_apps.HandleFunc("/{id}", authController.GetApp).Methods(http.MethodGet, http.MethodOptions)
+	route_for_apps.HandleFunc("/{id}", authController.RevokeApp).Methods(http.MethodDelete, http.MethodOptions)
+	route_for_apps.HandleFunc("/{id}/rotate-secret", authController.RotateAppSecret).Methods(http.MethodPost, http.MethodOptions)
 
 	// Группа для работы с пользователями
 	route_for_user_admin := r.PathPrefix("/api/v1/users").Subrouter()
 	route_for_user_admin.Use(middleware.ValidateToken)
-	route_for_user_admin.Use(middleware.RequireUserAdmin)
+	route_for_user_admin.Use(middleware.RequirePermission(models.PermUsersManage))
+	route_for_user_admin.Use(middleware.RequireScope(models.ScopeUsersWrite))
 
-	r.HandleFunc("/api/v1/users", usersController.GetUsers).Methods(http.MethodGet, http.MethodOptions)
-	r.HandleFunc("/api/v1/users/{id}", usersController.GetUserById).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/api/v1/users", middleware.OptionalToken(http.HandlerFunc(usersController.GetUsers))).Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/api/v1/users/{id}", middleware.OptionalToken(http.HandlerFunc(usersController.GetUserById))).Methods(http.MethodGet, http.MethodOptions)
 	route_for_user_admin.HandleFunc("", usersController.Insert).Methods(http.MethodPost, http.MethodOptions)
 	route_for_user_admin.HandleFunc("/{id}", usersController.Update).Methods(http.MethodPut, http.MethodOptions)
 	route_for_user_admin.HandleFunc("/{id}", usersController.Delete).Methods(http.MethodDelete, http.MethodOptions)
+	route_for_user_admin.HandleFunc("/{id}/unlock", authController.UnlockAccount).Methods(http.MethodPost, http.MethodOptions)
+
+	// Группа для просмотра ролей и их прав
+	route_for_roles := r.PathPrefix("/api/v1/roles").Subrouter()
+	route_for_roles.Use(middleware.ValidateToken)
+	route_for_roles.Use(middleware.RequirePermission(models.PermUsersManage))
+	route_for_roles.HandleFunc("", authController.ListRoles).Methods(http.MethodGet, http.MethodOptions)
 
-	// Группа для работы с постами и комментариями
-	route_for_article_admin := r.PathPrefix("/api/v1").Subrouter()
-	route_for_article_admin.Use(middleware.ValidateToken)
-	route_for_article_admin.Use(middleware.RequireArticleAdmin)
+	// Группа для создания и изменения постов и комментариев: автора и право владельца или модератора проверяют сами сервисы
+	route_for_owner := r.PathPrefix("/api/v1").Subrouter()
+	route_for_owner.Use(middleware.ValidateToken)
+	route_for_owner.Use(middleware.RateLimit(limiter, "write"))
 
 	route_for_user := r.PathPrefix("/api/v1").Subrouter()
 	route_for_user.Use(middleware.ValidateToken)
-	route_for_user.Use(middleware.RequireUser)
+	route_for_user.Use(middleware.RateLimit(limiter, "write"))
+	route_for_user.Use(middleware.RequirePermission(models.PermCommentsCreate))
+	route_for_user.Use(middleware.RequireScope(models.ScopeCommentsWrite))
 
 	r.HandleFunc("/api/v1/articles", articleController.GetArticles).Methods(http.MethodGet, http.MethodOptions)
 	r.HandleFunc("/api/v1/articles/{article_id}/", articleController.GetArticleById).Methods(http.MethodGet, http.MethodOptions)
 	r.HandleFunc("/api/v1/articles/{owner_id}", articleController.GetArticlesByOwnerId).Methods(http.MethodGet, http.MethodOptions)
-	// route_for_user.HandleFunc("/articles", articleController.Insert).Methods(http.MethodPost, http.MethodOptions)
-	route_for_article_admin.HandleFunc("/articles/{article_id}", articleController.Update).Methods(http.MethodPut, http.MethodOptions)
-	route_for_article_admin.HandleFunc("/articles/{article_id}", articleController.Delete).Methods(http.MethodDelete, http.MethodOptions)
+	requireArticlesWrite := middleware.RequireScope(models.ScopeArticlesWrite)
+	route_for_owner.Handle("/articles", middleware.RequirePermission(models.PermArticlesCreate)(requireArticlesWrite(http.HandlerFunc(articleController.Insert)))).Methods(http.MethodPost, http.MethodOptions)
+	route_for_owner.Handle("/articles/{article_id}", requireArticlesWrite(http.HandlerFunc(articleController.Update))).Methods(http.MethodPut, http.MethodOptions)
+	route_for_owner.Handle("/articles/{article_id}", requireArticlesWrite(http.HandlerFunc(articleController.Delete))).Methods(http.MethodDelete, http.MethodOptions)
 
 	r.HandleFunc("/api/v1/comments/{id}", commentsManagerController.GetCommentById).Methods(http.MethodGet, http.MethodOptions)
 	r.HandleFunc("/api/v1/{article_id}/comments", commentsManagerController.GetCommentsByArticleId).Methods(http.MethodGet, http.MethodOptions)
 	route_for_user.HandleFunc("/comments", commentsManagerController.Insert).Methods(http.MethodPost, http.MethodOptions)
-	route_for_article_admin.HandleFunc("/comments/{id}", commentsManagerController.Delete).Methods(http.MethodDelete, http.MethodOptions)
+	route_for_owner.Handle("/comments/{id}", middleware.RequireScope(models.ScopeCommentsWrite)(http.HandlerFunc(commentsManagerController.Delete))).Methods(http.MethodDelete, http.MethodOptions)
 
 	route_for_analyst := r.PathPrefix("/api/v1/stats").Subrouter()
 	route_for_analyst.Use(middleware.ValidateToken)
-	route_for_analyst.Use(middleware.RequireAnalyst)
+	route_for_analyst.Use(middleware.RequirePermission(models.PermStatsRead))
+	route_for_analyst.Use(middleware.RequireScope(models.ScopeStatsRead))
 	route_for_analyst.HandleFunc("/articles", statsController.GetArticlesStats).Methods(http.MethodGet, http.MethodOptions)
 	route_for_analyst.HandleFunc("/users", statsController.GetUsersStats).Methods(http.MethodGet, http.MethodOptions)
 
 	// route_for_moderation := r.PathPrefix("/api/v1/moderation").Subrouter()
-	// route_for_moderation.Use(middleware.RequireModerator)
+	// route_for_moderation.Use(middleware.RequirePermission(models.PermModerationManage))
 	r.HandleFunc("/api/v1/moderation/get", moderationController.GetArticles).Methods(http.MethodGet, http.MethodOptions)
 	r.HandleFunc("/api/v1/moderation/add", moderationController.AddArticle).Methods(http.MethodPost, http.MethodOptions)
 	r.HandleFunc("/api/v1/moderation/remove", moderationController.RemoveArticle).Methods(http.MethodDelete, http.MethodOptions)
-	r.HandleFunc("/api/v1/articles", articleController.Insert).Methods(http.MethodPost, http.MethodOptions)
 
 	route_for_favorites := r.PathPrefix("/api/v1/favorites").Subrouter()
 	route_for_favorites.Use(middleware.ValidateToken)
-	route_for_favorites.Use(middleware.RequireUser)
+	route_for_favorites.Use(middleware.RequirePermission(models.PermFavoritesManage))
+	route_for_favorites.Use(middleware.RequireScope(models.ScopeFavoritesWrite))
 	route_for_favorites.HandleFunc("/get", favoritesController.GetByUserId).Methods(http.MethodGet, http.MethodOptions)
 	route_for_favorites.HandleFunc("/add", favoritesController.Add).Methods(http.MethodPost, http.MethodOptions)
 	route_for_favorites.HandleFunc("/delete", favoritesController.Remove).Methods(http.MethodDelete, http.MethodOptions)
 
-	if err := http.ListenAndServe(fmt.Sprintf(":%d", a.cfg.API.Port), r); err != nil {
+	return r
+}
+
+func (a *App) Start() {
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", a.cfg.API.Port),
+		Handler:           a.Router(),
+		ReadHeaderTimeout: a.cfg.API.ReadHeaderTimeout,
+		ReadTimeout:       a.cfg.API.ReadTimeout,
+		WriteTimeout:      a.cfg.API.WriteTimeout,
+		IdleTimeout:       a.cfg.API.IdleTimeout,
+	}
+	a.mu.Lock()
+	a.server = server
+	a.mu.Unlock()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		panic(err)
 	}
 }
+
+// Stop waits for the requests in flight to finish, until ctx is done, and
+// closes the connections to the gRPC services.
+func (a *App) Stop(ctx context.Context) {
+	const op = "app.stop"
+	log := a.log.With(slog.String("op", op))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.server != nil {
+		if err := a.server.Shutdown(ctx); err != nil {
+			log.Error("Failed to shut down HTTP server", sl.Err(err))
+		}
+	}
+
+	for _, conn := range a.conns {
+		if err := conn.Close(); err != nil {
+			log.Error("Failed to close gRPC connection", sl.Err(err))
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"apigateway/pkg/config"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// pathParam matches the variables of a path template. They are compared
+// without their names, since OpenAPI treats paths differing only in them
+// as the same path.
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// TestOpenAPICoversRoutes fails when a route registered on the router has
+// no operation in the served OpenAPI document, or the document describes
+// an operation no route serves.
+func TestOpenAPICoversRoutes(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := &config.Config{}
+	cfg.API.Timeout = 5 * time.Second
+
+	router := New(log, cfg).Router()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/openapi.json: status %d", w.Code)
+	}
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("decode OpenAPI document: %v", err)
+	}
+
+	documented := make(map[string]map[string]bool)
+	for path, item := range spec.Paths {
+		key := pathParam.ReplaceAllString(path, "{}")
+		if documented[key] == nil {
+			documented[key] = make(map[string]bool)
+		}
+		for method := range item {
+			if method != "parameters" {
+				documented[key][strings.ToUpper(method)] = true
+			}
+		}
+	}
+
+	served := make(map[string]map[string]bool)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		key := pathParam.ReplaceAllString(template, "{}")
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			// A route for any method only needs to be documented at all.
+			if len(documented[key]) == 0 {
+				t.Errorf("route %s is missing from the OpenAPI document", template)
+			}
+			for method := range documented[key] {
+				markServed(served, key, method)
+			}
+			return nil
+		}
+
+		for _, method := range methods {
+			if method == http.MethodOptions {
+				continue
+			}
+			if !documented[key][method] {
+				t.Errorf("route %s %s is missing from the OpenAPI document", method, template)
+			}
+			markServed(served, key, method)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+
+	for key, methods := range documented {
+		for method := range methods {
+			if !served[key][method] {
+				t.Errorf("OpenAPI operation %s %s has no route", method, key)
+			}
+		}
+	}
+}
+
+func markServed(served map[string]map[string]bool, key string, method string) {
+	if served[key] == nil {
+		served[key] = make(map[string]bool)
+	}
+	served[key][method] = true
+}
@@ -2,6 +2,7 @@ package agreement
 
 import (
 	"apigateway/internal/domain/models"
+	"apigateway/internal/lib/apierror"
 	"apigateway/pkg/lib/logger/sl"
 	"encoding/json"
 	"log/slog"
@@ -37,7 +38,7 @@ func (a *AgreementHandler) AddArticle(w http.ResponseWriter, r *http.Request) {
 	var article models.Article
 	if err := json.NewDecoder(r.Body).Decode(&article); err != nil {
 		log.Error("cannot read request body", sl.Err(err))
-		http.Error(w, "cannot read request body", http.StatusBadRequest)
+		apierror.Write(w, "cannot read request body", http.StatusBadRequest)
 		return
 	}
 
@@ -54,7 +55,7 @@ func (a *AgreementHandler) RemoveArticle(w http.ResponseWriter, r *http.Request)
 	id, err := uuid.Parse(r.URL.Query().Get("id"))
 	if err != nil {
 		log.Error("invalid UUID format", sl.Err(err))
-		http.Error(w, "invalid UUID format", http.StatusBadRequest)
+		apierror.Write(w, "invalid UUID format", http.StatusBadRequest)
 		return
 	}
 
@@ -67,5 +68,5 @@ func (a *AgreementHandler) RemoveArticle(w http.ResponseWriter, r *http.Request)
 	}
 
 	log.Warn("article not found", "id", id)
-	http.Error(w, "article not found", http.StatusNotFound)
+	apierror.Write(w, "article not found", http.StatusNotFound)
 }
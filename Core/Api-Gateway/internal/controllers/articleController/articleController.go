@@ -3,17 +3,15 @@ package articlecontroller
 import (
 	"apigateway/internal/domain/interfaces/articles"
 	"apigateway/internal/domain/models"
+	"apigateway/internal/lib/apierror"
+	"apigateway/internal/lib/validation"
 	"apigateway/pkg/lib/logger/sl"
-	"context"
 	"encoding/json"
-	"errors"
 	"log/slog"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type ArticleController struct {
@@ -28,26 +26,13 @@ func New(log *slog.Logger, articleService articles.IArticlesService) *ArticleCon
 	}
 }
 
-func (ac *ArticleController) handleError(w http.ResponseWriter, err error, log *slog.Logger) {
-	if errors.Is(err, context.Canceled) {
-		log.Error("Request was canceled by the user")
-		http.Error(w, "Request canceled", http.StatusRequestTimeout)
-	} else if errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded {
-		log.Error("Request time out")
-		http.Error(w, "Request timeout", http.StatusRequestTimeout)
-	} else {
-		log.Error("Operation failed", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
-}
-
 func (ac *ArticleController) GetArticles(w http.ResponseWriter, r *http.Request) {
 	const op = "controllers.articlesController.getArticles"
 	log := ac.log.With(slog.String("op", op))
 
 	articles, err := ac.articleService.GetArticles(r.Context())
 	if err != nil {
-		ac.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -55,7 +40,6 @@ func (ac *ArticleController) GetArticles(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(articles); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	log.Info("Retrieved all articles successfully")
@@ -69,13 +53,13 @@ func (ac *ArticleController) GetArticleById(w http.ResponseWriter, r *http.Reque
 	uuidID, err := uuid.Parse(idStr)
 	if err != nil {
 		log.Error("Invalid UUID format", sl.Err(err))
-		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		apierror.Write(w, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
 	article, err := ac.articleService.GetArticleById(r.Context(), uuidID)
 	if err != nil {
-		ac.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -83,7 +67,6 @@ func (ac *ArticleController) GetArticleById(w http.ResponseWriter, r *http.Reque
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(article); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	log.Info("Retrieved article by ID successfully")
@@ -97,13 +80,13 @@ func (ac *ArticleController) GetArticlesByOwnerId(w http.ResponseWriter, r *http
 	owner_id, err := uuid.Parse(owner_id_s)
 	if err != nil {
 		log.Error("Invalid UUID format", sl.Err(err))
-		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		apierror.Write(w, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
 	article, err := ac.articleService.GetArticleByOwnerId(r.Context(), owner_id)
 	if err != nil {
-		ac.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -111,25 +94,50 @@ func (ac *ArticleController) GetArticlesByOwnerId(w http.ResponseWriter, r *http
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(article); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	log.Info("Retrieved article by OwnerID successfully")
 }
 
 func (ac *ArticleController) Insert(w http.ResponseWriter, r *http.Request) {
-	const op = "controllers.articlesController.getArticlesByOwnerId"
+	const op = "controllers.articlesController.insert"
 	log := ac.log.With(slog.String("op", op))
 
 	var article models.Article
 	if err := json.NewDecoder(r.Body).Decode(&article); err != nil {
 		log.Error("Cannot parse request body", sl.Err(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if fields := validation.Struct(article); fields != nil {
+		log.Warn("Invalid article", slog.Int("violations", len(fields)))
+		apierror.Write(w, "Invalid article", http.StatusBadRequest, fields...)
 		return
 	}
 
+	claims, ok := r.Context().Value("claims").(*models.Claims)
+	if !ok {
+		log.Error("Claims not found")
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	uid, err := claims.UserId()
+	if err != nil {
+		log.Error("Invalid user id in claims", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if article.OwnerId != uuid.Nil && article.OwnerId != uid {
+		log.Warn("Owner does not match the authenticated user")
+		apierror.Write(w, "owner_id does not match the authenticated user", http.StatusForbidden)
+		return
+	}
+	article.OwnerId = uid
+
 	if _, err := ac.articleService.Insert(r.Context(), article); err != nil {
-		ac.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -145,19 +153,27 @@ func (ac *ArticleController) Update(w http.ResponseWriter, r *http.Request) {
 	article_id, err := uuid.Parse(article_id_s)
 	if err != nil {
 		log.Error("Cannot parse request body", sl.Err(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	var article models.Article
 	if err := json.NewDecoder(r.Body).Decode(&article); err != nil {
 		log.Error("failed decode request body", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Only the title, the content and the tag of an article can be changed.
+	// All three are stored, so a missing tag is rejected rather than erased.
+	if fields := validation.Partial(article, "Title", "Content", "Tag"); fields != nil {
+		log.Warn("Invalid article", slog.Int("violations", len(fields)))
+		apierror.Write(w, "Invalid article", http.StatusBadRequest, fields...)
 		return
 	}
 
 	if _, err := ac.articleService.Update(r.Context(), article_id, article); err != nil {
-		ac.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -173,13 +189,13 @@ func (ac *ArticleController) Delete(w http.ResponseWriter, r *http.Request) {
 	article_id, err := uuid.Parse(article_id_s)
 	if err != nil {
 		log.Error("Invalid UUID format", sl.Err(err))
-		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		apierror.Write(w, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
 	article, err := ac.articleService.Delete(r.Context(), article_id)
 	if err != nil {
-		ac.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -188,7 +204,6 @@ func (ac *ArticleController) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(article); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -2,42 +2,41 @@ package authcontroller
 
 import (
 	"apigateway/internal/domain/models"
-	tokenparser "apigateway/internal/lib/jwt/tokenParser"
+	"apigateway/internal/lib/apierror"
+	"apigateway/internal/lib/apps"
+	"apigateway/internal/lib/clientip"
+	"apigateway/internal/lib/validation"
 	authservice "apigateway/internal/services/auth"
-	"apigateway/internal/storage/cache"
 	"apigateway/pkg/lib/logger/sl"
-	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+const refreshTokenCookie = "refresh_token"
+
 type AuthController struct {
 	log          *slog.Logger
 	auth_service *authservice.AuthService
+	clients      *clientip.Resolver
 }
 
-func New(log *slog.Logger, auth_service *authservice.AuthService) *AuthController {
+func New(log *slog.Logger, auth_service *authservice.AuthService, clients *clientip.Resolver) *AuthController {
 	return &AuthController{
 		log:          log,
 		auth_service: auth_service,
-	}
-}
-
-func (ac *AuthController) handleError(w http.ResponseWriter, err error, log *slog.Logger) {
-	if errors.Is(err, context.Canceled) {
-		log.Error("Request was canceled by the user.")
-		http.Error(w, "Request canceled", http.StatusRequestTimeout)
-	} else if errors.Is(err, context.DeadlineExceeded) {
-		log.Error("Request time out.")
-		http.Error(w, "Request timeout", http.StatusRequestTimeout)
-	} else {
-		log.Error("Error occurred", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		clients:      clients,
 	}
 }
 
@@ -56,7 +55,7 @@ func (ac *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 	body, err := ac.readRequestBody(r)
 	if err != nil {
 		log.Error("Bad request", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
@@ -66,40 +65,1069 @@ func (ac *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 	}
 	if err := json.Unmarshal(body, &user_credentials); err != nil {
 		log.Error("Bad request", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	accessToken, refreshToken, err := ac.auth_service.Login(r.Context(), user_credentials.Email, user_credentials.Password)
+	var app models.AppCredentials
+	if appId := r.Header.Get(apps.IdHeader); appId != "" {
+		app.Id, err = uuid.Parse(appId)
+		if err != nil {
+			log.Error("Invalid app id", sl.Err(err))
+			apierror.Write(w, "Unknown app", http.StatusUnauthorized)
+			return
+		}
+		app.Secret = r.Header.Get(apps.SecretHeader)
+	}
+
+	result, err := ac.auth_service.Login(r.Context(), user_credentials.Email, user_credentials.Password, app, models.Device{
+		UserAgent: r.UserAgent(),
+		Ip:        ac.clients.FromRequest(r),
+	})
 	if err != nil {
-		ac.handleError(w, err, log)
+		switch status.Code(err) {
+		case codes.Unauthenticated:
+			// Auth tells a wrong password from an unknown app in the message.
+			log.Warn("Login rejected", sl.Err(err))
+			apierror.Write(w, apierror.Message(err), http.StatusUnauthorized)
+			return
+		case codes.FailedPrecondition:
+			log.Warn("Email is not verified", sl.Err(err))
+			apierror.Write(w, "Email is not verified", http.StatusForbidden)
+			return
+		case codes.ResourceExhausted:
+			ac.writeTooManyAttempts(w, err, log)
+			return
+		}
+		apierror.FromError(w, err, log)
 		return
 	}
 
-	cache := cache.New()
-	claimsFromToken, _ := tokenparser.ParseToken(accessToken)
-	parsedUIDToUUID, _ := uuid.Parse(claimsFromToken.Uid)
-	cache.Add(parsedUIDToUUID, refreshToken)
+	// The password was right but a second factor is needed: the client
+	// answers the challenge at /login/2fa.
+	if result.ChallengeToken != "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(map[string]string{
+			"challenge_token": result.ChallengeToken,
+			"challenge_type":  result.ChallengeType,
+		}); err != nil {
+			log.Error("Failed to encode response", sl.Err(err))
+			return
+		}
+		log.Info("Second factor required")
+		return
+	}
 
+	ac.setRefreshTokenCookie(w, result.RefreshToken)
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(accessToken))
+	w.Write([]byte(result.AccessToken))
 	log.Info("Login succeeded")
 }
 
+// writeTooManyAttempts answers a locked out login with 429, passing on how
+// long the lockout lasts in Retry-After when Auth reported it.
+func (ac *AuthController) writeTooManyAttempts(w http.ResponseWriter, err error, log *slog.Logger) {
+	log.Warn("Login is locked out", sl.Err(err))
+
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			seconds := math.Ceil(info.GetRetryDelay().AsDuration().Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(max(int(seconds), 1)))
+			break
+		}
+	}
+
+	apierror.Write(w, "Too many failed login attempts, try again later", http.StatusTooManyRequests)
+}
+
+// LoginTotp finishes a login that Login answered with a challenge. The code
+// is a TOTP code or a recovery code; for a "totp_enrollment" challenge it
+// is the first code of the secret from BeginLoginTotpEnrollment, and the
+// new recovery codes are returned with the tokens.
+func (ac *AuthController) LoginTotp(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.loginTotp"
+	log := ac.log.With(slog.String("op", op))
+
+	var body struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.Error("Bad request", sl.Err(err))
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if body.ChallengeToken == "" || body.Code == "" {
+		log.Error("Challenge token and code are required")
+		apierror.Write(w, "Challenge token and code are required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := ac.auth_service.VerifyTotpLogin(r.Context(), body.ChallengeToken, body.Code, models.Device{
+		UserAgent: r.UserAgent(),
+		Ip:        ac.clients.FromRequest(r),
+	})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Unauthenticated:
+			log.Warn("Challenge rejected", sl.Err(err))
+			apierror.Write(w, "Invalid or expired challenge", http.StatusUnauthorized)
+			return
+		case codes.InvalidArgument:
+			log.Warn("Code rejected", sl.Err(err))
+			apierror.Write(w, "Invalid code", http.StatusUnauthorized)
+			return
+		case codes.FailedPrecondition:
+			log.Warn("Enrollment not started", sl.Err(err))
+			apierror.Write(w, "TOTP enrollment not started", http.StatusConflict)
+			return
+		case codes.ResourceExhausted:
+			ac.writeTooManyAttempts(w, err, log)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	ac.setRefreshTokenCookie(w, result.RefreshToken)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(struct {
+		AccessToken   string   `json:"access_token"`
+		RefreshToken  string   `json:"refresh_token"`
+		RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	}{
+		AccessToken:   result.AccessToken,
+		RefreshToken:  result.RefreshToken,
+		RecoveryCodes: result.RecoveryCodes,
+	}); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+	log.Info("Login with second factor succeeded")
+}
+
+// BeginLoginTotpEnrollment creates a TOTP secret for a user who must enroll
+// before their login can finish, authenticated by the challenge token.
+func (ac *AuthController) BeginLoginTotpEnrollment(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.beginLoginTotpEnrollment"
+	log := ac.log.With(slog.String("op", op))
+
+	var body struct {
+		ChallengeToken string `json:"challenge_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.Error("Bad request", sl.Err(err))
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if body.ChallengeToken == "" {
+		log.Error("Challenge token is required")
+		apierror.Write(w, "Challenge token is required", http.StatusBadRequest)
+		return
+	}
+
+	enrollment, err := ac.auth_service.BeginTotpEnrollmentWithChallenge(r.Context(), body.ChallengeToken)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Unauthenticated:
+			log.Warn("Challenge rejected", sl.Err(err))
+			apierror.Write(w, "Invalid or expired challenge", http.StatusUnauthorized)
+			return
+		case codes.AlreadyExists:
+			log.Warn("TOTP is already enabled", sl.Err(err))
+			apierror.Write(w, "TOTP is already enabled", http.StatusConflict)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	ac.writeTotpEnrollment(w, enrollment, log)
+}
+
+// BeginTotpEnrollment creates a TOTP secret for the current user. It only
+// protects logins after ConfirmTotpEnrollment.
+func (ac *AuthController) BeginTotpEnrollment(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.beginTotpEnrollment"
+	log := ac.log.With(slog.String("op", op))
+
+	uid, _, err := ac.claimsIds(r)
+	if err != nil {
+		log.Warn("Token has no session", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	enrollment, err := ac.auth_service.BeginTotpEnrollment(r.Context(), uid)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			log.Warn("TOTP is already enabled", sl.Err(err))
+			apierror.Write(w, "TOTP is already enabled", http.StatusConflict)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	ac.writeTotpEnrollment(w, enrollment, log)
+}
+
+func (ac *AuthController) writeTotpEnrollment(w http.ResponseWriter, enrollment models.TotpEnrollment, log *slog.Logger) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(enrollment); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+	log.Info("TOTP enrollment started")
+}
+
+// ConfirmTotpEnrollment enables the pending secret of the current user and
+// returns the recovery codes. They are shown only this once.
+func (ac *AuthController) ConfirmTotpEnrollment(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.confirmTotpEnrollment"
+	log := ac.log.With(slog.String("op", op))
+
+	uid, _, err := ac.claimsIds(r)
+	if err != nil {
+		log.Warn("Token has no session", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.Error("Bad request", sl.Err(err))
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if body.Code == "" {
+		log.Error("Code is required")
+		apierror.Write(w, "Code is required", http.StatusBadRequest)
+		return
+	}
+
+	recoveryCodes, err := ac.auth_service.ConfirmTotpEnrollment(r.Context(), uid, body.Code)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			log.Warn("Code rejected", sl.Err(err))
+			apierror.Write(w, "Invalid code", http.StatusBadRequest)
+			return
+		case codes.FailedPrecondition:
+			log.Warn("Enrollment not started", sl.Err(err))
+			apierror.Write(w, "TOTP enrollment not started", http.StatusConflict)
+			return
+		case codes.AlreadyExists:
+			log.Warn("TOTP is already enabled", sl.Err(err))
+			apierror.Write(w, "TOTP is already enabled", http.StatusConflict)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string][]string{
+		"recovery_codes": recoveryCodes,
+	}); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+	log.Info("TOTP enabled")
+}
+
+// DisableTotp turns TOTP off for the current user. It takes a current code
+// or a recovery code.
+func (ac *AuthController) DisableTotp(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.disableTotp"
+	log := ac.log.With(slog.String("op", op))
+
+	uid, _, err := ac.claimsIds(r)
+	if err != nil {
+		log.Warn("Token has no session", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		log.Error("Bad request", sl.Err(err))
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := ac.auth_service.DisableTotp(r.Context(), uid, body.Code); err != nil {
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			log.Warn("Code rejected", sl.Err(err))
+			apierror.Write(w, "Invalid code", http.StatusBadRequest)
+			return
+		case codes.FailedPrecondition:
+			log.Warn("TOTP is not set up", sl.Err(err))
+			apierror.Write(w, "TOTP is not set up", http.StatusConflict)
+			return
+		case codes.PermissionDenied:
+			log.Warn("TOTP is required", sl.Err(err))
+			apierror.Write(w, "TOTP is required for your role", http.StatusForbidden)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Info("TOTP disabled")
+}
+
+// Refresh exchanges a refresh token, taken from the cookie set by Login or
+// from the request body, for a new access/refresh pair.
+func (ac *AuthController) Refresh(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.refresh"
+	log := ac.log.With(slog.String("op", op))
+
+	var refreshToken string
+	if cookie, err := r.Cookie(refreshTokenCookie); err == nil {
+		refreshToken = cookie.Value
+	} else {
+		var body struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			log.Error("Bad request", sl.Err(err))
+			apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		refreshToken = body.RefreshToken
+	}
+
+	if refreshToken == "" {
+		log.Error("Refresh token is required")
+		apierror.Write(w, "Refresh token is required", http.StatusBadRequest)
+		return
+	}
+
+	accessToken, newRefreshToken, err := ac.auth_service.Refresh(r.Context(), refreshToken)
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			log.Warn("Refresh token rejected", sl.Err(err))
+			ac.setRefreshTokenCookie(w, "")
+			apierror.Write(w, "Invalid refresh token", http.StatusUnauthorized)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	ac.setRefreshTokenCookie(w, newRefreshToken)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string]string{
+		"access_token":  accessToken,
+		"refresh_token": newRefreshToken,
+	}); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+	log.Info("Refresh succeeded")
+}
+
+// setRefreshTokenCookie stores the refresh token in an HttpOnly cookie; an
+// empty token clears it.
+func (ac *AuthController) setRefreshTokenCookie(w http.ResponseWriter, refreshToken string) {
+	cookie := &http.Cookie{
+		Name:     refreshTokenCookie,
+		Value:    refreshToken,
+		Path:     "/api/v1",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if refreshToken == "" {
+		cookie.MaxAge = -1
+	}
+
+	http.SetCookie(w, cookie)
+}
+
+// claimsIds extracts the user and session ids from the claims stored by
+// middleware.ValidateToken.
+func (ac *AuthController) claimsIds(r *http.Request) (uuid.UUID, uuid.UUID, error) {
+	claims, ok := r.Context().Value("claims").(*models.Claims)
+	if !ok {
+		return uuid.Nil, uuid.Nil, errors.New("claims not found")
+	}
+
+	uid, err := uuid.Parse(claims.Uid)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	sid, err := uuid.Parse(claims.Sid)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return uid, sid, nil
+}
+
+// Logout revokes the session the access token was issued for.
+func (ac *AuthController) Logout(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.logout"
+	log := ac.log.With(slog.String("op", op))
+
+	uid, sid, err := ac.claimsIds(r)
+	if err != nil {
+		log.Warn("Token has no session", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := ac.auth_service.Logout(r.Context(), uid, sid); err != nil && status.Code(err) != codes.NotFound {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	ac.setRefreshTokenCookie(w, "")
+	w.WriteHeader(http.StatusNoContent)
+	log.Info("Logout succeeded")
+}
+
+// LogoutAll revokes every session of the current user, including this one.
+func (ac *AuthController) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.logoutAll"
+	log := ac.log.With(slog.String("op", op))
+
+	uid, _, err := ac.claimsIds(r)
+	if err != nil {
+		log.Warn("Token has no session", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	revoked, err := ac.auth_service.LogoutAll(r.Context(), uid)
+	if err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	ac.setRefreshTokenCookie(w, "")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string]int64{
+		"revoked": revoked,
+	}); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+	log.Info("Logout from all sessions succeeded")
+}
+
+// GetSessions lists the active sessions of the current user.
+func (ac *AuthController) GetSessions(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.getSessions"
+	log := ac.log.With(slog.String("op", op))
+
+	uid, sid, err := ac.claimsIds(r)
+	if err != nil {
+		log.Warn("Token has no session", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := ac.auth_service.GetSessions(r.Context(), uid)
+	if err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].Id == sid
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(sessions); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+	log.Info("Retrieved sessions successfully")
+}
+
+// RevokeSession revokes one of the current user's sessions by id.
+func (ac *AuthController) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.revokeSession"
+	log := ac.log.With(slog.String("op", op))
+
+	uid, currentSid, err := ac.claimsIds(r)
+	if err != nil {
+		log.Warn("Token has no session", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sid, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		log.Error("Invalid UUID format", sl.Err(err))
+		apierror.Write(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	if err := ac.auth_service.Logout(r.Context(), uid, sid); err != nil {
+		if status.Code(err) == codes.NotFound {
+			log.Warn("Session not found", sl.Err(err))
+			apierror.Write(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	if sid == currentSid {
+		ac.setRefreshTokenCookie(w, "")
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Info("Session revoked")
+}
+
+// VerifyEmail confirms the address of an account. The token comes either from
+// the query string, when the link from the email is opened directly, or from
+// the JSON body.
+func (ac *AuthController) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.verifyEmail"
+	log := ac.log.With(slog.String("op", op))
+
+	token := r.URL.Query().Get("token")
+	if token == "" && r.Method == http.MethodPost {
+		var body struct {
+			Token string `json:"token"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			log.Error("Bad request", sl.Err(err))
+			apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		token = body.Token
+	}
+
+	if token == "" {
+		log.Error("Token is required")
+		apierror.Write(w, "Token is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := ac.auth_service.VerifyEmail(r.Context(), token); err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			log.Warn("Verification token rejected", sl.Err(err))
+			apierror.Write(w, "Invalid or expired token", http.StatusBadRequest)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Email verified"))
+	log.Info("Email verified")
+}
+
+// ResendVerificationEmail always answers 202 so it cannot be used to find
+// out which emails are registered.
+func (ac *AuthController) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.resendVerificationEmail"
+	log := ac.log.With(slog.String("op", op))
+
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.Error("Bad request", sl.Err(err))
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if body.Email == "" {
+		log.Error("Email is required")
+		apierror.Write(w, "Email is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := ac.auth_service.ResendVerificationEmail(r.Context(), body.Email); err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	log.Info("Verification email requested")
+}
+
+// RequestPasswordReset always answers 202, whether or not the email belongs
+// to an account.
+func (ac *AuthController) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.requestPasswordReset"
+	log := ac.log.With(slog.String("op", op))
+
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.Error("Bad request", sl.Err(err))
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if body.Email == "" {
+		log.Error("Email is required")
+		apierror.Write(w, "Email is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := ac.auth_service.RequestPasswordReset(r.Context(), body.Email); err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	log.Info("Password reset requested")
+}
+
+// ConfirmPasswordReset sets a new password using the token from the reset
+// email. All sessions of the user are revoked, so the refresh cookie of
+// this client is cleared too.
+func (ac *AuthController) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.confirmPasswordReset"
+	log := ac.log.With(slog.String("op", op))
+
+	var body struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.Error("Bad request", sl.Err(err))
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if body.Token == "" || body.Password == "" {
+		log.Error("Token and password are required")
+		apierror.Write(w, "Token and password are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := ac.auth_service.ConfirmPasswordReset(r.Context(), body.Token, body.Password); err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			log.Warn("Reset token rejected", sl.Err(err))
+			apierror.Write(w, "Invalid or expired token", http.StatusBadRequest)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	ac.setRefreshTokenCookie(w, "")
+	w.WriteHeader(http.StatusNoContent)
+	log.Info("Password reset")
+}
+
+// UnlockAccount lifts the login lockout of an account. It is meant for user
+// admins helping someone who was locked out.
+func (ac *AuthController) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.unlockAccount"
+	log := ac.log.With(slog.String("op", op))
+
+	uid, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		log.Error("Invalid UUID format", sl.Err(err))
+		apierror.Write(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	if err := ac.auth_service.UnlockAccount(r.Context(), uid); err != nil {
+		if status.Code(err) == codes.NotFound {
+			log.Warn("User not found", sl.Err(err))
+			apierror.Write(w, "User not found", http.StatusNotFound)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Info("Account unlocked", slog.String("uid", uid.String()))
+}
+
+// CreatePersonalAccessToken issues a named token with the requested scopes
+// for the current user. The token itself is only returned in this response.
+func (ac *AuthController) CreatePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.createPersonalAccessToken"
+	log := ac.log.With(slog.String("op", op))
+
+	uid, _, err := ac.claimsIds(r)
+	if err != nil {
+		log.Warn("Token has no session", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.Error("Bad request", sl.Err(err))
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if body.Name == "" {
+		log.Error("Name is required")
+		apierror.Write(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+
+	var expiresAt time.Time
+	if body.ExpiresAt != nil {
+		expiresAt = *body.ExpiresAt
+	}
+
+	token, pat, err := ac.auth_service.CreatePersonalAccessToken(r.Context(), uid, body.Name, body.Scopes, expiresAt)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			log.Warn("Token request rejected", sl.Err(err))
+			apierror.Write(w, apierror.Message(err), http.StatusBadRequest)
+			return
+		case codes.FailedPrecondition:
+			log.Warn("Too many tokens", sl.Err(err))
+			apierror.Write(w, "Too many personal access tokens", http.StatusConflict)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(struct {
+		Token               string                     `json:"token"`
+		PersonalAccessToken models.PersonalAccessToken `json:"personal_access_token"`
+	}{
+		Token:               token,
+		PersonalAccessToken: pat,
+	}); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+	log.Info("Personal access token created", slog.String("id", pat.Id.String()))
+}
+
+// ListPersonalAccessTokens lists the tokens of the current user, without the
+// token values.
+func (ac *AuthController) ListPersonalAccessTokens(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.listPersonalAccessTokens"
+	log := ac.log.With(slog.String("op", op))
+
+	uid, _, err := ac.claimsIds(r)
+	if err != nil {
+		log.Warn("Token has no session", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	pats, err := ac.auth_service.ListPersonalAccessTokens(r.Context(), uid)
+	if err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	if pats == nil {
+		pats = []models.PersonalAccessToken{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(pats); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+	log.Info("Retrieved personal access tokens successfully")
+}
+
+// RevokePersonalAccessToken deletes one of the current user's tokens by id.
+func (ac *AuthController) RevokePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.revokePersonalAccessToken"
+	log := ac.log.With(slog.String("op", op))
+
+	uid, _, err := ac.claimsIds(r)
+	if err != nil {
+		log.Warn("Token has no session", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		log.Error("Invalid UUID format", sl.Err(err))
+		apierror.Write(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	if err := ac.auth_service.RevokePersonalAccessToken(r.Context(), uid, id); err != nil {
+		if status.Code(err) == codes.NotFound {
+			log.Warn("Personal access token not found", sl.Err(err))
+			apierror.Write(w, "Personal access token not found", http.StatusNotFound)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Info("Personal access token revoked", slog.String("id", id.String()))
+}
+
+// CreateApp registers a client application. The secret is only returned in
+// this response and by RotateAppSecret.
+func (ac *AuthController) CreateApp(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.createApp"
+	log := ac.log.With(slog.String("op", op))
+
+	var body struct {
+		Name         string   `json:"name"`
+		RedirectUris []string `json:"redirect_uris"`
+		Scopes       []string `json:"scopes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.Error("Bad request", sl.Err(err))
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if body.Name == "" {
+		log.Error("Name is required")
+		apierror.Write(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+
+	secret, app, err := ac.auth_service.CreateApp(r.Context(), body.Name, body.RedirectUris, body.Scopes)
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			log.Warn("App rejected", sl.Err(err))
+			apierror.Write(w, apierror.Message(err), http.StatusBadRequest)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	ac.writeAppSecret(w, http.StatusCreated, secret, app, log)
+	log.Info("App created", slog.String("app_id", app.Id.String()))
+}
+
+// writeAppSecret answers with an app together with its freshly issued
+// secret.
+func (ac *AuthController) writeAppSecret(w http.ResponseWriter, code int, secret string, app models.App, log *slog.Logger) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(struct {
+		Secret string     `json:"secret"`
+		App    models.App `json:"app"`
+	}{
+		Secret: secret,
+		App:    app,
+	}); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+	}
+}
+
+// ListApps lists every registered app, revoked ones included.
+func (ac *AuthController) ListApps(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.listApps"
+	log := ac.log.With(slog.String("op", op))
+
+	list, err := ac.auth_service.ListApps(r.Context())
+	if err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+	log.Info("Retrieved apps successfully")
+}
+
+func (ac *AuthController) ListRoles(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.listRoles"
+	log := ac.log.With(slog.String("op", op))
+
+	roles, err := ac.auth_service.ListRoles(r.Context())
+	if err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(roles); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+	log.Info("Retrieved roles successfully")
+}
+
+func (ac *AuthController) GetApp(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.getApp"
+	log := ac.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		log.Error("Invalid UUID format", sl.Err(err))
+		apierror.Write(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	app, err := ac.auth_service.GetApp(r.Context(), id)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			log.Warn("App not found", sl.Err(err))
+			apierror.Write(w, "App not found", http.StatusNotFound)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(app); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+}
+
+// RotateAppSecret replaces the secret of an app. The old one stops working
+// at once.
+func (ac *AuthController) RotateAppSecret(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.rotateAppSecret"
+	log := ac.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		log.Error("Invalid UUID format", sl.Err(err))
+		apierror.Write(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	secret, app, err := ac.auth_service.RotateAppSecret(r.Context(), id)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			log.Warn("App not found", sl.Err(err))
+			apierror.Write(w, "App not found", http.StatusNotFound)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	ac.writeAppSecret(w, http.StatusOK, secret, app, log)
+	log.Info("App secret rotated", slog.String("app_id", id.String()))
+}
+
+// RevokeApp disables an app: logins through it are refused and the tokens
+// it holds stop being accepted.
+func (ac *AuthController) RevokeApp(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.revokeApp"
+	log := ac.log.With(slog.String("op", op))
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		log.Error("Invalid UUID format", sl.Err(err))
+		apierror.Write(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	if err := ac.auth_service.RevokeApp(r.Context(), id); err != nil {
+		if status.Code(err) == codes.NotFound {
+			log.Warn("App not found", sl.Err(err))
+			apierror.Write(w, "App not found", http.StatusNotFound)
+			return
+		}
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Info("App revoked", slog.String("app_id", id.String()))
+}
+
+// JWKS publishes the keys access tokens can be verified with.
+func (ac *AuthController) JWKS(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.auth.jwks"
+	log := ac.log.With(slog.String("op", op))
+
+	jwks, err := ac.auth_service.GetJWKS(r.Context())
+	if err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string][]models.Jwk{
+		"keys": jwks,
+	}); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+}
+
+// registration is a new account, which unlike an updated one needs a
+// password.
+type registration struct {
+	models.User
+	Password string `json:"password" validate:"required,max=72"`
+}
+
 func (ac *AuthController) Register(w http.ResponseWriter, r *http.Request) {
 	const op = "controllers.auth.register"
 	log := ac.log.With(slog.String("op", op))
 
-	var user models.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+	var body registration
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		log.Error("Bad request", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if fields := validation.Struct(body); fields != nil {
+		log.Warn("Invalid user", slog.Int("violations", len(fields)))
+		apierror.Write(w, "Invalid user", http.StatusBadRequest, fields...)
 		return
 	}
 
-	user.Role = "user"
+	user := body.User
+	user.Password = body.Password
 	if err := ac.auth_service.Register(r.Context(), user); err != nil {
-		ac.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
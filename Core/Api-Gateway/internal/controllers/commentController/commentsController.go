@@ -3,17 +3,15 @@ package commentsmanagercontroller
 import (
 	"apigateway/internal/domain/interfaces/comments"
 	"apigateway/internal/domain/models"
+	"apigateway/internal/lib/apierror"
+	"apigateway/internal/lib/validation"
 	"apigateway/pkg/lib/logger/sl"
-	"context"
 	"encoding/json"
-	"errors"
 	"log/slog"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type CommentController struct {
@@ -28,19 +26,6 @@ func New(log *slog.Logger, commentsService comments.CommentsService) *CommentCon
 	}
 }
 
-func (cs *CommentController) handleError(w http.ResponseWriter, err error, log *slog.Logger) {
-	if errors.Is(err, context.Canceled) {
-		log.Error("Request was canceled by the user")
-		http.Error(w, "Request canceled", http.StatusRequestTimeout)
-	} else if errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded {
-		log.Error("Request time out")
-		http.Error(w, "Request timeout", http.StatusRequestTimeout)
-	} else {
-		log.Error("Operation failed", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
-}
-
 func (cs *CommentController) GetCommentById(w http.ResponseWriter, r *http.Request) {
 	const op = "controllers.commentsController.getCommentById"
 	log := cs.log.With(
@@ -50,20 +35,20 @@ func (cs *CommentController) GetCommentById(w http.ResponseWriter, r *http.Reque
 	id_s := mux.Vars(r)["id"]
 	if id_s == "" {
 		log.Error("Failed to get id")
-		http.Error(w, "failed to get id", http.StatusBadRequest)
+		apierror.Write(w, "failed to get id", http.StatusBadRequest)
 		return
 	}
 
 	parsedUUID, err := uuid.Parse(id_s)
 	if err != nil {
 		log.Error("Invalid id, must be uuid", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	comment, err := cs.commentService.GetCommentById(r.Context(), parsedUUID)
 	if err != nil {
-		cs.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -71,7 +56,6 @@ func (cs *CommentController) GetCommentById(w http.ResponseWriter, r *http.Reque
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(comment); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	log.Info("Retrieved comment by ID successfully")
@@ -86,20 +70,20 @@ func (cs *CommentController) GetCommentsByArticleId(w http.ResponseWriter, r *ht
 	id_s := mux.Vars(r)["article_id"]
 	if id_s == "" {
 		log.Error("Failed to get article_id")
-		http.Error(w, "failed to get article_id", http.StatusBadRequest)
+		apierror.Write(w, "failed to get article_id", http.StatusBadRequest)
 		return
 	}
 
 	parsedUUID, err := uuid.Parse(id_s)
 	if err != nil {
 		log.Error("Invalid article_id, must be uuid", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	comments, err := cs.commentService.GetCommentsByArticleId(r.Context(), parsedUUID)
 	if err != nil {
-		cs.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -107,7 +91,6 @@ func (cs *CommentController) GetCommentsByArticleId(w http.ResponseWriter, r *ht
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(comments); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	log.Info("Retrieved comments by article_id successfully")
@@ -122,13 +105,39 @@ func (cs *CommentController) Insert(w http.ResponseWriter, r *http.Request) {
 	var comment models.Comment
 	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
 		log.Error("Cannot parse request body", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if fields := validation.Struct(comment); fields != nil {
+		log.Warn("Invalid comment", slog.Int("violations", len(fields)))
+		apierror.Write(w, "Invalid comment", http.StatusBadRequest, fields...)
+		return
+	}
+
+	claims, ok := r.Context().Value("claims").(*models.Claims)
+	if !ok {
+		log.Error("Claims not found")
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	uid, err := claims.UserId()
+	if err != nil {
+		log.Error("Invalid user id in claims", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if comment.OwnerId != uuid.Nil && comment.OwnerId != uid {
+		log.Warn("Owner does not match the authenticated user")
+		apierror.Write(w, "owner_id does not match the authenticated user", http.StatusForbidden)
 		return
 	}
+	comment.OwnerId = uid
 
-	comment, err := cs.commentService.Insert(r.Context(), comment)
+	comment, err = cs.commentService.Insert(r.Context(), comment)
 	if err != nil {
-		cs.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -136,7 +145,6 @@ func (cs *CommentController) Insert(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(comment); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	log.Info("Inserting comment successfully")
@@ -151,20 +159,20 @@ func (cs *CommentController) Delete(w http.ResponseWriter, r *http.Request) {
 	id_s := mux.Vars(r)["id"]
 	if id_s == "" {
 		log.Error("Failed to get id")
-		http.Error(w, "failed to get id", http.StatusBadRequest)
+		apierror.Write(w, "failed to get id", http.StatusBadRequest)
 		return
 	}
 
 	parsedUUID, err := uuid.Parse(id_s)
 	if err != nil {
 		log.Error("Invalid id, must be uuid", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	comment, err := cs.commentService.Delete(r.Context(), parsedUUID)
 	if err != nil {
-		cs.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -172,7 +180,6 @@ func (cs *CommentController) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(comment); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	log.Info("Deleting comment successfully")
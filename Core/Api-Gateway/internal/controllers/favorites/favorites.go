@@ -3,6 +3,7 @@ package favoritescontroller
 import (
 	"apigateway/internal/controllers/favorites/mock"
 	"apigateway/internal/domain/models"
+	"apigateway/internal/lib/apierror"
 	"apigateway/pkg/lib/logger/sl"
 	"encoding/json"
 	"log/slog"
@@ -16,10 +17,10 @@ type FavoritesController struct {
 	db  *mock.MockFavorites
 }
 
-func New(log *slog.Logger) *FavoritesController {
+func New(log *slog.Logger, db *mock.MockFavorites) *FavoritesController {
 	return &FavoritesController{
 		log: log,
-		db:  mock.New(log),
+		db:  db,
 	}
 }
 
@@ -32,13 +33,13 @@ func (f *FavoritesController) GetByUserId(w http.ResponseWriter, r *http.Request
 	id, err := uuid.Parse(r.URL.Query().Get("id"))
 	if err != nil {
 		log.Error("id must be uuid", sl.Err(err))
-		http.Error(w, "id must be uuid", http.StatusBadRequest)
+		apierror.Write(w, "id must be uuid", http.StatusBadRequest)
 		return
 	}
 
 	articles, err := f.db.GetByUserId(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -46,7 +47,7 @@ func (f *FavoritesController) GetByUserId(w http.ResponseWriter, r *http.Request
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(articles); err != nil {
 		log.Error("cannot write to response", sl.Err(err))
-		http.Error(w, "cannot write to response", http.StatusInternalServerError)
+		apierror.Write(w, "cannot write to response", http.StatusInternalServerError)
 		return
 	}
 }
@@ -60,14 +61,14 @@ func (f *FavoritesController) Add(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(r.URL.Query().Get("id"))
 	if err != nil {
 		log.Error("id must be uuid", sl.Err(err))
-		http.Error(w, "id must be uuid", http.StatusBadRequest)
+		apierror.Write(w, "id must be uuid", http.StatusBadRequest)
 		return
 	}
 
 	var article models.Article
 	if err := json.NewDecoder(r.Body).Decode(&article); err != nil {
 		log.Error("error reading request body", sl.Err(err))
-		http.Error(w, "error reading request body", http.StatusBadRequest)
+		apierror.Write(w, "error reading request body", http.StatusBadRequest)
 		return
 	}
 
@@ -89,7 +90,7 @@ func (f *FavoritesController) Remove(w http.ResponseWriter, r *http.Request) {
 
 	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
 		log.Error("error reading request body", sl.Err(err))
-		http.Error(w, "error reading request body", http.StatusBadRequest)
+		apierror.Write(w, "error reading request body", http.StatusBadRequest)
 		return
 	}
 
@@ -0,0 +1,231 @@
+package mecontroller
+
+import (
+	"apigateway/internal/controllers/favorites/mock"
+	"apigateway/internal/domain/interfaces/articles"
+	"apigateway/internal/domain/interfaces/comments"
+	"apigateway/internal/domain/interfaces/users"
+	"apigateway/internal/domain/models"
+	"apigateway/internal/lib/apierror"
+	"apigateway/internal/lib/validation"
+	authservice "apigateway/internal/services/auth"
+	"apigateway/pkg/lib/logger/sl"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MeController serves /api/v1/me, the profile of the user the token was
+// issued to.
+type MeController struct {
+	log            *slog.Logger
+	usersService   users.UsersService
+	authService    *authservice.AuthService
+	articleService articles.IArticlesService
+	commentService comments.CommentsService
+	favorites      *mock.MockFavorites
+}
+
+func New(log *slog.Logger,
+	usersService users.UsersService,
+	authService *authservice.AuthService,
+	articleService articles.IArticlesService,
+	commentService comments.CommentsService,
+	favorites *mock.MockFavorites,
+) *MeController {
+	return &MeController{
+		log:            log,
+		usersService:   usersService,
+		authService:    authService,
+		articleService: articleService,
+		commentService: commentService,
+		favorites:      favorites,
+	}
+}
+
+type profile struct {
+	models.Account
+	ArticlesCount  int `json:"articles_count"`
+	CommentsCount  int `json:"comments_count"`
+	FavoritesCount int `json:"favorites_count"`
+}
+
+// updateRequest lists what a user may change about themselves. Fields left
+// out are kept; a new password needs the current one.
+type updateRequest struct {
+	Nick            *string    `json:"nick" validate:"omitnil,min=1,max=50"`
+	Description     *string    `json:"description"`
+	Birthday        *time.Time `json:"birthday" validate:"omitnil,notfuture"`
+	Password        *string    `json:"password" validate:"omitnil,min=1,max=72"`
+	CurrentPassword string     `json:"current_password" validate:"required_with=Password"`
+}
+
+func (mc *MeController) claims(r *http.Request) (*models.Claims, uuid.UUID, error) {
+	claims, ok := r.Context().Value("claims").(*models.Claims)
+	if !ok {
+		return nil, uuid.Nil, errors.New("claims not found")
+	}
+
+	uid, err := claims.UserId()
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	return claims, uid, nil
+}
+
+// Get returns the user's profile with the number of their articles,
+// comments and favorites.
+func (mc *MeController) Get(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.me.get"
+	log := mc.log.With(slog.String("op", op))
+
+	_, uid, err := mc.claims(r)
+	if err != nil {
+		log.Error("Invalid claims", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := mc.usersService.GetUserById(r.Context(), uid)
+	if err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	userArticles, err := mc.articleService.GetArticleByOwnerId(r.Context(), uid)
+	if err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	userComments, err := mc.commentService.GetCommentsByOwnerId(r.Context(), uid)
+	if err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	userFavorites, err := mc.favorites.GetByUserId(r.Context(), uid)
+	if err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(profile{
+		Account:        user.Account(),
+		ArticlesCount:  len(userArticles),
+		CommentsCount:  len(userComments),
+		FavoritesCount: len(userFavorites),
+	}); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+	log.Info("Retrieved own profile successfully")
+}
+
+// Update changes the user's nick, description, birthday and password.
+func (mc *MeController) Update(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.me.update"
+	log := mc.log.With(slog.String("op", op))
+
+	claims, uid, err := mc.claims(r)
+	if err != nil {
+		log.Error("Invalid claims", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req updateRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		log.Error("Cannot parse request body", sl.Err(err))
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if fields := validation.Struct(req); fields != nil {
+		log.Warn("Invalid profile update", slog.Int("violations", len(fields)))
+		apierror.Write(w, "Invalid profile update", http.StatusBadRequest, fields...)
+		return
+	}
+
+	if req.Password != nil {
+		sid, err := uuid.Parse(claims.Sid)
+		if err != nil {
+			log.Error("Invalid session id in claims", sl.Err(err))
+			apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		revoked, err := mc.authService.ChangePassword(r.Context(), uid, sid, req.CurrentPassword, *req.Password)
+		if err != nil {
+			apierror.FromError(w, err, log)
+			return
+		}
+		log.Info("Password changed", slog.Int64("revoked_sessions", revoked))
+	}
+
+	// The password is not read back; an empty one leaves it as it is.
+	user, err := mc.usersService.GetUserById(r.Context(), uid)
+	if err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	if req.Nick != nil || req.Description != nil || req.Birthday != nil {
+		if req.Nick != nil {
+			user.Nick = *req.Nick
+		}
+		if req.Description != nil {
+			user.Description = *req.Description
+		}
+		if req.Birthday != nil {
+			user.Birthday = *req.Birthday
+		}
+
+		user, err = mc.usersService.Update(r.Context(), uid, user)
+		if err != nil {
+			apierror.FromError(w, err, log)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(user.Account()); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		return
+	}
+	log.Info("Updated own profile successfully")
+}
+
+// Delete removes the user's account and signs them out everywhere.
+func (mc *MeController) Delete(w http.ResponseWriter, r *http.Request) {
+	const op = "controllers.me.delete"
+	log := mc.log.With(slog.String("op", op))
+
+	_, uid, err := mc.claims(r)
+	if err != nil {
+		log.Error("Invalid claims", sl.Err(err))
+		apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if _, err := mc.usersService.Delete(r.Context(), uid); err != nil {
+		apierror.FromError(w, err, log)
+		return
+	}
+
+	if _, err := mc.authService.LogoutAll(r.Context(), uid); err != nil {
+		log.Error("Failed to revoke sessions of deleted user", sl.Err(err))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Info("Deleted own account successfully")
+}
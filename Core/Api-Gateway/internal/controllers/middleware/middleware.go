@@ -2,79 +2,307 @@ package middleware
 
 import (
 	"apigateway/internal/domain/models"
+	"apigateway/internal/lib/apierror"
+	"apigateway/internal/lib/apps"
 	tokenparser "apigateway/internal/lib/jwt/tokenParser"
-	"apigateway/internal/storage/cache"
+	"apigateway/internal/lib/ratelimit"
+	"apigateway/internal/lib/sessions"
+	authservice "apigateway/internal/services/auth"
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Middleware struct {
+	tokenParser *tokenparser.Parser
+	authService *authservice.AuthService
+	apps        *apps.Cache
+	sessions    *sessions.Cache
+	appRequired bool
 }
 
-func New() *Middleware {
-	return &Middleware{}
+func New(tokenParser *tokenparser.Parser, authService *authservice.AuthService, apps *apps.Cache, sessions *sessions.Cache, appRequired bool) *Middleware {
+	return &Middleware{
+		tokenParser: tokenParser,
+		authService: authService,
+		apps:        apps,
+		sessions:    sessions,
+		appRequired: appRequired,
+	}
 }
 
+// appFreePaths never need an app id: the health check and the API docs.
+var appFreePaths = map[string]bool{
+	"/api/v1/health-check": true,
+	"/api/v1/openapi.json": true,
+	"/api/v1/docs":         true,
+}
+
+// ValidateApp rejects requests that name, in the X-App-Id header, an app
+// Auth does not know or has revoked. Requests naming no app pass unless
+// apps are required, in which case only those to appFreePaths do.
+func (m *Middleware) ValidateApp(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get(apps.IdHeader)
+		if header == "" {
+			if m.appRequired && strings.HasPrefix(r.URL.Path, "/api/v1/") && !appFreePaths[r.URL.Path] {
+				apierror.Write(w, "App id is required", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, ok := m.checkApp(w, r, header); !ok {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkApp looks the app up and answers the request itself if it is not
+// usable.
+func (m *Middleware) checkApp(w http.ResponseWriter, r *http.Request, appId string) (models.App, bool) {
+	id, err := uuid.Parse(appId)
+	if err != nil {
+		apierror.Write(w, "Unknown app", http.StatusUnauthorized)
+		return models.App{}, false
+	}
+
+	app, err := m.apps.App(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, apps.ErrUnknownApp):
+			apierror.Write(w, "Unknown app", http.StatusUnauthorized)
+		case errors.Is(err, apps.ErrAppDisabled):
+			apierror.Write(w, "App is disabled", http.StatusForbidden)
+		default:
+			apierror.Write(w, "Failed to check app", http.StatusServiceUnavailable)
+		}
+		return models.App{}, false
+	}
+
+	return app, true
+}
+
+// ValidateToken accepts access tokens issued at login, checked locally
+// against the JWKS, and personal access tokens, checked by Auth. An access
+// token is refused once Auth reports its session ended, which the sessions
+// cache learns within its ttl. A token issued through an app is only accepted while the app is active, and is
+// limited to the app's scopes. The token itself is kept in the context
+// under "token" so it can be passed on to the services.
 func (m *Middleware) ValidateToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			apierror.Write(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
 		tokenString := strings.TrimSpace(strings.Replace(authHeader, "Bearer", "", 1))
 
-		claims, err := tokenparser.ParseToken(tokenString)
+		if strings.HasPrefix(tokenString, models.PersonalAccessTokenPrefix) {
+			claims, err := m.authService.VerifyPersonalAccessToken(r.Context(), tokenString)
+			if err != nil {
+				switch status.Code(err) {
+				case codes.Unauthenticated, codes.InvalidArgument:
+					apierror.Write(w, "Invalid token", http.StatusUnauthorized)
+				default:
+					apierror.Write(w, "Failed to verify token", http.StatusServiceUnavailable)
+				}
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), "claims", claims)
+			ctx = context.WithValue(ctx, "token", tokenString)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		claims, err := m.tokenParser.ParseToken(r.Context(), tokenString)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusUnauthorized)
+			if errors.Is(err, tokenparser.ErrTokenExpired) {
+				apierror.Write(w, "Token has expired", http.StatusUnauthorized)
+				return
+			}
+			apierror.Write(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
 
-		if claims.Exp.Before(time.Now()) {
-			parsedUIDToUUID, _ := uuid.Parse(claims.Uid)
-			cache := cache.New()
-			cache.Delete(parsedUIDToUUID)
+		if claims.AppId != "" {
+			if header := r.Header.Get(apps.IdHeader); header != "" && !strings.EqualFold(header, claims.AppId) {
+				apierror.Write(w, "Token was issued to another app", http.StatusUnauthorized)
+				return
+			}
+
+			app, ok := m.checkApp(w, r, claims.AppId)
+			if !ok {
+				return
+			}
+			claims.Scopes = app.Scopes
+		}
 
-			http.Error(w, "Token has expired", http.StatusUnauthorized)
+		if err := m.sessions.Check(r.Context(), tokenString); err != nil {
+			if errors.Is(err, sessions.ErrInactive) {
+				apierror.Write(w, "Session has ended", http.StatusUnauthorized)
+				return
+			}
+			apierror.Write(w, "Failed to verify token", http.StatusServiceUnavailable)
 			return
 		}
 
 		ctx := context.WithValue(r.Context(), "claims", claims)
+		ctx = context.WithValue(ctx, "token", tokenString)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-func (m *Middleware) RequireUser(next http.Handler) http.Handler {
-	return m.roleMiddleware("user", next)
+// OptionalToken lets anonymous requests through and validates the token of
+// the others, so that handlers can show more to a known caller.
+func (m *Middleware) OptionalToken(next http.Handler) http.Handler {
+	validate := m.ValidateToken(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		validate.ServeHTTP(w, r)
+	})
 }
 
-func (m *Middleware) RequireUserAdmin(next http.Handler) http.Handler {
-	return m.roleMiddleware("user_admin", next)
+// RequestId tags the request with the id the client or proxy sent in
+// X-Request-Id, or a new one, and echoes it on the response so that error
+// bodies and logs can be matched with it.
+func (m *Middleware) RequestId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(apierror.RequestIdHeader)
+		if !validRequestId(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(apierror.RequestIdHeader, id)
+		ctx := context.WithValue(r.Context(), "request_id", id)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
 }
 
-func (m *Middleware) RequireAnalyst(next http.Handler) http.Handler {
-	return m.roleMiddleware("analyst", next)
+func validRequestId(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Deadline gives every request timeout to complete, or the timeout of its
+// route in routes, keyed by path template. The deadline travels with the
+// context into the gRPC calls made for the request.
+func (m *Middleware) Deadline(timeout time.Duration, routes map[string]time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := timeout
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					if routeTimeout, ok := routes[template]; ok {
+						limit = routeTimeout
+					}
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), limit)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RateLimit spends a token of group's budget on every request, from the
+// bucket of the calling user or, for anonymous requests, of the client IP.
+// The user is only known after ValidateToken, so a group mounted before it
+// is always counted per client IP. Refused requests get 429.
+func (m *Middleware) RateLimit(limiter *ratelimit.Limiter, group string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := "ip:" + limiter.ClientIP(r)
+			if claims, ok := r.Context().Value("claims").(*models.Claims); ok {
+				if uid, err := claims.UserId(); err == nil {
+					key = "user:" + uid.String()
+				}
+			}
+
+			res, limit, ok := limiter.Take(r.Context(), group, key)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", limit.Requests, ceilSeconds(limit.Per), limit.Burst))
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				apierror.Write(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
-func (m *Middleware) RequireArticleAdmin(next http.Handler) http.Handler {
-	return m.roleMiddleware("article_admin", next)
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
 }
 
-func (m *Middleware) RequireModerator(next http.Handler) http.Handler {
-	return m.roleMiddleware("moderator", next)
+// RequireScope lets personal access tokens through only if they were granted
+// scope. Session tokens are not restricted by scopes.
+func (m *Middleware) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := r.Context().Value("claims").(*models.Claims)
+
+			if !claims.HasScope(scope) {
+				apierror.Write(w, "Token lacks scope "+scope, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
-func (m *Middleware) roleMiddleware(requiredRole string, next http.Handler) http.Handler {
+// RequireSession keeps personal access tokens away from routes that manage
+// the account itself: sessions, second factor and the tokens themselves.
+func (m *Middleware) RequireSession(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		claims := r.Context().Value("claims").(*models.Claims)
 
-		if claims.Role != requiredRole {
-			http.Error(w, "Forbidden", http.StatusForbidden)
+		if claims.IsPersonalAccessToken() {
+			apierror.Write(w, "Personal access tokens cannot be used here", http.StatusForbidden)
 			return
 		}
 
@@ -82,15 +310,32 @@ func (m *Middleware) roleMiddleware(requiredRole string, next http.Handler) http
 	})
 }
 
+// RequirePermission lets the request through only if the user's roles grant
+// permission.
+func (m *Middleware) RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := r.Context().Value("claims").(*models.Claims)
+
+			if !claims.HasPermission(permission) {
+				apierror.Write(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func (m *Middleware) PreventAccessIfLoggedIn(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader != "" {
 			tokenString := strings.TrimSpace(strings.Replace(authHeader, "Bearer", "", 1))
 
-			_, err := tokenparser.ParseToken(tokenString)
+			_, err := m.tokenParser.ParseToken(r.Context(), tokenString)
 			if err == nil {
-				http.Error(w, "Already logged in", http.StatusForbidden)
+				apierror.Write(w, "Already logged in", http.StatusForbidden)
 				return
 			}
 		}
@@ -102,8 +347,8 @@ func (m *Middleware) PreventAccessIfLoggedIn(next http.Handler) http.Handler {
 func (m *Middleware) CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+apps.IdHeader+", "+apps.SecretHeader)
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
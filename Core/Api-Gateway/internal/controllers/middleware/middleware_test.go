@@ -0,0 +1,584 @@
+package middleware
+
+import (
+	"apigateway/internal/domain/interfaces"
+	"apigateway/internal/domain/models"
+	"apigateway/internal/lib/apps"
+	"apigateway/internal/lib/jwt/jwks"
+	tokenparser "apigateway/internal/lib/jwt/tokenParser"
+	"apigateway/internal/lib/ratelimit"
+	"apigateway/internal/lib/sessions"
+	authservice "apigateway/internal/services/auth"
+	"apigateway/pkg/config"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testKid = "test-key"
+	testPat = models.PersonalAccessTokenPrefix + "valid"
+	// downPat is a personal access token Auth fails to check.
+	downPat = models.PersonalAccessTokenPrefix + "down"
+	// endedSid and downSid name a logged out session and one Auth fails to
+	// check.
+	endedSid = "ended"
+	downSid  = "down"
+)
+
+type keysFetcher []models.Jwk
+
+func (f keysFetcher) GetJWKS(ctx context.Context) ([]models.Jwk, error) {
+	return f, nil
+}
+
+var (
+	activeApp   = uuid.New()
+	disabledApp = uuid.New()
+)
+
+type appsFetcher struct{}
+
+func (appsFetcher) GetApp(ctx context.Context, id uuid.UUID) (models.App, error) {
+	switch id {
+	case activeApp:
+		return models.App{Id: id, Scopes: []string{"stats:read"}}, nil
+	case disabledApp:
+		return models.App{Id: id, Disabled: true}, nil
+	default:
+		return models.App{}, status.Error(codes.NotFound, "app not found")
+	}
+}
+
+// patVerifier is the part of Auth that checks personal access tokens.
+type patVerifier struct {
+	interfaces.Auth
+}
+
+func (patVerifier) VerifyPersonalAccessToken(ctx context.Context, token string) (*models.Claims, error) {
+	switch token {
+	case testPat:
+		return &models.Claims{Uid: uuid.NewString(), Roles: []string{"user"}, TokenId: uuid.NewString(), Scopes: []string{"stats:read"}}, nil
+	case downPat:
+		return nil, status.Error(codes.Unavailable, "auth is down")
+	default:
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+}
+
+// introspector is the part of Auth that reports whether a session has ended.
+type introspector struct{}
+
+func (introspector) Introspect(ctx context.Context, token string) (bool, error) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return false, nil
+	}
+
+	switch claims["sid"] {
+	case endedSid:
+		return false, nil
+	case downSid:
+		return false, status.Error(codes.Unavailable, "auth is down")
+	default:
+		return true, nil
+	}
+}
+
+// newTestMiddleware returns middleware that trusts tokens signed by the
+// returned key.
+func newTestMiddleware(t *testing.T) (*Middleware, ed25519.PrivateKey) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := jwks.New(slog.New(slog.NewTextHandler(io.Discard, nil)), keysFetcher{{
+		Kid: testKid,
+		Kty: "OKP",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(public),
+	}}, time.Hour)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(tokenparser.New(keys, "auth", "redhub"), authservice.New(log, patVerifier{}), apps.New(log, appsFetcher{}, time.Hour), sessions.New(log, introspector{}, time.Hour), false), private
+}
+
+// sign returns a token with claims over a valid access token's, signed by key.
+func sign(t *testing.T, key ed25519.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+
+	all := jwt.MapClaims{
+		"iss":   "auth",
+		"aud":   "redhub",
+		"uid":   uuid.NewString(),
+		"roles": []string{"user"},
+		"sid":   uuid.NewString(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+	}
+	for name, value := range claims {
+		if value == nil {
+			delete(all, name)
+			continue
+		}
+		all[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, all)
+	token.Header["kid"] = testKid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestValidateToken(t *testing.T) {
+	_, otherKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		// header returns the Authorization header, given the trusted key.
+		header     func(t *testing.T, key ed25519.PrivateKey) string
+		appHeader  string
+		wantStatus int
+		wantScopes []string
+	}{
+		{
+			name:       "valid token",
+			header:     func(t *testing.T, key ed25519.PrivateKey) string { return "Bearer " + sign(t, key, nil) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "no header",
+			header:     func(t *testing.T, key ed25519.PrivateKey) string { return "" },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "expired",
+			header: func(t *testing.T, key ed25519.PrivateKey) string {
+				return "Bearer " + sign(t, key, jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "refresh token",
+			header: func(t *testing.T, key ed25519.PrivateKey) string {
+				return "Bearer " + sign(t, key, jwt.MapClaims{"aud": "auth", "typ": "refresh"})
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "other issuer",
+			header: func(t *testing.T, key ed25519.PrivateKey) string {
+				return "Bearer " + sign(t, key, jwt.MapClaims{"iss": "other"})
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "unknown key",
+			header:     func(t *testing.T, key ed25519.PrivateKey) string { return "Bearer " + sign(t, otherKey, nil) },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "without a user",
+			header: func(t *testing.T, key ed25519.PrivateKey) string {
+				return "Bearer " + sign(t, key, jwt.MapClaims{"uid": nil})
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "token of an ended session",
+			header: func(t *testing.T, key ed25519.PrivateKey) string {
+				return "Bearer " + sign(t, key, jwt.MapClaims{"sid": endedSid})
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "session check unavailable",
+			header: func(t *testing.T, key ed25519.PrivateKey) string {
+				return "Bearer " + sign(t, key, jwt.MapClaims{"sid": downSid})
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "token of an app",
+			header: func(t *testing.T, key ed25519.PrivateKey) string {
+				return "Bearer " + sign(t, key, jwt.MapClaims{"app_id": activeApp.String()})
+			},
+			appHeader:  activeApp.String(),
+			wantStatus: http.StatusOK,
+			wantScopes: []string{"stats:read"},
+		},
+		{
+			name: "token of a revoked app",
+			header: func(t *testing.T, key ed25519.PrivateKey) string {
+				return "Bearer " + sign(t, key, jwt.MapClaims{"app_id": disabledApp.String()})
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "token of another app",
+			header: func(t *testing.T, key ed25519.PrivateKey) string {
+				return "Bearer " + sign(t, key, jwt.MapClaims{"app_id": activeApp.String()})
+			},
+			appHeader:  disabledApp.String(),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "personal access token",
+			header:     func(t *testing.T, key ed25519.PrivateKey) string { return "Bearer " + testPat },
+			wantStatus: http.StatusOK,
+			wantScopes: []string{"stats:read"},
+		},
+		{
+			name: "unknown personal access token",
+			header: func(t *testing.T, key ed25519.PrivateKey) string {
+				return "Bearer " + models.PersonalAccessTokenPrefix + "unknown"
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "auth unavailable",
+			header:     func(t *testing.T, key ed25519.PrivateKey) string { return "Bearer " + downPat },
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "not a token",
+			header:     func(t *testing.T, key ed25519.PrivateKey) string { return "Bearer not-a-token" },
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, key := newTestMiddleware(t)
+
+			var claims *models.Claims
+			handler := m.ValidateToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				claims, _ = r.Context().Value("claims").(*models.Claims)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if header := tt.header(t, key); header != "" {
+				r.Header.Set("Authorization", header)
+			}
+			if tt.appHeader != "" {
+				r.Header.Set(apps.IdHeader, tt.appHeader)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+			if (claims != nil) != (tt.wantStatus == http.StatusOK) {
+				t.Fatalf("claims %+v passed on with status %d", claims, w.Code)
+			}
+			if claims != nil && !slices.Equal(claims.Scopes, tt.wantScopes) {
+				t.Errorf("scopes %v, want %v", claims.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestValidateApp(t *testing.T) {
+	tests := []struct {
+		name       string
+		required   bool
+		path       string
+		appId      string
+		wantStatus int
+	}{
+		{name: "no app", path: "/api/v1/articles", wantStatus: http.StatusOK},
+		{name: "no app when one is required", required: true, path: "/api/v1/articles", wantStatus: http.StatusUnauthorized},
+		{name: "health check when an app is required", required: true, path: "/api/v1/health-check", wantStatus: http.StatusOK},
+		{name: "active app", required: true, path: "/api/v1/articles", appId: activeApp.String(), wantStatus: http.StatusOK},
+		{name: "revoked app", path: "/api/v1/articles", appId: disabledApp.String(), wantStatus: http.StatusForbidden},
+		{name: "unknown app", path: "/api/v1/articles", appId: uuid.NewString(), wantStatus: http.StatusUnauthorized},
+		{name: "malformed id", path: "/api/v1/articles", appId: "app", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestMiddleware(t)
+			m.appRequired = tt.required
+
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.appId != "" {
+				r.Header.Set(apps.IdHeader, tt.appId)
+			}
+			w := httptest.NewRecorder()
+			m.ValidateApp(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestOptionalToken(t *testing.T) {
+	tests := []struct {
+		name string
+		// header returns the Authorization header, given the trusted key.
+		header     func(t *testing.T, key ed25519.PrivateKey) string
+		wantStatus int
+		wantClaims bool
+	}{
+		{
+			name:       "anonymous",
+			header:     func(t *testing.T, key ed25519.PrivateKey) string { return "" },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "valid token",
+			header:     func(t *testing.T, key ed25519.PrivateKey) string { return "Bearer " + sign(t, key, nil) },
+			wantStatus: http.StatusOK,
+			wantClaims: true,
+		},
+		{
+			name:       "invalid token",
+			header:     func(t *testing.T, key ed25519.PrivateKey) string { return "Bearer not-a-token" },
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, key := newTestMiddleware(t)
+
+			var claims *models.Claims
+			handler := m.OptionalToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				claims, _ = r.Context().Value("claims").(*models.Claims)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if header := tt.header(t, key); header != "" {
+				r.Header.Set("Authorization", header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+			if (claims != nil) != tt.wantClaims {
+				t.Errorf("claims %v, want claims %t", claims, tt.wantClaims)
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	session := &models.Claims{Uid: uuid.NewString(), Roles: []string{"user"}, Sid: uuid.NewString()}
+	pat := &models.Claims{Uid: uuid.NewString(), Roles: []string{"user"}, TokenId: uuid.NewString(), Scopes: []string{"stats:read"}}
+	appSession := &models.Claims{Uid: uuid.NewString(), Roles: []string{"user"}, Sid: uuid.NewString(), AppId: activeApp.String(), Scopes: []string{"stats:read"}}
+
+	tests := []struct {
+		name       string
+		claims     *models.Claims
+		scope      string
+		wantStatus int
+	}{
+		{name: "session token", claims: session, scope: "comments.create", wantStatus: http.StatusOK},
+		{name: "granted scope", claims: pat, scope: "stats:read", wantStatus: http.StatusOK},
+		{name: "missing scope", claims: pat, scope: "comments.create", wantStatus: http.StatusForbidden},
+		{name: "session of an app with the scope", claims: appSession, scope: "stats:read", wantStatus: http.StatusOK},
+		{name: "session of an app without the scope", claims: appSession, scope: "comments.create", wantStatus: http.StatusForbidden},
+		{name: "token without scopes", claims: &models.Claims{Uid: pat.Uid, Roles: []string{"user"}, TokenId: pat.TokenId}, scope: "stats:read", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestMiddleware(t)
+			if got := serveWith(m.RequireScope(tt.scope), tt.claims); got != tt.wantStatus {
+				t.Errorf("status %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     *models.Claims
+		wantStatus int
+	}{
+		{name: "session token", claims: &models.Claims{Uid: uuid.NewString(), Roles: []string{"user"}, Sid: uuid.NewString()}, wantStatus: http.StatusOK},
+		{name: "personal access token", claims: &models.Claims{Uid: uuid.NewString(), Roles: []string{"user"}, TokenId: uuid.NewString()}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestMiddleware(t)
+			if got := serveWith(m.RequireSession, tt.claims); got != tt.wantStatus {
+				t.Errorf("status %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	user, other := uuid.NewString(), uuid.NewString()
+
+	tests := []struct {
+		name string
+		// before are the requests made first, each by the given user or,
+		// when empty, anonymously.
+		before     []string
+		uid        string
+		method     string
+		group      string
+		wantStatus int
+	}{
+		{name: "first request", group: "write", wantStatus: http.StatusOK},
+		{name: "budget of the address spent", before: []string{""}, group: "write", wantStatus: http.StatusTooManyRequests},
+		{name: "budget of the user spent", before: []string{user}, uid: user, group: "write", wantStatus: http.StatusTooManyRequests},
+		{name: "users behind one address", before: []string{other}, uid: user, group: "write", wantStatus: http.StatusOK},
+		{name: "user after anonymous requests", before: []string{""}, uid: user, group: "write", wantStatus: http.StatusOK},
+		{name: "preflight", before: []string{""}, method: http.MethodOptions, group: "write", wantStatus: http.StatusOK},
+		{name: "group without limit", before: []string{""}, group: "read", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestMiddleware(t)
+			limiter, err := ratelimit.New(slog.New(slog.NewTextHandler(io.Discard, nil)), ratelimit.NewMemoryStore(), config.RateLimitConfig{
+				Enabled: true,
+				Groups:  map[string]config.RateLimitGroup{"write": {Requests: 1, Per: time.Hour}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler := m.RateLimit(limiter, tt.group)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			request := func(method string, uid string) *httptest.ResponseRecorder {
+				r := httptest.NewRequest(method, "/", nil)
+				r.RemoteAddr = "203.0.113.7:5000"
+				if uid != "" {
+					r = r.WithContext(context.WithValue(r.Context(), "claims", &models.Claims{Uid: uid}))
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				return w
+			}
+
+			for _, uid := range tt.before {
+				request(http.MethodPost, uid)
+			}
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			w := request(method, tt.uid)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "3600" {
+				t.Errorf("Retry-After %q, want 3600", w.Header().Get("Retry-After"))
+			}
+			wantHeaders := method != http.MethodOptions && tt.group == "write"
+			if got := w.Header().Get("RateLimit-Limit"); (got != "") != wantHeaders {
+				t.Errorf("RateLimit-Limit %q, want set %t", got, wantHeaders)
+			}
+		})
+	}
+}
+
+// TestRateLimitOrder checks that a group mounted before ValidateToken, like
+// "default", is counted per address and one mounted after it per user.
+func TestRateLimitOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		afterAuth  bool
+		wantStatus int
+	}{
+		{name: "before token validation", wantStatus: http.StatusTooManyRequests},
+		{name: "after token validation", afterAuth: true, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, key := newTestMiddleware(t)
+			limiter, err := ratelimit.New(slog.New(slog.NewTextHandler(io.Discard, nil)), ratelimit.NewMemoryStore(), config.RateLimitConfig{
+				Enabled: true,
+				Groups:  map[string]config.RateLimitGroup{"default": {Requests: 1, Per: time.Hour}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			if tt.afterAuth {
+				handler = m.ValidateToken(m.RateLimit(limiter, "default")(handler))
+			} else {
+				handler = m.RateLimit(limiter, "default")(m.ValidateToken(handler))
+			}
+
+			// Two users behind one address.
+			var w *httptest.ResponseRecorder
+			for range 2 {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.RemoteAddr = "203.0.113.7:5000"
+				r.Header.Set("Authorization", "Bearer "+sign(t, key, nil))
+				w = httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// serveWith runs a request carrying claims through middleware and returns
+// the status of the response.
+func serveWith(middleware func(http.Handler) http.Handler, claims *models.Claims) int {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), "claims", claims))
+	w := httptest.NewRecorder()
+
+	middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     *models.Claims
+		wantStatus int
+	}{
+		{name: "granted", claims: &models.Claims{Uid: uuid.NewString(), Roles: []string{"moderator"}, Permissions: []string{"comments.moderate"}}, wantStatus: http.StatusOK},
+		{name: "not granted", claims: &models.Claims{Uid: uuid.NewString(), Roles: []string{"user"}, Permissions: []string{"comments.create"}}, wantStatus: http.StatusForbidden},
+		{name: "no permissions", claims: &models.Claims{Uid: uuid.NewString()}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestMiddleware(t)
+			if got := serveWith(m.RequirePermission("comments.moderate"), tt.claims); got != tt.wantStatus {
+				t.Errorf("status %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -4,18 +4,15 @@ import (
 	"apigateway/internal/domain/interfaces/articles"
 	"apigateway/internal/domain/interfaces/comments"
 	"apigateway/internal/domain/interfaces/users"
+	"apigateway/internal/lib/apierror"
 	"apigateway/pkg/lib/logger/sl"
-	"context"
 	"encoding/json"
-	"errors"
 	"log/slog"
 	"net/http"
 	"sort"
 	"time"
 
 	"github.com/google/uuid"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type StatsController struct {
@@ -38,19 +35,6 @@ func New(log *slog.Logger,
 	}
 }
 
-func (sc *StatsController) handleError(w http.ResponseWriter, err error, log *slog.Logger) {
-	if errors.Is(err, context.Canceled) {
-		log.Error("Request was canceled by the user")
-		http.Error(w, "Request canceled", http.StatusRequestTimeout)
-	} else if errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded {
-		log.Error("Request time out")
-		http.Error(w, "Request timeout", http.StatusRequestTimeout)
-	} else {
-		log.Error("Operation failed", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
-}
-
 // количество статей
 // количество статей пользователя отсортировано по убыванию
 func (sc *StatsController) GetArticlesStats(w http.ResponseWriter, r *http.Request) {
@@ -71,7 +55,7 @@ func (sc *StatsController) GetArticlesStats(w http.ResponseWriter, r *http.Reque
 
 	articles, err := sc.articleService.GetArticles(r.Context())
 	if err != nil {
-		sc.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -99,7 +83,6 @@ func (sc *StatsController) GetArticlesStats(w http.ResponseWriter, r *http.Reque
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(res_struct); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
@@ -114,7 +97,7 @@ func (sc *StatsController) GetUsersStats(w http.ResponseWriter, r *http.Request)
 
 	users, err := sc.usersService.GetUsers(r.Context())
 	if err != nil {
-		sc.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -142,7 +125,6 @@ func (sc *StatsController) GetUsersStats(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(res_struct); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	log.Info("Retrieved usersStats")
@@ -2,43 +2,67 @@ package userscontroller
 
 import (
 	"apigateway/internal/domain/models"
+	"apigateway/internal/lib/apierror"
+	"apigateway/internal/lib/validation"
+	authservice "apigateway/internal/services/auth"
 	usersmanagerservice "apigateway/internal/services/usersManager"
 	"apigateway/pkg/lib/logger/sl"
-	"context"
 	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type UsersController struct {
 	log          *slog.Logger
 	usersService *usersmanagerservice.UsersManager
+	authService  *authservice.AuthService
 }
 
-func New(log *slog.Logger, usersService *usersmanagerservice.UsersManager) *UsersController {
+func New(log *slog.Logger, usersService *usersmanagerservice.UsersManager, authService *authservice.AuthService) *UsersController {
 	return &UsersController{
 		log:          log,
 		usersService: usersService,
+		authService:  authService,
 	}
 }
 
-func (uc *UsersController) handleError(w http.ResponseWriter, err error, log *slog.Logger) {
-	if errors.Is(err, context.Canceled) {
-		log.Error("Request was canceled by the user")
-		http.Error(w, "Request canceled", http.StatusRequestTimeout)
-	} else if errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded {
-		log.Error("Request time out")
-		http.Error(w, "Request timeout", http.StatusRequestTimeout)
-	} else {
-		log.Error("Operation failed", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+// checkRoles answers the request itself and returns false if roles name a
+// role Auth does not know.
+func (uc *UsersController) checkRoles(w http.ResponseWriter, r *http.Request, roles []string, log *slog.Logger) bool {
+	if len(roles) == 0 {
+		return true
 	}
+
+	known, err := uc.authService.ListRoles(r.Context())
+	if err != nil {
+		apierror.FromError(w, err, log)
+		return false
+	}
+
+	for _, role := range roles {
+		if !slices.ContainsFunc(known, func(k models.Role) bool { return k.Name == role }) {
+			log.Warn("Unknown role", slog.String("role", role))
+			apierror.Write(w, "Unknown role "+role, http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
+// view picks what the caller may see about user: the whole account for the
+// user themselves and user admins, the public profile for everyone else,
+// anonymous callers included.
+func (uc *UsersController) view(r *http.Request, user models.User) any {
+	claims, ok := r.Context().Value("claims").(*models.Claims)
+	if ok && (claims.Uid == user.Id.String() || claims.HasPermission(models.PermUsersManage)) {
+		return user.Account()
+	}
+	return user.PublicProfile()
 }
 
 func (uc *UsersController) GetUsers(w http.ResponseWriter, r *http.Request) {
@@ -47,15 +71,19 @@ func (uc *UsersController) GetUsers(w http.ResponseWriter, r *http.Request) {
 
 	users, err := uc.usersService.GetUsers(r.Context())
 	if err != nil {
-		uc.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
+	views := make([]any, 0, len(users))
+	for _, user := range users {
+		views = append(views, uc.view(r, user))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(users); err != nil {
+	if err := json.NewEncoder(w).Encode(views); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	log.Info("Retrieved all users successfully")
@@ -69,21 +97,20 @@ func (uc *UsersController) GetUserById(w http.ResponseWriter, r *http.Request) {
 	uuidID, err := uuid.Parse(idStr)
 	if err != nil {
 		log.Error("Invalid UUID format", sl.Err(err))
-		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		apierror.Write(w, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
 	user, err := uc.usersService.GetUserById(r.Context(), uuidID)
 	if err != nil {
-		uc.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(user); err != nil {
+	if err := json.NewEncoder(w).Encode(uc.view(r, user)); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	log.Info("Retrieved user by ID successfully")
@@ -96,15 +123,14 @@ func (uc *UsersController) GetUserByEmail(w http.ResponseWriter, r *http.Request
 	email := mux.Vars(r)["email"]
 	user, err := uc.usersService.GetUserByEmail(r.Context(), email)
 	if err != nil {
-		uc.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(user); err != nil {
+	if err := json.NewEncoder(w).Encode(user.Account()); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	log.Info("Retrieved user by email successfully")
@@ -117,18 +143,28 @@ func (uc *UsersController) Insert(w http.ResponseWriter, r *http.Request) {
 	var user models.User
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
 		log.Error("Cannot parse request body", sl.Err(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if fields := validation.Struct(user); fields != nil {
+		log.Warn("Invalid user", slog.Int("violations", len(fields)))
+		apierror.Write(w, "Invalid user", http.StatusBadRequest, fields...)
 		return
 	}
 
 	if user.Id == uuid.Nil {
 		log.Error("Empty request body", sl.Err(errors.New("bad request")))
-		http.Error(w, "Empty request body", http.StatusBadRequest)
+		apierror.Write(w, "Empty request body", http.StatusBadRequest)
+		return
+	}
+
+	if !uc.checkRoles(w, r, user.Roles, log) {
 		return
 	}
 
 	if _, err := uc.usersService.Insert(r.Context(), user); err != nil {
-		uc.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -144,19 +180,29 @@ func (uc *UsersController) Update(w http.ResponseWriter, r *http.Request) {
 	uuidID, err := uuid.Parse(idStr)
 	if err != nil {
 		log.Error("Invalid UUID format", sl.Err(err))
-		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		apierror.Write(w, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
 	var user models.User
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
 		log.Error("Cannot parse request body", sl.Err(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierror.Write(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if fields := validation.Struct(user); fields != nil {
+		log.Warn("Invalid user", slog.Int("violations", len(fields)))
+		apierror.Write(w, "Invalid user", http.StatusBadRequest, fields...)
+		return
+	}
+
+	if !uc.checkRoles(w, r, user.Roles, log) {
 		return
 	}
 
 	if _, err := uc.usersService.Update(r.Context(), uuidID, user); err != nil {
-		uc.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
@@ -172,21 +218,20 @@ func (uc *UsersController) Delete(w http.ResponseWriter, r *http.Request) {
 	uuidID, err := uuid.Parse(idStr)
 	if err != nil {
 		log.Error("Invalid UUID format", sl.Err(err))
-		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		apierror.Write(w, "Invalid UUID", http.StatusBadRequest)
 		return
 	}
 
 	user, err := uc.usersService.Delete(r.Context(), uuidID)
 	if err != nil {
-		uc.handleError(w, err, log)
+		apierror.FromError(w, err, log)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(user); err != nil {
+	if err := json.NewEncoder(w).Encode(user.Account()); err != nil {
 		log.Error("Failed to encode response", sl.Err(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -0,0 +1,37 @@
+package docs
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// spec is the OpenAPI 3 document of the gateway. It is kept by hand next
+// to the routes in app.Router; a test in the app package fails when a
+// route is missing from it.
+//
+//go:embed openapi.json
+var spec []byte
+
+//go:embed index.html
+var page []byte
+
+// Document returns the OpenAPI document as served by Spec.
+func Document() []byte {
+	return spec
+}
+
+// Spec serves the OpenAPI document.
+func Spec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.Write(spec)
+}
+
+// Page serves the interactive documentation, which renders the document
+// from Spec with Swagger UI.
+func Page(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page)
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// App is a client application registered in Auth.
+type App struct {
+	Id              uuid.UUID `json:"id"`
+	Name            string    `json:"name"`
+	RedirectUris    []string  `json:"redirect_uris"`
+	Scopes          []string  `json:"scopes"`
+	Disabled        bool      `json:"disabled"`
+	CreatedAt       time.Time `json:"created_at"`
+	SecretRotatedAt time.Time `json:"secret_rotated_at"`
+}
+
+// AppCredentials identify the app a login is made through. A zero Id means
+// no app was named.
+type AppCredentials struct {
+	Id     uuid.UUID
+	Secret string
+}
@@ -9,8 +9,8 @@ import (
 type Article struct {
 	Id        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Tag       string    `json:"tag"`
+	Title     string    `json:"title" validate:"required,max=255"`
+	Content   string    `json:"content" validate:"required"`
+	Tag       string    `json:"tag" validate:"required,max=50"`
 	OwnerId   uuid.UUID `json:"owner_id"`
 }
@@ -1,9 +1,47 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"time"
 
+	"github.com/google/uuid"
+)
+
+// Claims describe who a request is made by. For a personal access token
+// TokenId is set, Sid is empty and Scopes limit what the request may do.
+// For a session opened through a registered app AppId is set and Scopes are
+// the app's. Other session tokens are not limited by scopes.
 type Claims struct {
-	Uid  string    `json:"uid"`
-	Role string    `json:"role"`
-	Exp  time.Time `json:"exp"`
+	Uid         string    `json:"uid"`
+	Roles       []string  `json:"roles"`
+	Permissions []string  `json:"permissions"`
+	Sid         string    `json:"sid"`
+	Exp         time.Time `json:"exp"`
+	TokenId     string    `json:"token_id,omitempty"`
+	AppId       string    `json:"app_id,omitempty"`
+	Scopes      []string  `json:"scopes,omitempty"`
+}
+
+// IsPersonalAccessToken reports whether the claims come from a personal
+// access token rather than a login session.
+func (c *Claims) IsPersonalAccessToken() bool {
+	return c.TokenId != ""
+}
+
+// HasScope reports whether the request may use scope.
+func (c *Claims) HasScope(scope string) bool {
+	if !c.IsPersonalAccessToken() && c.AppId == "" {
+		return true
+	}
+	return slices.Contains(c.Scopes, scope)
+}
+
+// HasPermission reports whether the user's roles grant permission.
+func (c *Claims) HasPermission(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
+
+// UserId returns Uid as a UUID.
+func (c *Claims) UserId() (uuid.UUID, error) {
+	return uuid.Parse(c.Uid)
 }
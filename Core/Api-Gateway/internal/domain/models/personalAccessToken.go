@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token issued by
+// Auth, so ValidateToken knows not to parse it as a JWT.
+const PersonalAccessTokenPrefix = "rh_pat_"
+
+const (
+	ScopeArticlesWrite  = "articles:write"
+	ScopeCommentsWrite  = "comments:write"
+	ScopeStatsRead      = "stats:read"
+	ScopeUsersWrite     = "users:write"
+	ScopeFavoritesWrite = "favorites:write"
+)
+
+type PersonalAccessToken struct {
+	Id         uuid.UUID  `json:"id"`
+	UserId     uuid.UUID  `json:"user_id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
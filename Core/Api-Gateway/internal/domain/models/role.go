@@ -0,0 +1,22 @@
+package models
+
+// Permissions route guards can require. Auth resolves them from the user's
+// roles and puts them in the token.
+const (
+	PermArticlesCreate   = "articles.create"
+	PermArticlesModerate = "articles.moderate"
+	PermCommentsCreate   = "comments.create"
+	PermCommentsModerate = "comments.moderate"
+	PermFavoritesManage  = "favorites.manage"
+	PermModerationManage = "moderation.manage"
+	PermStatsRead        = "stats.read"
+	PermUsersManage      = "users.manage"
+	PermAppsManage       = "apps.manage"
+)
+
+type Role struct {
+	Name                 string   `json:"name"`
+	Inherits             []string `json:"inherits"`
+	Permissions          []string `json:"permissions"`
+	EffectivePermissions []string `json:"effective_permissions"`
+}
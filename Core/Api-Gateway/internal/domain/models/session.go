@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Session struct {
+	Id         uuid.UUID `json:"id"`
+	UserId     uuid.UUID `json:"user_id"`
+	UserAgent  string    `json:"user_agent"`
+	Ip         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// Device describes the client a login request came from.
+type Device struct {
+	UserAgent string
+	Ip        string
+}
@@ -0,0 +1,18 @@
+package models
+
+// LoginResult holds either the tokens of a new session or, when a second
+// factor is needed, the challenge to answer first.
+type LoginResult struct {
+	AccessToken    string
+	RefreshToken   string
+	ChallengeToken string
+	ChallengeType  string
+	RecoveryCodes  []string
+}
+
+// TotpEnrollment is what an authenticator app needs to be set up: the
+// secret itself and the otpauth:// URI to render as a QR code.
+type TotpEnrollment struct {
+	Secret     string `json:"secret"`
+	OtpauthUri string `json:"otpauth_uri"`
+}
@@ -6,12 +6,55 @@ import (
 	"github.com/google/uuid"
 )
 
+// User is what the gateway sends to UsersManageService. Password is only
+// written, the service never returns it; responses use PublicProfile or
+// Account instead.
 type User struct {
+	Id            uuid.UUID `json:"id"`
+	Email         string    `json:"email" validate:"required,email,max=255"`
+	Password      string    `json:"password,omitempty" validate:"omitempty,max=72"`
+	Roles         []string  `json:"roles"`
+	Nick          string    `json:"nick" validate:"required,max=50"`
+	Description   string    `json:"description"`
+	Birthday      time.Time `json:"birthday" validate:"notfuture"`
+	EmailVerified bool      `json:"email_verified"`
+}
+
+// PublicProfile is what anyone may see about a user.
+type PublicProfile struct {
 	Id          uuid.UUID `json:"id"`
-	Email       string    `json:"email"`
-	Password    string    `json:"password"`
-	Role        string    `json:"role"`
 	Nick        string    `json:"nick"`
 	Description string    `json:"description"`
-	Birthday    time.Time `json:"birthday"`
+}
+
+// Account is the private view of a user, shown to the user themselves and
+// to user admins.
+type Account struct {
+	Id            uuid.UUID `json:"id"`
+	Email         string    `json:"email"`
+	Roles         []string  `json:"roles"`
+	Nick          string    `json:"nick"`
+	Description   string    `json:"description"`
+	Birthday      time.Time `json:"birthday"`
+	EmailVerified bool      `json:"email_verified"`
+}
+
+func (u User) PublicProfile() PublicProfile {
+	return PublicProfile{
+		Id:          u.Id,
+		Nick:        u.Nick,
+		Description: u.Description,
+	}
+}
+
+func (u User) Account() Account {
+	return Account{
+		Id:            u.Id,
+		Email:         u.Email,
+		Roles:         u.Roles,
+		Nick:          u.Nick,
+		Description:   u.Description,
+		Birthday:      u.Birthday,
+		EmailVerified: u.EmailVerified,
+	}
 }
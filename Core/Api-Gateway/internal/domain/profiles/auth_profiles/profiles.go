@@ -19,7 +19,7 @@ func UsrToProtoUsr(user models.User) (*authv1.User, error) {
 		Id:          user.Id.String(),
 		Email:       user.Email,
 		Password:    user.Password,
-		Role:        user.Role,
+		Roles:       user.Roles,
 		Nick:        user.Nick,
 		Description: user.Description,
 		Birthday:    birthday,
@@ -41,9 +41,96 @@ func ProtoUsrToUsr(proto_usr *authv1.User) (models.User, error) {
 		Id:          parsedUUID,
 		Email:       proto_usr.GetEmail(),
 		Password:    proto_usr.GetPassword(),
-		Role:        proto_usr.GetRole(),
+		Roles:       proto_usr.GetRoles(),
 		Nick:        proto_usr.GetNick(),
 		Description: proto_usr.GetDescription(),
 		Birthday:    birthday,
 	}, nil
 }
+
+func ProtoSessionToSession(proto_session *authv1.Session) (models.Session, error) {
+	sid, err := uuid.Parse(proto_session.GetId())
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	uid, err := uuid.Parse(proto_session.GetUserId())
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	return models.Session{
+		Id:         sid,
+		UserId:     uid,
+		UserAgent:  proto_session.GetUserAgent(),
+		Ip:         proto_session.GetIp(),
+		CreatedAt:  proto_session.GetCreatedAt().AsTime(),
+		LastUsedAt: proto_session.GetLastUsedAt().AsTime(),
+		ExpiresAt:  proto_session.GetExpiresAt().AsTime(),
+	}, nil
+}
+
+func ProtoJwkToJwk(proto_jwk *authv1.Jwk) models.Jwk {
+	return models.Jwk{
+		Kid: proto_jwk.GetKid(),
+		Kty: proto_jwk.GetKty(),
+		Crv: proto_jwk.GetCrv(),
+		Alg: proto_jwk.GetAlg(),
+		Use: proto_jwk.GetUse(),
+		X:   proto_jwk.GetX(),
+	}
+}
+
+func ProtoPersonalAccessTokenToPersonalAccessToken(proto_pat *authv1.PersonalAccessToken) (models.PersonalAccessToken, error) {
+	id, err := uuid.Parse(proto_pat.GetId())
+	if err != nil {
+		return models.PersonalAccessToken{}, err
+	}
+
+	uid, err := uuid.Parse(proto_pat.GetUserId())
+	if err != nil {
+		return models.PersonalAccessToken{}, err
+	}
+
+	var lastUsedAt *time.Time
+	if proto_pat.GetLastUsedAt() != nil {
+		t := proto_pat.GetLastUsedAt().AsTime()
+		lastUsedAt = &t
+	}
+
+	return models.PersonalAccessToken{
+		Id:         id,
+		UserId:     uid,
+		Name:       proto_pat.GetName(),
+		Scopes:     proto_pat.GetScopes(),
+		CreatedAt:  proto_pat.GetCreatedAt().AsTime(),
+		ExpiresAt:  proto_pat.GetExpiresAt().AsTime(),
+		LastUsedAt: lastUsedAt,
+	}, nil
+}
+
+func ProtoAppToApp(proto_app *authv1.App) (models.App, error) {
+	id, err := uuid.Parse(proto_app.GetId())
+	if err != nil {
+		return models.App{}, err
+	}
+
+	return models.App{
+		Id:              id,
+		Name:            proto_app.GetName(),
+		RedirectUris:    proto_app.GetRedirectUris(),
+		Scopes:          proto_app.GetScopes(),
+		Disabled:        proto_app.GetDisabled(),
+		CreatedAt:       proto_app.GetCreatedAt().AsTime(),
+		SecretRotatedAt: proto_app.GetSecretRotatedAt().AsTime(),
+	}, nil
+}
+
+func ProtoRoleToRole(proto_role *authv1.Role) models.Role {
+	return models.Role{
+		Name:                 proto_role.GetName(),
+		Inherits:             proto_role.GetInherits(),
+		Permissions:          proto_role.GetPermissions(),
+		EffectivePermissions: proto_role.GetEffectivePermissions(),
+	}
+}
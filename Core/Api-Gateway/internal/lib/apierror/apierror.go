@@ -0,0 +1,193 @@
+package apierror
+
+import (
+	"apigateway/pkg/lib/logger/sl"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIdHeader carries the id of a request. The gateway sets it on every
+// response before the handlers run, and the error body repeats it.
+const RequestIdHeader = "X-Request-Id"
+
+// Body is the JSON every error response of the gateway is made of.
+type Body struct {
+	Error Detail `json:"error"`
+}
+
+// Detail.Code is a stable, machine-readable name of the error, such as
+// "not_found" or "invalid_argument"; Message is meant for people.
+type Detail struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	RequestId string       `json:"request_id,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError points at the request field that was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Write answers the request with status and an error body carrying
+// message; it takes the place of http.Error.
+func Write(w http.ResponseWriter, message string, code int, fields ...FieldError) {
+	write(w, code, codeName(code), message, fields)
+}
+
+// FromError answers the request with the status matching err, which is a
+// context error or comes from a gRPC service. Client errors pass the
+// service's message on; server errors only say what failed, the details go
+// to the log.
+func FromError(w http.ResponseWriter, err error, log *slog.Logger) {
+	if errors.Is(err, context.Canceled) {
+		log.Warn("Request was canceled by the user")
+		write(w, http.StatusRequestTimeout, "canceled", "Request canceled", nil)
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Error("Request time out")
+		write(w, http.StatusGatewayTimeout, "deadline_exceeded", "Gateway timeout", nil)
+		return
+	}
+
+	st := grpcStatus(err)
+	httpStatus := HTTPStatus(st.Code())
+	name := snake(st.Code().String())
+
+	if httpStatus >= http.StatusInternalServerError {
+		log.Error("Operation failed", sl.Err(err))
+		message := http.StatusText(httpStatus)
+		if st.Code() == codes.Unknown || st.Code() == codes.Internal {
+			name, message = "internal", "Internal server error"
+		}
+		write(w, httpStatus, name, message, nil)
+		return
+	}
+
+	log.Warn("Request rejected", sl.Err(err))
+	write(w, httpStatus, name, st.Message(), fieldErrors(st))
+}
+
+// Message returns the message a gRPC service gave err, without what the
+// gateway wrapped around it.
+func Message(err error) string {
+	return grpcStatus(err).Message()
+}
+
+// HTTPStatus maps a gRPC code to the HTTP status of the response.
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted, codes.FailedPrecondition:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return http.StatusRequestTimeout
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// grpcStatus finds the status a service returned inside err. The status
+// package would report the whole wrapped error as the message.
+func grpcStatus(err error) *status.Status {
+	var se interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &se) {
+		return se.GRPCStatus()
+	}
+	return status.New(codes.Unknown, err.Error())
+}
+
+func fieldErrors(st *status.Status) []FieldError {
+	var fields []FieldError
+	for _, detail := range st.Details() {
+		badRequest, ok := detail.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, violation := range badRequest.GetFieldViolations() {
+			fields = append(fields, FieldError{Field: violation.GetField(), Message: violation.GetDescription()})
+		}
+	}
+	return fields
+}
+
+func write(w http.ResponseWriter, code int, name string, message string, fields []FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(Body{Error: Detail{
+		Code:      name,
+		Message:   message,
+		RequestId: w.Header().Get(RequestIdHeader),
+		Fields:    fields,
+	}})
+}
+
+// codeName names the errors the gateway makes itself after their status.
+func codeName(code int) string {
+	switch code {
+	case http.StatusBadRequest:
+		return "invalid_argument"
+	case http.StatusUnauthorized:
+		return "unauthenticated"
+	case http.StatusForbidden:
+		return "permission_denied"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusTooManyRequests:
+		return "resource_exhausted"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	case http.StatusGatewayTimeout:
+		return "deadline_exceeded"
+	case http.StatusInternalServerError:
+		return "internal"
+	default:
+		return snake(strings.ReplaceAll(http.StatusText(code), " ", ""))
+	}
+}
+
+// snake turns a name like "AlreadyExists" into "already_exists".
+func snake(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,200 @@
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want int
+	}{
+		{codes.OK, http.StatusOK},
+		{codes.InvalidArgument, http.StatusBadRequest},
+		{codes.OutOfRange, http.StatusBadRequest},
+		{codes.Unauthenticated, http.StatusUnauthorized},
+		{codes.PermissionDenied, http.StatusForbidden},
+		{codes.NotFound, http.StatusNotFound},
+		{codes.AlreadyExists, http.StatusConflict},
+		{codes.Aborted, http.StatusConflict},
+		{codes.FailedPrecondition, http.StatusConflict},
+		{codes.ResourceExhausted, http.StatusTooManyRequests},
+		{codes.Canceled, http.StatusRequestTimeout},
+		{codes.Unimplemented, http.StatusNotImplemented},
+		{codes.Unavailable, http.StatusServiceUnavailable},
+		{codes.DeadlineExceeded, http.StatusGatewayTimeout},
+		{codes.Unknown, http.StatusInternalServerError},
+		{codes.Internal, http.StatusInternalServerError},
+		{codes.DataLoss, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			if got := HTTPStatus(tt.code); got != tt.want {
+				t.Errorf("HTTPStatus(%v) = %d, want %d", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromError(t *testing.T) {
+	badRequest, err := status.New(codes.InvalidArgument, "invalid user").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "email", Description: "must be a valid email"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+		wantFields  []FieldError
+	}{
+		{
+			name:        "client error",
+			err:         fmt.Errorf("usersManager.get: %w", status.Error(codes.NotFound, "user not found")),
+			wantStatus:  http.StatusNotFound,
+			wantCode:    "not_found",
+			wantMessage: "user not found",
+		},
+		{
+			name:        "field violations",
+			err:         badRequest.Err(),
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "invalid_argument",
+			wantMessage: "invalid user",
+			wantFields:  []FieldError{{Field: "email", Message: "must be a valid email"}},
+		},
+		{
+			name:        "two word code",
+			err:         status.Error(codes.AlreadyExists, "email is taken"),
+			wantStatus:  http.StatusConflict,
+			wantCode:    "already_exists",
+			wantMessage: "email is taken",
+		},
+		{
+			name:        "internal error hides the message",
+			err:         status.Error(codes.Internal, "pq: relation does not exist"),
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    "internal",
+			wantMessage: "Internal server error",
+		},
+		{
+			name:        "unavailable hides the message",
+			err:         status.Error(codes.Unavailable, "connection refused"),
+			wantStatus:  http.StatusServiceUnavailable,
+			wantCode:    "unavailable",
+			wantMessage: "Service Unavailable",
+		},
+		{
+			name:        "plain error",
+			err:         errors.New("boom"),
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    "internal",
+			wantMessage: "Internal server error",
+		},
+		{
+			name:        "canceled",
+			err:         fmt.Errorf("articles.get: %w", context.Canceled),
+			wantStatus:  http.StatusRequestTimeout,
+			wantCode:    "canceled",
+			wantMessage: "Request canceled",
+		},
+		{
+			name:        "deadline",
+			err:         context.DeadlineExceeded,
+			wantStatus:  http.StatusGatewayTimeout,
+			wantCode:    "deadline_exceeded",
+			wantMessage: "Gateway timeout",
+		},
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			w.Header().Set(RequestIdHeader, "req-1")
+
+			FromError(w, tt.err, log)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+			body := decode(t, w)
+			if body.Error.Code != tt.wantCode || body.Error.Message != tt.wantMessage {
+				t.Errorf("error %q %q, want %q %q", body.Error.Code, body.Error.Message, tt.wantCode, tt.wantMessage)
+			}
+			if body.Error.RequestId != "req-1" {
+				t.Errorf("request id %q, want %q", body.Error.RequestId, "req-1")
+			}
+			if !slices.Equal(body.Error.Fields, tt.wantFields) {
+				t.Errorf("fields %v, want %v", body.Error.Fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		status   int
+		wantCode string
+	}{
+		{http.StatusBadRequest, "invalid_argument"},
+		{http.StatusUnauthorized, "unauthenticated"},
+		{http.StatusConflict, "conflict"},
+		{http.StatusTooManyRequests, "resource_exhausted"},
+		{http.StatusRequestEntityTooLarge, "request_entity_too_large"},
+		{http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			Write(w, "rejected", tt.status)
+
+			if w.Code != tt.status {
+				t.Errorf("status %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("content type %q, want application/json", got)
+			}
+			if body := decode(t, w); body.Error.Code != tt.wantCode {
+				t.Errorf("code %q, want %q", body.Error.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	err := fmt.Errorf("articles.get: %w", status.Error(codes.NotFound, "article not found"))
+	if got := Message(err); got != "article not found" {
+		t.Errorf("Message = %q, want %q", got, "article not found")
+	}
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) Body {
+	t.Helper()
+
+	var body Body
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	return body
+}
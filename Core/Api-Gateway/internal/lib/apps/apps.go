@@ -0,0 +1,117 @@
+package apps
+
+import (
+	"apigateway/internal/domain/models"
+	"apigateway/pkg/lib/logger/sl"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Headers a client names its registered app with. The secret is only
+// needed at login.
+const (
+	IdHeader     = "X-App-Id"
+	SecretHeader = "X-App-Secret"
+)
+
+// maxEntries bounds the cache, so made up app ids cannot grow it forever.
+const maxEntries = 1024
+
+var (
+	ErrUnknownApp  = errors.New("unknown app")
+	ErrAppDisabled = errors.New("app is disabled")
+)
+
+type Fetcher interface {
+	GetApp(ctx context.Context, id uuid.UUID) (models.App, error)
+}
+
+type entry struct {
+	app       models.App
+	known     bool
+	fetchedAt time.Time
+}
+
+// Cache remembers the apps registered in Auth for ttl, including ids Auth
+// does not know, so checking the app of every request stays cheap. A
+// revoked app is therefore rejected at most ttl after it was revoked.
+type Cache struct {
+	log     *slog.Logger
+	fetcher Fetcher
+	ttl     time.Duration
+
+	mu      sync.RWMutex
+	entries map[uuid.UUID]entry
+}
+
+func New(log *slog.Logger, fetcher Fetcher, ttl time.Duration) *Cache {
+	return &Cache{
+		log:     log,
+		fetcher: fetcher,
+		ttl:     ttl,
+		entries: make(map[uuid.UUID]entry),
+	}
+}
+
+// App returns the app with the given id, ErrUnknownApp if Auth does not
+// know it, or ErrAppDisabled if it was revoked.
+func (c *Cache) App(ctx context.Context, id uuid.UUID) (models.App, error) {
+	const op = "apps.App"
+	log := c.log.With(slog.String("op", op))
+
+	c.mu.RLock()
+	cached, ok := c.entries[id]
+	c.mu.RUnlock()
+
+	if !ok || time.Since(cached.fetchedAt) >= c.ttl {
+		fetched, err := c.fetch(ctx, id)
+		switch {
+		case err == nil:
+			cached = fetched
+		case ok:
+			// The last known state stays in use while Auth is unreachable.
+			log.Error("failed to refresh app", sl.Err(err))
+		default:
+			return models.App{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if !cached.known {
+		return models.App{}, ErrUnknownApp
+	}
+	if cached.app.Disabled {
+		return models.App{}, ErrAppDisabled
+	}
+
+	return cached.app, nil
+}
+
+func (c *Cache) fetch(ctx context.Context, id uuid.UUID) (entry, error) {
+	app, err := c.fetcher.GetApp(ctx, id)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return entry{}, err
+	}
+
+	fetched := entry{
+		app:       app,
+		known:     err == nil,
+		fetchedAt: time.Now(),
+	}
+
+	c.mu.Lock()
+	if len(c.entries) >= maxEntries {
+		c.entries = make(map[uuid.UUID]entry)
+	}
+	c.entries[id] = fetched
+	c.mu.Unlock()
+
+	return fetched, nil
+}
@@ -0,0 +1,36 @@
+package caller
+
+import (
+	"apigateway/internal/domain/models"
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys backend services read the caller from. Services that check
+// tokens with Auth themselves use AuthorizationKey and ignore the others.
+const (
+	IdKey            = "x-caller-id"
+	PermissionsKey   = "x-caller-permissions"
+	AuthorizationKey = "authorization"
+)
+
+// UnaryClientInterceptor passes the authenticated user of the request, if
+// any, and their token to the service being called, so it can enforce
+// ownership itself.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if token, ok := ctx.Value("token").(string); ok && token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, AuthorizationKey, "Bearer "+token)
+	}
+	if claims, ok := ctx.Value("claims").(*models.Claims); ok {
+		pairs := make([]string, 0, 2+2*len(claims.Permissions))
+		pairs = append(pairs, IdKey, claims.Uid)
+		for _, permission := range claims.Permissions {
+			pairs = append(pairs, PermissionsKey, permission)
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+	}
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,71 @@
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Resolver finds the address of the client that made a request. The
+// X-Forwarded-For header is read only when the request came through a
+// trusted proxy, and only as far back as the proxies in it are trusted too,
+// so clients calling the gateway directly cannot choose their address.
+type Resolver struct {
+	trusted []*net.IPNet
+}
+
+// New returns a Resolver that trusts the proxies in trustedProxies, given
+// as networks in CIDR form.
+func New(trustedProxies []string) (*Resolver, error) {
+	const op = "clientip.new"
+
+	trusted := make([]*net.IPNet, 0, len(trustedProxies))
+	for _, cidr := range trustedProxies {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		trusted = append(trusted, network)
+	}
+
+	return &Resolver{trusted: trusted}, nil
+}
+
+// FromRequest returns the address of the client that made r.
+func (c *Resolver) FromRequest(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !c.isTrusted(host) {
+		return host
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !c.isTrusted(hop) {
+			return hop
+		}
+		host = hop
+	}
+
+	return host
+}
+
+func (c *Resolver) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range c.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromRequest(t *testing.T) {
+	clients, err := New([]string{"10.0.0.0/8", "172.16.0.0/12"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "direct client forging headers", remoteAddr: "203.0.113.7:5000", forwarded: "198.51.100.1", realIP: "198.51.100.1", want: "203.0.113.7"},
+		{name: "through trusted proxy", remoteAddr: "172.18.0.5:5000", forwarded: "203.0.113.7", want: "203.0.113.7"},
+		{name: "client forging a hop", remoteAddr: "172.18.0.5:5000", forwarded: "198.51.100.1, 203.0.113.7", want: "203.0.113.7"},
+		{name: "chain of trusted proxies", remoteAddr: "172.18.0.5:5000", forwarded: "203.0.113.7, 10.1.2.3", want: "203.0.113.7"},
+		{name: "only trusted hops", remoteAddr: "172.18.0.5:5000", forwarded: "10.1.2.3", want: "10.1.2.3"},
+		{name: "trusted proxy without header", remoteAddr: "172.18.0.5:5000", realIP: "198.51.100.1", want: "172.18.0.5"},
+		{name: "empty hops", remoteAddr: "172.18.0.5:5000", forwarded: "203.0.113.7, ,", want: "203.0.113.7"},
+		{name: "address without port", remoteAddr: "203.0.113.7", want: "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			if got := clients.FromRequest(r); got != tt.want {
+				t.Errorf("FromRequest = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidNetworks(t *testing.T) {
+	if _, err := New([]string{"10.0.0.0"}); err == nil {
+		t.Error("New accepted an address without a prefix length")
+	}
+}
@@ -0,0 +1,185 @@
+package jwks
+
+import (
+	"apigateway/internal/domain/models"
+	"apigateway/pkg/lib/logger/sl"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// minRefetchInterval limits how often an unknown kid can force a fetch,
+	// so tokens with made up key ids cannot be used to flood Auth.
+	minRefetchInterval = 30 * time.Second
+
+	// After a failed fetch the next one waits minRetryBackoff, doubled after
+	// every further failure up to maxRetryBackoff, so an unreachable Auth is
+	// not asked again on every request.
+	minRetryBackoff = time.Second
+	maxRetryBackoff = time.Minute
+
+	// fetchTimeout bounds a fetch, which does not end with the request that
+	// started it since other requests may be waiting for it.
+	fetchTimeout = 5 * time.Second
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+type Fetcher interface {
+	GetJWKS(ctx context.Context) ([]models.Jwk, error)
+}
+
+// Cache keeps the public keys published by Auth and refreshes them every
+// refreshInterval, or earlier when a token names a key it has not seen yet.
+// Concurrent refreshes share a single fetch.
+type Cache struct {
+	log             *slog.Logger
+	fetcher         Fetcher
+	refreshInterval time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]ed25519.PublicKey
+	fetchedAt   time.Time
+	nextAttempt time.Time
+	backoff     time.Duration
+	lastErr     error
+	inflight    *refreshCall
+}
+
+// refreshCall is a fetch under way; done is closed once err is set.
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+func New(log *slog.Logger, fetcher Fetcher, refreshInterval time.Duration) *Cache {
+	return &Cache{
+		log:             log,
+		fetcher:         fetcher,
+		refreshInterval: refreshInterval,
+		keys:            make(map[string]ed25519.PublicKey),
+	}
+}
+
+// Key returns the public key with the given id.
+func (c *Cache) Key(ctx context.Context, kid string) (ed25519.PublicKey, error) {
+	const op = "jwks.Key"
+
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	fresh := time.Since(c.fetchedAt) < c.refreshInterval
+	wait := time.Now().Before(c.nextAttempt)
+	lastErr := c.lastErr
+	c.mu.RUnlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+
+	if wait {
+		// Known keys stay usable while Auth is unreachable.
+		if ok {
+			return key, nil
+		}
+		if lastErr != nil {
+			return nil, fmt.Errorf("%s: %w", op, lastErr)
+		}
+		return nil, ErrUnknownKey
+	}
+
+	if err := c.refresh(ctx); err != nil {
+		if ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.mu.RLock()
+	key, ok = c.keys[kid]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+// refresh fetches the keys, or waits for the fetch already under way.
+func (c *Cache) refresh(ctx context.Context) error {
+	c.mu.Lock()
+	call := c.inflight
+	if call == nil {
+		call = &refreshCall{done: make(chan struct{})}
+		c.inflight = call
+		go c.fetch(context.WithoutCancel(ctx), call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetch loads the keys from Auth and schedules the earliest next attempt:
+// minRefetchInterval after a success, the current backoff after a failure.
+func (c *Cache) fetch(ctx context.Context, call *refreshCall) {
+	const op = "jwks.fetch"
+	log := c.log.With(slog.String("op", op))
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	keys, err := c.load(ctx)
+
+	c.mu.Lock()
+	now := time.Now()
+	if err != nil {
+		c.backoff = min(max(2*c.backoff, minRetryBackoff), maxRetryBackoff)
+		c.nextAttempt = now.Add(c.backoff)
+		c.lastErr = err
+		log.Error("failed to refresh signing keys", sl.Err(err), slog.Duration("retry_in", c.backoff))
+	} else {
+		c.keys = keys
+		c.fetchedAt = now
+		c.nextAttempt = now.Add(minRefetchInterval)
+		c.backoff = 0
+		c.lastErr = nil
+	}
+	c.inflight = nil
+	call.err = err
+	c.mu.Unlock()
+
+	close(call.done)
+}
+
+func (c *Cache) load(ctx context.Context) (map[string]ed25519.PublicKey, error) {
+	jwks, err := c.fetcher.GetJWKS(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]ed25519.PublicKey, len(jwks))
+	for _, jwk := range jwks {
+		if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" {
+			continue
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			c.log.Warn("skipping malformed key", slog.String("kid", jwk.Kid))
+			continue
+		}
+		keys[jwk.Kid] = ed25519.PublicKey(x)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,152 @@
+package jwks
+
+import (
+	"apigateway/internal/domain/models"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeFetcher struct {
+	calls   atomic.Int32
+	release chan struct{}
+	jwks    []models.Jwk
+	err     error
+}
+
+func (f *fakeFetcher) GetJWKS(ctx context.Context) ([]models.Jwk, error) {
+	f.calls.Add(1)
+	if f.release != nil {
+		<-f.release
+	}
+	return f.jwks, f.err
+}
+
+func newCache(fetcher Fetcher) *Cache {
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), fetcher, 5*time.Minute)
+}
+
+func jwk(t *testing.T, kid string) models.Jwk {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models.Jwk{Kty: "OKP", Crv: "Ed25519", Kid: kid, X: base64.RawURLEncoding.EncodeToString(pub)}
+}
+
+func TestKeySharesConcurrentFetches(t *testing.T) {
+	fetcher := &fakeFetcher{release: make(chan struct{}), jwks: []models.Jwk{jwk(t, "k1")}}
+	cache := newCache(fetcher)
+
+	const callers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.Key(context.Background(), "k1")
+			errs <- err
+		}()
+	}
+
+	// Let every caller queue up behind the first fetch before it returns.
+	for fetcher.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(fetcher.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Key: %v", err)
+		}
+	}
+	if got := fetcher.calls.Load(); got != 1 {
+		t.Errorf("fetched %d times, want 1", got)
+	}
+}
+
+func TestKeyBacksOffAfterFailure(t *testing.T) {
+	fetcher := &fakeFetcher{err: errors.New("auth is down")}
+	cache := newCache(fetcher)
+
+	for range 10 {
+		if _, err := cache.Key(context.Background(), "k1"); err == nil {
+			t.Fatal("Key succeeded without keys")
+		}
+	}
+	if got := fetcher.calls.Load(); got != 1 {
+		t.Errorf("fetched %d times while backing off, want 1", got)
+	}
+
+	cache.mu.Lock()
+	firstBackoff := cache.backoff
+	cache.nextAttempt = time.Time{}
+	cache.mu.Unlock()
+
+	if _, err := cache.Key(context.Background(), "k1"); err == nil {
+		t.Fatal("Key succeeded without keys")
+	}
+	cache.mu.RLock()
+	secondBackoff := cache.backoff
+	cache.mu.RUnlock()
+
+	if firstBackoff != minRetryBackoff || secondBackoff != 2*minRetryBackoff {
+		t.Errorf("backoff %v then %v, want %v then %v", firstBackoff, secondBackoff, minRetryBackoff, 2*minRetryBackoff)
+	}
+}
+
+func TestKeyKeepsKnownKeysWhileAuthIsDown(t *testing.T) {
+	fetcher := &fakeFetcher{jwks: []models.Jwk{jwk(t, "k1")}}
+	cache := newCache(fetcher)
+
+	want, err := cache.Key(context.Background(), "k1")
+	if err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+
+	// The keys go stale and Auth stops answering.
+	fetcher.err = errors.New("auth is down")
+	cache.mu.Lock()
+	cache.fetchedAt = time.Time{}
+	cache.nextAttempt = time.Time{}
+	cache.mu.Unlock()
+
+	for range 5 {
+		got, err := cache.Key(context.Background(), "k1")
+		if err != nil {
+			t.Fatalf("Key: %v", err)
+		}
+		if !got.Equal(want) {
+			t.Fatal("Key returned another key")
+		}
+	}
+	if got := fetcher.calls.Load(); got != 2 {
+		t.Errorf("fetched %d times, want 2", got)
+	}
+}
+
+func TestKeyLimitsUnknownKidFetches(t *testing.T) {
+	fetcher := &fakeFetcher{jwks: []models.Jwk{jwk(t, "k1")}}
+	cache := newCache(fetcher)
+
+	for range 10 {
+		if _, err := cache.Key(context.Background(), "made-up"); !errors.Is(err, ErrUnknownKey) {
+			t.Fatalf("Key: %v, want ErrUnknownKey", err)
+		}
+	}
+	if got := fetcher.calls.Load(); got != 1 {
+		t.Errorf("fetched %d times, want 1", got)
+	}
+}
@@ -2,24 +2,92 @@ package tokenparser
 
 import (
 	"apigateway/internal/domain/models"
-	"time"
+	"apigateway/internal/lib/jwt/jwks"
+	"context"
+	"errors"
+	"fmt"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func ParseToken(tokenString string) (*models.Claims, error) {
-	token, _ := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return models.Claims{}, nil
-	})
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token has expired")
+)
+
+type Parser struct {
+	keys     *jwks.Cache
+	issuer   string
+	audience string
+}
+
+func New(keys *jwks.Cache, issuer string, audience string) *Parser {
+	return &Parser{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+	}
+}
 
-	claims := token.Claims.(jwt.MapClaims)
-	exp := time.Unix(int64(claims["exp"].(float64)), 0)
+// ParseToken verifies the signature, issuer, audience and expiry of an
+// access token and returns its claims.
+func (p *Parser) ParseToken(ctx context.Context, tokenString string) (*models.Claims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("missing kid")
+		}
+		return p.keys.Key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithAudience(p.audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
 
-	customClaims := &models.Claims{
-		Uid:  claims["uid"].(string),
-		Role: claims["role"].(string),
-		Exp:  exp,
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
 	}
 
-	return customClaims, nil
+	uid, _ := claims["uid"].(string)
+	sid, _ := claims["sid"].(string)
+	appId, _ := claims["app_id"].(string)
+	if uid == "" {
+		return nil, fmt.Errorf("%w: missing uid", ErrInvalidToken)
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	return &models.Claims{
+		Uid:         uid,
+		Roles:       stringList(claims["roles"]),
+		Permissions: stringList(claims["perms"]),
+		Sid:         sid,
+		Exp:         exp.Time,
+		AppId:       appId,
+	}, nil
+}
+
+// stringList reads a claim holding a JSON array of strings, skipping
+// anything else.
+func stringList(claim any) []string {
+	values, _ := claim.([]any)
+
+	list := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
 }
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+// MemoryStore keeps the buckets in the gateway's memory. Buckets that have
+// filled up again are dropped now and then.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Take implements Store.
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+	rate := float64(limit.Requests) / limit.Per.Seconds()
+	burst := float64(limit.Burst)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) > sweepInterval {
+		for k, b := range s.buckets {
+			if now.After(b.full) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	res := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((burst - b.tokens) / rate)
+	b.full = now.Add(res.Reset)
+
+	return res, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
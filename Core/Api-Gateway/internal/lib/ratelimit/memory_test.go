@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	// One token a second, up to 3.
+	limit := Limit{Requests: 60, Per: time.Minute, Burst: 3}
+
+	tests := []struct {
+		name           string
+		taken          int
+		elapsed        time.Duration
+		wantAllowed    bool
+		wantRemaining  int
+		wantRetryAfter time.Duration
+		wantReset      time.Duration
+	}{
+		{name: "first request", wantAllowed: true, wantRemaining: 2, wantReset: time.Second},
+		{name: "last token of the burst", taken: 2, wantAllowed: true, wantRemaining: 0, wantReset: 3 * time.Second},
+		{name: "burst spent", taken: 3, wantRemaining: 0, wantRetryAfter: time.Second, wantReset: 3 * time.Second},
+		{name: "half a token back", taken: 3, elapsed: 500 * time.Millisecond, wantRemaining: 0, wantRetryAfter: 500 * time.Millisecond, wantReset: 2500 * time.Millisecond},
+		{name: "one token back", taken: 3, elapsed: time.Second, wantAllowed: true, wantRemaining: 0, wantReset: 3 * time.Second},
+		{name: "refill stops at the burst", taken: 3, elapsed: time.Hour, wantAllowed: true, wantRemaining: 2, wantReset: time.Second},
+	}
+
+	const tolerance = 50 * time.Millisecond
+	near := func(got, want time.Duration) bool {
+		return got >= want-tolerance && got <= want+tolerance
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore()
+			ctx := context.Background()
+
+			for range tt.taken {
+				if _, err := s.Take(ctx, "key", limit); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if b, ok := s.buckets["key"]; ok {
+				b.updated = b.updated.Add(-tt.elapsed)
+			}
+
+			res, err := s.Take(ctx, "key", limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Allowed != tt.wantAllowed || res.Remaining != tt.wantRemaining || res.Limit != limit.Burst {
+				t.Errorf("allowed %v, remaining %d, limit %d; want %v, %d, %d",
+					res.Allowed, res.Remaining, res.Limit, tt.wantAllowed, tt.wantRemaining, limit.Burst)
+			}
+			if !near(res.RetryAfter, tt.wantRetryAfter) {
+				t.Errorf("retry after %v, want %v", res.RetryAfter, tt.wantRetryAfter)
+			}
+			if !near(res.Reset, tt.wantReset) {
+				t.Errorf("reset %v, want %v", res.Reset, tt.wantReset)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreKeysAreSeparate(t *testing.T) {
+	s := NewMemoryStore()
+	limit := Limit{Requests: 1, Per: time.Hour, Burst: 1}
+
+	if res, _ := s.Take(context.Background(), "a", limit); !res.Allowed {
+		t.Fatal("first request of a refused")
+	}
+	if res, _ := s.Take(context.Background(), "a", limit); res.Allowed {
+		t.Error("second request of a allowed")
+	}
+	if res, _ := s.Take(context.Background(), "b", limit); !res.Allowed {
+		t.Error("first request of b refused")
+	}
+}
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"apigateway/internal/lib/clientip"
+	"apigateway/pkg/config"
+	"apigateway/pkg/lib/logger/sl"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Limit is a token bucket: it holds up to Burst tokens and gains Requests
+// of them every Per.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// Result tells how a request fared against its bucket. Reset is the time
+// until the bucket is full again, RetryAfter the time until a refused
+// request could pass.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. MemoryStore serves a single gateway; replicas
+// share their limits through a Store kept in a common database.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Limiter applies the budget of each route group.
+type Limiter struct {
+	log     *slog.Logger
+	store   Store
+	enabled bool
+	groups  map[string]Limit
+	clients *clientip.Resolver
+}
+
+func New(log *slog.Logger, store Store, cfg config.RateLimitConfig) (*Limiter, error) {
+	const op = "ratelimit.new"
+
+	groups := make(map[string]Limit, len(cfg.Groups))
+	for name, group := range cfg.Groups {
+		if group.Requests <= 0 || group.Per <= 0 {
+			return nil, fmt.Errorf("%s: group %q needs positive requests and per", op, name)
+		}
+		burst := group.Burst
+		if burst <= 0 {
+			burst = group.Requests
+		}
+		groups[name] = Limit{Requests: group.Requests, Per: group.Per, Burst: burst}
+	}
+
+	clients, err := clientip.New(cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Limiter{
+		log:     log,
+		store:   store,
+		enabled: cfg.Enabled,
+		groups:  groups,
+		clients: clients,
+	}, nil
+}
+
+// Take spends a token from the bucket of key in group. ok is false when
+// the group has no limit. Should the store fail, the request is let
+// through rather than refused.
+func (l *Limiter) Take(ctx context.Context, group string, key string) (res Result, limit Limit, ok bool) {
+	const op = "ratelimit.take"
+
+	limit, ok = l.groups[group]
+	if !l.enabled || !ok {
+		return Result{}, Limit{}, false
+	}
+
+	res, err := l.store.Take(ctx, group+":"+key, limit)
+	if err != nil {
+		l.log.With(slog.String("op", op)).Error("Rate limit store failed", sl.Err(err))
+		return Result{}, Limit{}, false
+	}
+
+	return res, limit, true
+}
+
+// ClientIP returns the address of the client that made r, see
+// clientip.Resolver.
+func (l *Limiter) ClientIP(r *http.Request) string {
+	return l.clients.FromRequest(r)
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"apigateway/pkg/config"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestLimiterTake(t *testing.T) {
+	groups := map[string]config.RateLimitGroup{
+		"auth":    {Requests: 2, Per: time.Minute, Burst: 1},
+		"default": {Requests: 2, Per: time.Minute},
+	}
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		group     string
+		wantOk    bool
+		wantBurst int
+	}{
+		{name: "limited group", enabled: true, group: "auth", wantOk: true, wantBurst: 1},
+		{name: "burst defaults to requests", enabled: true, group: "default", wantOk: true, wantBurst: 2},
+		{name: "group left out", enabled: true, group: "write"},
+		{name: "disabled", group: "auth"},
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, err := New(log, NewMemoryStore(), config.RateLimitConfig{Enabled: tt.enabled, Groups: groups})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, limit, ok := limiter.Take(context.Background(), tt.group, "ip:203.0.113.7")
+			if ok != tt.wantOk {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && (!res.Allowed || limit.Burst != tt.wantBurst) {
+				t.Errorf("allowed %v with burst %d, want allowed with burst %d", res.Allowed, limit.Burst, tt.wantBurst)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidGroups(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tests := []struct {
+		name string
+		cfg  config.RateLimitConfig
+	}{
+		{name: "no requests", cfg: config.RateLimitConfig{Groups: map[string]config.RateLimitGroup{"auth": {Per: time.Minute}}}},
+		{name: "no period", cfg: config.RateLimitConfig{Groups: map[string]config.RateLimitGroup{"auth": {Requests: 1}}}},
+		{name: "invalid proxy network", cfg: config.RateLimitConfig{TrustedProxies: []string{"nginx"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(log, NewMemoryStore(), tt.cfg); err == nil {
+				t.Error("New accepted an invalid config")
+			}
+		})
+	}
+}
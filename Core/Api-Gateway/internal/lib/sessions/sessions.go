@@ -0,0 +1,94 @@
+package sessions
+
+import (
+	"apigateway/pkg/lib/logger/sl"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// maxEntries bounds the cache; only validly signed tokens are added, but
+// there is no point keeping more.
+const maxEntries = 4096
+
+var ErrInactive = errors.New("session is not active")
+
+type Introspector interface {
+	Introspect(ctx context.Context, token string) (bool, error)
+}
+
+type entry struct {
+	active    bool
+	fetchedAt time.Time
+}
+
+// Cache remembers for ttl whether Auth still accepts an access token, so
+// that checking the session of every request stays cheap. A token of a
+// session that was logged out is therefore refused at most ttl after the
+// logout.
+type Cache struct {
+	log          *slog.Logger
+	introspector Introspector
+	ttl          time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]entry
+}
+
+func New(log *slog.Logger, introspector Introspector, ttl time.Duration) *Cache {
+	return &Cache{
+		log:          log,
+		introspector: introspector,
+		ttl:          ttl,
+		entries:      make(map[string]entry),
+	}
+}
+
+// Check returns nil if the session token was issued for is still active
+// and ErrInactive if it has ended.
+func (c *Cache) Check(ctx context.Context, token string) error {
+	const op = "sessions.Check"
+	log := c.log.With(slog.String("op", op))
+
+	c.mu.RLock()
+	cached, ok := c.entries[token]
+	c.mu.RUnlock()
+
+	if !ok || time.Since(cached.fetchedAt) >= c.ttl {
+		active, err := c.introspector.Introspect(ctx, token)
+		switch {
+		case err == nil:
+			cached = c.store(token, active)
+		case ok:
+			// The last known state stays in use while Auth is unreachable.
+			log.Error("failed to recheck token", sl.Err(err))
+		default:
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if !cached.active {
+		return ErrInactive
+	}
+
+	return nil
+}
+
+func (c *Cache) store(token string, active bool) entry {
+	fetched := entry{
+		active:    active,
+		fetchedAt: time.Now(),
+	}
+
+	c.mu.Lock()
+	if len(c.entries) >= maxEntries {
+		c.entries = make(map[string]entry)
+	}
+	c.entries[token] = fetched
+	c.mu.Unlock()
+
+	return fetched
+}
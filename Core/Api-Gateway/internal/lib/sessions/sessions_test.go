@@ -0,0 +1,74 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type fakeIntrospector struct {
+	calls  int
+	active bool
+	err    error
+}
+
+func (f *fakeIntrospector) Introspect(ctx context.Context, token string) (bool, error) {
+	f.calls++
+	return f.active, f.err
+}
+
+func newCache(introspector Introspector, ttl time.Duration) *Cache {
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), introspector, ttl)
+}
+
+func TestCheckCachesWithinTTL(t *testing.T) {
+	introspector := &fakeIntrospector{active: true}
+	cache := newCache(introspector, time.Hour)
+
+	for range 2 {
+		if err := cache.Check(context.Background(), "token"); err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+	}
+	if introspector.calls != 1 {
+		t.Errorf("introspected %d times, want 1", introspector.calls)
+	}
+}
+
+func TestCheckRefusesEndedSessionAfterTTL(t *testing.T) {
+	introspector := &fakeIntrospector{active: true}
+	cache := newCache(introspector, time.Millisecond)
+
+	if err := cache.Check(context.Background(), "token"); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	introspector.active = false
+	time.Sleep(5 * time.Millisecond)
+
+	if err := cache.Check(context.Background(), "token"); !errors.Is(err, ErrInactive) {
+		t.Fatalf("Check after logout: %v, want ErrInactive", err)
+	}
+}
+
+func TestCheckKeepsLastStateWhileAuthIsDown(t *testing.T) {
+	introspector := &fakeIntrospector{active: true}
+	cache := newCache(introspector, time.Millisecond)
+
+	if err := cache.Check(context.Background(), "token"); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	introspector.err = errors.New("auth is down")
+	time.Sleep(5 * time.Millisecond)
+
+	if err := cache.Check(context.Background(), "token"); err != nil {
+		t.Errorf("Check with a known session: %v", err)
+	}
+	if err := cache.Check(context.Background(), "other"); err == nil || errors.Is(err, ErrInactive) {
+		t.Errorf("Check with an unknown session: %v, want the Auth error", err)
+	}
+}
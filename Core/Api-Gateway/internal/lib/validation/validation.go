@@ -0,0 +1,34 @@
+package validation
+
+import (
+	"apigateway/internal/lib/apierror"
+
+	"github.com/chas3air/shared/validation"
+)
+
+// Struct checks every rule of s and returns all violations, or nil when s
+// is valid. The rules are the ones the services check again on their side.
+func Struct(s any) []apierror.FieldError {
+	return fieldErrors(validation.Check(s))
+}
+
+// Partial checks only the rules of the named fields of s, for requests
+// that change just those fields.
+func Partial(s any, fields ...string) []apierror.FieldError {
+	return fieldErrors(validation.CheckPartial(s, fields...))
+}
+
+func fieldErrors(violations []validation.Violation) []apierror.FieldError {
+	if violations == nil {
+		return nil
+	}
+
+	fields := make([]apierror.FieldError, 0, len(violations))
+	for _, violation := range violations {
+		fields = append(fields, apierror.FieldError{
+			Field:   violation.Field,
+			Message: violation.Message,
+		})
+	}
+	return fields
+}
@@ -0,0 +1,86 @@
+package validation
+
+import (
+	"apigateway/internal/lib/apierror"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type profile struct {
+	Email    string    `json:"email" validate:"required,email"`
+	Nick     string    `json:"nick" validate:"min=3,max=8"`
+	Title    string    `json:"title" validate:"min=1"`
+	Birthday time.Time `json:"birthday" validate:"notfuture"`
+	Internal string    `json:"-" validate:"required"`
+	Secret   string    `validate:"oneof=a b"`
+}
+
+func valid() profile {
+	return profile{
+		Email:    "user@example.com",
+		Nick:     "user",
+		Title:    "title",
+		Birthday: time.Now().AddDate(-20, 0, 0),
+		Internal: "set",
+		Secret:   "a",
+	}
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(p *profile)
+		want   []apierror.FieldError
+	}{
+		{name: "valid", change: func(p *profile) {}},
+		{name: "required", change: func(p *profile) { p.Email = "" }, want: []apierror.FieldError{{Field: "email", Message: "is required"}}},
+		{name: "email", change: func(p *profile) { p.Email = "user" }, want: []apierror.FieldError{{Field: "email", Message: "must be a valid email address"}}},
+		{name: "too short", change: func(p *profile) { p.Nick = "ab" }, want: []apierror.FieldError{{Field: "nick", Message: "must be at least 3 characters long"}}},
+		{name: "too long", change: func(p *profile) { p.Nick = strings.Repeat("a", 9) }, want: []apierror.FieldError{{Field: "nick", Message: "must be at most 8 characters long"}}},
+		{name: "empty", change: func(p *profile) { p.Title = "" }, want: []apierror.FieldError{{Field: "title", Message: "must not be empty"}}},
+		{name: "future", change: func(p *profile) { p.Birthday = time.Now().Add(time.Hour) }, want: []apierror.FieldError{{Field: "birthday", Message: "must not be in the future"}}},
+		{name: "other rule", change: func(p *profile) { p.Secret = "c" }, want: []apierror.FieldError{{Field: "Secret", Message: "is invalid"}}},
+		{name: "field without a json key", change: func(p *profile) { p.Internal = "" }, want: []apierror.FieldError{{Field: "Internal", Message: "is required"}}},
+		{
+			name:   "all violations",
+			change: func(p *profile) { p.Email, p.Nick = "", "ab" },
+			want:   []apierror.FieldError{{Field: "email", Message: "is required"}, {Field: "nick", Message: "must be at least 3 characters long"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid()
+			tt.change(&p)
+
+			if got := Struct(p); !slices.Equal(got, tt.want) {
+				t.Errorf("Struct = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartial(t *testing.T) {
+	p := valid()
+	p.Email, p.Nick = "", "ab"
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   []apierror.FieldError
+	}{
+		{name: "broken field", fields: []string{"Nick"}, want: []apierror.FieldError{{Field: "nick", Message: "must be at least 3 characters long"}}},
+		{name: "valid field", fields: []string{"Title"}},
+		{name: "broken field left out", fields: []string{"Title", "Email"}, want: []apierror.FieldError{{Field: "email", Message: "is required"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Partial(p, tt.fields...); !slices.Equal(got, tt.want) {
+				t.Errorf("Partial(%v) = %v, want %v", tt.fields, got, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -22,7 +23,7 @@ func New(log *slog.Logger, storage interfaces.Auth) *AuthService {
 	}
 }
 
-func (as *AuthService) Login(ctx context.Context, email string, password string) (string, string, error) {
+func (as *AuthService) Login(ctx context.Context, email string, password string, app models.AppCredentials, device models.Device) (models.LoginResult, error) {
 	const op = "service.auth.login"
 	log := as.log.With(
 		slog.String("op", op),
@@ -32,16 +33,16 @@ func (as *AuthService) Login(ctx context.Context, email string, password string)
 
 	select {
 	case <-ctx.Done():
-		return "", "", fmt.Errorf("%s: %w", op, ctx.Err())
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	accessToken, refreshToken, err := as.storage.Login(ctx, email, password)
+	result, err := as.storage.Login(ctx, email, password, app, device)
 	if err != nil {
-		return "", "", fmt.Errorf("%s: %w", op, err)
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return accessToken, refreshToken, nil
+	return result, nil
 }
 
 func (as *AuthService) Register(ctx context.Context, user models.User) error {
@@ -86,3 +87,455 @@ func (as *AuthService) IsAdmin(ctx context.Context, user_id uuid.UUID) (bool, er
 
 	return isAdmin, nil
 }
+
+func (as *AuthService) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	const op = "service.auth.refresh"
+
+	select {
+	case <-ctx.Done():
+		return "", "", fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	accessToken, newRefreshToken, err := as.storage.Refresh(ctx, refreshToken)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return accessToken, newRefreshToken, nil
+}
+
+func (as *AuthService) Logout(ctx context.Context, uid uuid.UUID, sid uuid.UUID) error {
+	const op = "service.auth.logout"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if err := as.storage.Logout(ctx, uid, sid); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthService) LogoutAll(ctx context.Context, uid uuid.UUID) (int64, error) {
+	const op = "service.auth.logoutAll"
+
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	revoked, err := as.storage.LogoutAll(ctx, uid)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revoked, nil
+}
+
+func (as *AuthService) GetSessions(ctx context.Context, uid uuid.UUID) ([]models.Session, error) {
+	const op = "service.auth.getSessions"
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	sessions, err := as.storage.GetSessions(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+func (as *AuthService) GetJWKS(ctx context.Context) ([]models.Jwk, error) {
+	const op = "service.auth.getJWKS"
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	jwks, err := as.storage.GetJWKS(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return jwks, nil
+}
+
+func (as *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	const op = "service.auth.verifyEmail"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if err := as.storage.VerifyEmail(ctx, token); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthService) ResendVerificationEmail(ctx context.Context, email string) error {
+	const op = "service.auth.resendVerificationEmail"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if err := as.storage.ResendVerificationEmail(ctx, email); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	const op = "service.auth.requestPasswordReset"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if err := as.storage.RequestPasswordReset(ctx, email); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthService) ConfirmPasswordReset(ctx context.Context, token string, newPassword string) error {
+	const op = "service.auth.confirmPasswordReset"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if err := as.storage.ConfirmPasswordReset(ctx, token, newPassword); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthService) ChangePassword(ctx context.Context, uid uuid.UUID, sid uuid.UUID, currentPassword string, newPassword string) (int64, error) {
+	const op = "service.auth.changePassword"
+
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	revoked, err := as.storage.ChangePassword(ctx, uid, sid, currentPassword, newPassword)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revoked, nil
+}
+
+func (as *AuthService) BeginTotpEnrollment(ctx context.Context, uid uuid.UUID) (models.TotpEnrollment, error) {
+	const op = "service.auth.beginTotpEnrollment"
+
+	select {
+	case <-ctx.Done():
+		return models.TotpEnrollment{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	enrollment, err := as.storage.BeginTotpEnrollment(ctx, uid)
+	if err != nil {
+		return models.TotpEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return enrollment, nil
+}
+
+func (as *AuthService) BeginTotpEnrollmentWithChallenge(ctx context.Context, challengeToken string) (models.TotpEnrollment, error) {
+	const op = "service.auth.beginTotpEnrollmentWithChallenge"
+
+	select {
+	case <-ctx.Done():
+		return models.TotpEnrollment{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	enrollment, err := as.storage.BeginTotpEnrollmentWithChallenge(ctx, challengeToken)
+	if err != nil {
+		return models.TotpEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return enrollment, nil
+}
+
+func (as *AuthService) ConfirmTotpEnrollment(ctx context.Context, uid uuid.UUID, code string) ([]string, error) {
+	const op = "service.auth.confirmTotpEnrollment"
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	recoveryCodes, err := as.storage.ConfirmTotpEnrollment(ctx, uid, code)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return recoveryCodes, nil
+}
+
+func (as *AuthService) DisableTotp(ctx context.Context, uid uuid.UUID, code string) error {
+	const op = "service.auth.disableTotp"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if err := as.storage.DisableTotp(ctx, uid, code); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthService) VerifyTotpLogin(ctx context.Context, challengeToken string, code string, device models.Device) (models.LoginResult, error) {
+	const op = "service.auth.verifyTotpLogin"
+
+	select {
+	case <-ctx.Done():
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	result, err := as.storage.VerifyTotpLogin(ctx, challengeToken, code, device)
+	if err != nil {
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+func (as *AuthService) UnlockAccount(ctx context.Context, uid uuid.UUID) error {
+	const op = "service.auth.unlockAccount"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if err := as.storage.UnlockAccount(ctx, uid); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthService) CreatePersonalAccessToken(ctx context.Context, uid uuid.UUID, name string, scopes []string, expiresAt time.Time) (string, models.PersonalAccessToken, error) {
+	const op = "service.auth.createPersonalAccessToken"
+
+	select {
+	case <-ctx.Done():
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	token, pat, err := as.storage.CreatePersonalAccessToken(ctx, uid, name, scopes, expiresAt)
+	if err != nil {
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, pat, nil
+}
+
+func (as *AuthService) ListPersonalAccessTokens(ctx context.Context, uid uuid.UUID) ([]models.PersonalAccessToken, error) {
+	const op = "service.auth.listPersonalAccessTokens"
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	pats, err := as.storage.ListPersonalAccessTokens(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pats, nil
+}
+
+func (as *AuthService) RevokePersonalAccessToken(ctx context.Context, uid uuid.UUID, id uuid.UUID) error {
+	const op = "service.auth.revokePersonalAccessToken"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if err := as.storage.RevokePersonalAccessToken(ctx, uid, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthService) VerifyPersonalAccessToken(ctx context.Context, token string) (*models.Claims, error) {
+	const op = "service.auth.verifyPersonalAccessToken"
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	claims, err := as.storage.VerifyPersonalAccessToken(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return claims, nil
+}
+
+// Introspect reports whether Auth still accepts token, which it does not
+// once the session of an access token has ended.
+func (as *AuthService) Introspect(ctx context.Context, token string) (bool, error) {
+	const op = "service.auth.introspect"
+
+	select {
+	case <-ctx.Done():
+		return false, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	active, err := as.storage.Introspect(ctx, token)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return active, nil
+}
+
+func (as *AuthService) CreateApp(ctx context.Context, name string, redirectUris []string, scopes []string) (string, models.App, error) {
+	const op = "service.auth.createApp"
+
+	select {
+	case <-ctx.Done():
+		return "", models.App{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	secret, app, err := as.storage.CreateApp(ctx, name, redirectUris, scopes)
+	if err != nil {
+		return "", models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return secret, app, nil
+}
+
+func (as *AuthService) GetApp(ctx context.Context, id uuid.UUID) (models.App, error) {
+	const op = "service.auth.getApp"
+
+	select {
+	case <-ctx.Done():
+		return models.App{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	app, err := as.storage.GetApp(ctx, id)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return app, nil
+}
+
+func (as *AuthService) ListApps(ctx context.Context) ([]models.App, error) {
+	const op = "service.auth.listApps"
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	apps, err := as.storage.ListApps(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return apps, nil
+}
+
+func (as *AuthService) RotateAppSecret(ctx context.Context, id uuid.UUID) (string, models.App, error) {
+	const op = "service.auth.rotateAppSecret"
+
+	select {
+	case <-ctx.Done():
+		return "", models.App{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	secret, app, err := as.storage.RotateAppSecret(ctx, id)
+	if err != nil {
+		return "", models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return secret, app, nil
+}
+
+func (as *AuthService) RevokeApp(ctx context.Context, id uuid.UUID) error {
+	const op = "service.auth.revokeApp"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if err := as.storage.RevokeApp(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	const op = "service.auth.listRoles"
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	roles, err := as.storage.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
@@ -65,6 +65,27 @@ func (cs *CommentsService) GetCommentsByArticleId(ctx context.Context, aid uuid.
 	return comments, nil
 }
 
+func (cs *CommentsService) GetCommentsByOwnerId(ctx context.Context, uid uuid.UUID) ([]models.Comment, error) {
+	const op = "services.comments.getCommentsByOwnerId"
+	log := cs.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	comments, err := cs.storage.GetCommentsByOwnerId(ctx, uid)
+	if err != nil {
+		log.Error("error getting comments by owner_id", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return comments, nil
+}
+
 func (cs *CommentsService) Insert(ctx context.Context, comment models.Comment) (models.Comment, error) {
 	const op = "services.comments.insert"
 	log := cs.log.With(
@@ -4,6 +4,7 @@ import (
 	"apigateway/internal/domain/models"
 	"apigateway/internal/storage"
 	"context"
+	"slices"
 
 	"github.com/google/uuid"
 )
@@ -42,7 +43,7 @@ func (a *MockAuth) Register(ctx context.Context, user models.User) (err error) {
 func (a *MockAuth) IsAdmin(ctx context.Context, userID uuid.UUID) (isAdmin bool, err error) {
 	for _, user := range a.users {
 		if user.Id == userID {
-			return user.Role == "admin", nil
+			return slices.Contains(user.Roles, "admin"), nil
 		}
 	}
 
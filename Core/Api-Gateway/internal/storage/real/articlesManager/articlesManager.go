@@ -9,25 +9,31 @@ import (
 	"log/slog"
 
 	amv1 "github.com/chas3air/protos/gen/go/articlesManager"
+	"github.com/chas3air/shared/grpcconn"
 	"github.com/google/uuid"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type ArticlesManageStorage struct {
-	log         *slog.Logger
-	ServiceHost string
-	ServicePort int
+	log  *slog.Logger
+	conn *grpcconn.Conn
 }
 
-func New(log *slog.Logger, serviceHost string, servicePort int) *ArticlesManageStorage {
+// New builds the storage on conn, which is shared by all its calls.
+func New(log *slog.Logger, conn *grpcconn.Conn) *ArticlesManageStorage {
 	return &ArticlesManageStorage{
-		log:         log,
-		ServiceHost: serviceHost,
-		ServicePort: servicePort,
+		log:  log,
+		conn: conn,
 	}
 }
 
+// ReadMethods are the calls of the storage that only read and so may be
+// retried.
+var ReadMethods = []string{
+	amv1.ArticlesManager_GetArticles_FullMethodName,
+	amv1.ArticlesManager_GetArticleById_FullMethodName,
+	amv1.ArticlesManager_GetArticlesByOwnerId_FullMethodName,
+}
+
 // GetArticles implements articles.IArticlesStorage.
 func (a *ArticlesManageStorage) GetArticles(ctx context.Context) ([]models.Article, error) {
 	const op = "articlesmanagestorage.getArticles"
@@ -39,15 +45,11 @@ func (a *ArticlesManageStorage) GetArticles(ctx context.Context) ([]models.Artic
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", a.ServiceHost, a.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := a.conn.Get()
 	if err != nil {
 		log.Error("Failed to connect to gRPC server", sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := amv1.NewArticlesManagerClient(conn)
 	res, err := c.GetArticles(ctx, nil)
@@ -80,15 +82,11 @@ func (a *ArticlesManageStorage) GetArticleById(ctx context.Context, aid uuid.UUI
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", a.ServiceHost, a.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := a.conn.Get()
 	if err != nil {
 		log.Error("Failed to connect to gRPC server", sl.Err(err))
 		return models.Article{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := amv1.NewArticlesManagerClient(conn)
 	res, err := c.GetArticleById(ctx, &amv1.GetArticleByIdRequest{
@@ -119,15 +117,11 @@ func (a *ArticlesManageStorage) GetArticleByOwnerId(ctx context.Context, uid uui
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", a.ServiceHost, a.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := a.conn.Get()
 	if err != nil {
 		log.Error("Failed to connect to gRPC server", sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := amv1.NewArticlesManagerClient(conn)
 	res, err := c.GetArticlesByOwnerId(ctx, &amv1.GetArticlesByOwnerIdRequest{
@@ -168,15 +162,11 @@ func (a *ArticlesManageStorage) Insert(ctx context.Context, article models.Artic
 		return models.Article{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", a.ServiceHost, a.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := a.conn.Get()
 	if err != nil {
 		log.Error("Failed to connect to gRPC server", sl.Err(err))
 		return models.Article{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := amv1.NewArticlesManagerClient(conn)
 	_, err = c.InsertArticle(ctx, &amv1.InsertArticleRequest{
@@ -207,15 +197,11 @@ func (a *ArticlesManageStorage) Update(ctx context.Context, aid uuid.UUID, artic
 		return models.Article{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", a.ServiceHost, a.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := a.conn.Get()
 	if err != nil {
 		log.Error("Failed to connect to gRPC server", sl.Err(err))
 		return models.Article{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := amv1.NewArticlesManagerClient(conn)
 	_, err = c.UpdateArticle(ctx, &amv1.UpdateArticleRequest{
@@ -241,15 +227,11 @@ func (a *ArticlesManageStorage) Delete(ctx context.Context, aid uuid.UUID) (mode
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", a.ServiceHost, a.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := a.conn.Get()
 	if err != nil {
 		log.Error("Failed to connect to gRPC server", sl.Err(err))
 		return models.Article{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := amv1.NewArticlesManagerClient(conn)
 	res, err := c.DeleteArticle(ctx, &amv1.DeleteArticleRequest{
@@ -7,28 +7,41 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	authv1 "github.com/chas3air/protos/gen/go/auth"
+	"github.com/chas3air/shared/grpcconn"
 	"github.com/google/uuid"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type AuthStorage struct {
-	log        *slog.Logger
-	ServerHost string
-	ServerPort int
+	log  *slog.Logger
+	conn *grpcconn.Conn
 }
 
-func New(log *slog.Logger, host string, port int) *AuthStorage {
+// New builds the storage on conn, which is shared by all its calls.
+func New(log *slog.Logger, conn *grpcconn.Conn) *AuthStorage {
 	return &AuthStorage{
-		log:        log,
-		ServerHost: host,
-		ServerPort: port,
+		log:  log,
+		conn: conn,
 	}
 }
 
-func (as *AuthStorage) Login(ctx context.Context, email string, password string) (accessToken string, refreshToken string, err error) {
+// ReadMethods are the calls of the storage that only read and so may be
+// retried.
+var ReadMethods = []string{
+	authv1.Auth_IsAdmin_FullMethodName,
+	authv1.Auth_GetSessions_FullMethodName,
+	authv1.Auth_GetJWKS_FullMethodName,
+	authv1.Auth_GetApp_FullMethodName,
+	authv1.Auth_ListApps_FullMethodName,
+	authv1.Auth_ListPersonalAccessTokens_FullMethodName,
+	authv1.Auth_ListRoles_FullMethodName,
+	authv1.Auth_Introspect_FullMethodName,
+}
+
+func (as *AuthStorage) Login(ctx context.Context, email string, password string, app models.AppCredentials, device models.Device) (models.LoginResult, error) {
 	const op = "service.auth.login"
 	log := as.log.With(
 		slog.String("op", op),
@@ -37,32 +50,39 @@ func (as *AuthStorage) Login(ctx context.Context, email string, password string)
 
 	select {
 	case <-ctx.Done():
-		return "", "", fmt.Errorf("%s: %w", op, ctx.Err())
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", as.ServerHost, as.ServerPort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := as.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
-		return "", "", fmt.Errorf("%s: %w", op, err)
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	req := &authv1.LoginRequest{Email: email,
+		Password:  password,
+		UserAgent: device.UserAgent,
+		Ip:        device.Ip,
+	}
+	if app.Id != uuid.Nil {
+		req.AppId = app.Id.String()
+		req.AppSecret = app.Secret
 	}
-	defer conn.Close()
 
 	c := authv1.NewAuthClient(conn)
-	res, err := c.Login(ctx,
-		&authv1.LoginRequest{Email: email,
-			Password: password,
-		},
-	)
+	res, err := c.Login(ctx, req)
 	if err != nil {
 		log.Warn("failed to get users", sl.Err(err))
-		return "", "", fmt.Errorf("%s: %w", op, err)
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return res.GetAccessToken(), res.GetRefreshToken(), nil
+	return models.LoginResult{
+		AccessToken:    res.GetAccessToken(),
+		RefreshToken:   res.GetRefreshToken(),
+		ChallengeToken: res.GetChallengeToken(),
+		ChallengeType:  res.GetChallengeType(),
+	}, nil
 }
 
 func (as *AuthStorage) Register(ctx context.Context, user models.User) (err error) {
@@ -78,15 +98,11 @@ func (as *AuthStorage) Register(ctx context.Context, user models.User) (err erro
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", as.ServerHost, as.ServerPort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := as.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := authv1.NewAuthClient(conn)
 
@@ -115,15 +131,11 @@ func (as *AuthStorage) IsAdmin(ctx context.Context, userID uuid.UUID) (bool, err
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", as.ServerHost, as.ServerPort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := as.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := authv1.NewAuthClient(conn)
 	res, err := c.IsAdmin(ctx, &authv1.IsAdminRequest{
@@ -135,3 +147,886 @@ func (as *AuthStorage) IsAdmin(ctx context.Context, userID uuid.UUID) (bool, err
 
 	return res.IsAdmin, nil
 }
+
+func (as *AuthStorage) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	const op = "service.auth.refresh"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return "", "", fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.Refresh(ctx, &authv1.RefreshRequest{
+		RefreshToken: refreshToken,
+	})
+	if err != nil {
+		log.Warn("failed to refresh tokens", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.GetAccessToken(), res.GetRefreshToken(), nil
+}
+
+func (as *AuthStorage) Logout(ctx context.Context, uid uuid.UUID, sid uuid.UUID) error {
+	const op = "service.auth.logout"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	_, err = c.Logout(ctx, &authv1.LogoutRequest{
+		UserId:    uid.String(),
+		SessionId: sid.String(),
+	})
+	if err != nil {
+		log.Warn("failed to logout", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthStorage) LogoutAll(ctx context.Context, uid uuid.UUID) (int64, error) {
+	const op = "service.auth.logoutAll"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.LogoutAll(ctx, &authv1.LogoutAllRequest{
+		UserId: uid.String(),
+	})
+	if err != nil {
+		log.Warn("failed to logout from all sessions", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.GetRevoked(), nil
+}
+
+func (as *AuthStorage) GetSessions(ctx context.Context, uid uuid.UUID) ([]models.Session, error) {
+	const op = "service.auth.getSessions"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.GetSessions(ctx, &authv1.GetSessionsRequest{
+		UserId: uid.String(),
+	})
+	if err != nil {
+		log.Warn("failed to get sessions", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sessions := make([]models.Session, 0, len(res.GetSessions()))
+	for _, proto_session := range res.GetSessions() {
+		session, err := authprofiles.ProtoSessionToSession(proto_session)
+		if err != nil {
+			log.Warn("failed to convert session", sl.Err(err))
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func (as *AuthStorage) GetJWKS(ctx context.Context) ([]models.Jwk, error) {
+	const op = "service.auth.getJWKS"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.GetJWKS(ctx, &authv1.GetJWKSRequest{})
+	if err != nil {
+		log.Warn("failed to get signing keys", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	jwks := make([]models.Jwk, 0, len(res.GetKeys()))
+	for _, proto_jwk := range res.GetKeys() {
+		jwks = append(jwks, authprofiles.ProtoJwkToJwk(proto_jwk))
+	}
+
+	return jwks, nil
+}
+
+func (as *AuthStorage) VerifyEmail(ctx context.Context, token string) error {
+	const op = "service.auth.verifyEmail"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	_, err = c.VerifyEmail(ctx, &authv1.VerifyEmailRequest{
+		Token: token,
+	})
+	if err != nil {
+		log.Warn("failed to verify email", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthStorage) ResendVerificationEmail(ctx context.Context, email string) error {
+	const op = "service.auth.resendVerificationEmail"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	_, err = c.ResendVerificationEmail(ctx, &authv1.ResendVerificationEmailRequest{
+		Email: email,
+	})
+	if err != nil {
+		log.Warn("failed to resend verification email", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthStorage) RequestPasswordReset(ctx context.Context, email string) error {
+	const op = "service.auth.requestPasswordReset"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	_, err = c.RequestPasswordReset(ctx, &authv1.RequestPasswordResetRequest{
+		Email: email,
+	})
+	if err != nil {
+		log.Warn("failed to request password reset", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthStorage) ConfirmPasswordReset(ctx context.Context, token string, newPassword string) error {
+	const op = "service.auth.confirmPasswordReset"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	_, err = c.ConfirmPasswordReset(ctx, &authv1.ConfirmPasswordResetRequest{
+		Token:       token,
+		NewPassword: newPassword,
+	})
+	if err != nil {
+		log.Warn("failed to reset password", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthStorage) ChangePassword(ctx context.Context, uid uuid.UUID, sid uuid.UUID, currentPassword string, newPassword string) (int64, error) {
+	const op = "service.auth.changePassword"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.ChangePassword(ctx, &authv1.ChangePasswordRequest{
+		UserId:          uid.String(),
+		SessionId:       sid.String(),
+		CurrentPassword: currentPassword,
+		NewPassword:     newPassword,
+	})
+	if err != nil {
+		log.Warn("failed to change password", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.GetRevokedSessions(), nil
+}
+
+func (as *AuthStorage) BeginTotpEnrollment(ctx context.Context, uid uuid.UUID) (models.TotpEnrollment, error) {
+	const op = "service.auth.beginTotpEnrollment"
+	return as.beginTotpEnrollment(ctx, op, &authv1.BeginTotpEnrollmentRequest{
+		UserId: uid.String(),
+	})
+}
+
+func (as *AuthStorage) BeginTotpEnrollmentWithChallenge(ctx context.Context, challengeToken string) (models.TotpEnrollment, error) {
+	const op = "service.auth.beginTotpEnrollmentWithChallenge"
+	return as.beginTotpEnrollment(ctx, op, &authv1.BeginTotpEnrollmentRequest{
+		ChallengeToken: challengeToken,
+	})
+}
+
+func (as *AuthStorage) beginTotpEnrollment(ctx context.Context, op string, req *authv1.BeginTotpEnrollmentRequest) (models.TotpEnrollment, error) {
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.TotpEnrollment{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return models.TotpEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.BeginTotpEnrollment(ctx, req)
+	if err != nil {
+		log.Warn("failed to start totp enrollment", sl.Err(err))
+		return models.TotpEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.TotpEnrollment{
+		Secret:     res.GetSecret(),
+		OtpauthUri: res.GetOtpauthUri(),
+	}, nil
+}
+
+func (as *AuthStorage) ConfirmTotpEnrollment(ctx context.Context, uid uuid.UUID, code string) ([]string, error) {
+	const op = "service.auth.confirmTotpEnrollment"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.ConfirmTotpEnrollment(ctx, &authv1.ConfirmTotpEnrollmentRequest{
+		UserId: uid.String(),
+		Code:   code,
+	})
+	if err != nil {
+		log.Warn("failed to confirm totp enrollment", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.GetRecoveryCodes(), nil
+}
+
+func (as *AuthStorage) DisableTotp(ctx context.Context, uid uuid.UUID, code string) error {
+	const op = "service.auth.disableTotp"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	_, err = c.DisableTotp(ctx, &authv1.DisableTotpRequest{
+		UserId: uid.String(),
+		Code:   code,
+	})
+	if err != nil {
+		log.Warn("failed to disable totp", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthStorage) VerifyTotpLogin(ctx context.Context, challengeToken string, code string, device models.Device) (models.LoginResult, error) {
+	const op = "service.auth.verifyTotpLogin"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.VerifyTotpLogin(ctx, &authv1.VerifyTotpLoginRequest{
+		ChallengeToken: challengeToken,
+		Code:           code,
+		UserAgent:      device.UserAgent,
+		Ip:             device.Ip,
+	})
+	if err != nil {
+		log.Warn("failed to verify second factor", sl.Err(err))
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.LoginResult{
+		AccessToken:   res.GetAccessToken(),
+		RefreshToken:  res.GetRefreshToken(),
+		RecoveryCodes: res.GetRecoveryCodes(),
+	}, nil
+}
+
+func (as *AuthStorage) UnlockAccount(ctx context.Context, uid uuid.UUID) error {
+	const op = "service.auth.unlockAccount"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	_, err = c.UnlockAccount(ctx, &authv1.UnlockAccountRequest{
+		UserId: uid.String(),
+	})
+	if err != nil {
+		log.Warn("failed to unlock account", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthStorage) CreatePersonalAccessToken(ctx context.Context, uid uuid.UUID, name string, scopes []string, expiresAt time.Time) (string, models.PersonalAccessToken, error) {
+	const op = "service.auth.createPersonalAccessToken"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	req := &authv1.CreatePersonalAccessTokenRequest{
+		UserId: uid.String(),
+		Name:   name,
+		Scopes: scopes,
+	}
+	if !expiresAt.IsZero() {
+		req.ExpiresAt = timestamppb.New(expiresAt)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.CreatePersonalAccessToken(ctx, req)
+	if err != nil {
+		log.Warn("failed to create personal access token", sl.Err(err))
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pat, err := authprofiles.ProtoPersonalAccessTokenToPersonalAccessToken(res.GetPersonalAccessToken())
+	if err != nil {
+		log.Error("failed to convert personal access token", sl.Err(err))
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.GetToken(), pat, nil
+}
+
+func (as *AuthStorage) ListPersonalAccessTokens(ctx context.Context, uid uuid.UUID) ([]models.PersonalAccessToken, error) {
+	const op = "service.auth.listPersonalAccessTokens"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.ListPersonalAccessTokens(ctx, &authv1.ListPersonalAccessTokensRequest{
+		UserId: uid.String(),
+	})
+	if err != nil {
+		log.Warn("failed to get personal access tokens", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pats := make([]models.PersonalAccessToken, 0, len(res.GetPersonalAccessTokens()))
+	for _, proto_pat := range res.GetPersonalAccessTokens() {
+		pat, err := authprofiles.ProtoPersonalAccessTokenToPersonalAccessToken(proto_pat)
+		if err != nil {
+			log.Warn("failed to convert personal access token", sl.Err(err))
+			continue
+		}
+		pats = append(pats, pat)
+	}
+
+	return pats, nil
+}
+
+func (as *AuthStorage) RevokePersonalAccessToken(ctx context.Context, uid uuid.UUID, id uuid.UUID) error {
+	const op = "service.auth.revokePersonalAccessToken"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	_, err = c.RevokePersonalAccessToken(ctx, &authv1.RevokePersonalAccessTokenRequest{
+		UserId: uid.String(),
+		Id:     id.String(),
+	})
+	if err != nil {
+		log.Warn("failed to revoke personal access token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthStorage) VerifyPersonalAccessToken(ctx context.Context, token string) (*models.Claims, error) {
+	const op = "service.auth.verifyPersonalAccessToken"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.VerifyPersonalAccessToken(ctx, &authv1.VerifyPersonalAccessTokenRequest{
+		Token: token,
+	})
+	if err != nil {
+		log.Warn("failed to verify personal access token", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &models.Claims{
+		Uid:         res.GetUserId(),
+		Roles:       res.GetRoles(),
+		Permissions: res.GetPermissions(),
+		Exp:         res.GetExpiresAt().AsTime(),
+		TokenId:     res.GetTokenId(),
+		Scopes:      res.GetScopes(),
+	}, nil
+}
+
+func (as *AuthStorage) Introspect(ctx context.Context, token string) (bool, error) {
+	const op = "service.auth.introspect"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return false, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.Introspect(ctx, &authv1.IntrospectRequest{
+		Token: token,
+	})
+	if err != nil {
+		log.Warn("failed to introspect token", sl.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.GetActive(), nil
+}
+
+func (as *AuthStorage) CreateApp(ctx context.Context, name string, redirectUris []string, scopes []string) (string, models.App, error) {
+	const op = "service.auth.createApp"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return "", models.App{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return "", models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.CreateApp(ctx, &authv1.CreateAppRequest{
+		Name:         name,
+		RedirectUris: redirectUris,
+		Scopes:       scopes,
+	})
+	if err != nil {
+		log.Warn("failed to create app", sl.Err(err))
+		return "", models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app, err := authprofiles.ProtoAppToApp(res.GetApp())
+	if err != nil {
+		log.Error("failed to convert app", sl.Err(err))
+		return "", models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.GetSecret(), app, nil
+}
+
+func (as *AuthStorage) GetApp(ctx context.Context, id uuid.UUID) (models.App, error) {
+	const op = "service.auth.getApp"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.App{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.GetApp(ctx, &authv1.GetAppRequest{
+		Id: id.String(),
+	})
+	if err != nil {
+		log.Warn("failed to get app", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app, err := authprofiles.ProtoAppToApp(res.GetApp())
+	if err != nil {
+		log.Error("failed to convert app", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return app, nil
+}
+
+func (as *AuthStorage) ListApps(ctx context.Context) ([]models.App, error) {
+	const op = "service.auth.listApps"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.ListApps(ctx, &authv1.ListAppsRequest{})
+	if err != nil {
+		log.Warn("failed to get apps", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	apps := make([]models.App, 0, len(res.GetApps()))
+	for _, proto_app := range res.GetApps() {
+		app, err := authprofiles.ProtoAppToApp(proto_app)
+		if err != nil {
+			log.Warn("failed to convert app", sl.Err(err))
+			continue
+		}
+		apps = append(apps, app)
+	}
+
+	return apps, nil
+}
+
+func (as *AuthStorage) RotateAppSecret(ctx context.Context, id uuid.UUID) (string, models.App, error) {
+	const op = "service.auth.rotateAppSecret"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return "", models.App{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return "", models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.RotateAppSecret(ctx, &authv1.RotateAppSecretRequest{
+		Id: id.String(),
+	})
+	if err != nil {
+		log.Warn("failed to rotate app secret", sl.Err(err))
+		return "", models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app, err := authprofiles.ProtoAppToApp(res.GetApp())
+	if err != nil {
+		log.Error("failed to convert app", sl.Err(err))
+		return "", models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.GetSecret(), app, nil
+}
+
+func (as *AuthStorage) RevokeApp(ctx context.Context, id uuid.UUID) error {
+	const op = "service.auth.revokeApp"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	_, err = c.RevokeApp(ctx, &authv1.RevokeAppRequest{
+		Id: id.String(),
+	})
+	if err != nil {
+		log.Warn("failed to revoke app", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (as *AuthStorage) ListRoles(ctx context.Context) ([]models.Role, error) {
+	const op = "service.auth.listRoles"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := as.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := authv1.NewAuthClient(conn)
+	res, err := c.ListRoles(ctx, &authv1.ListRolesRequest{})
+	if err != nil {
+		log.Warn("failed to get roles", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roles := make([]models.Role, 0, len(res.GetRoles()))
+	for _, proto_role := range res.GetRoles() {
+		roles = append(roles, authprofiles.ProtoRoleToRole(proto_role))
+	}
+
+	return roles, nil
+}
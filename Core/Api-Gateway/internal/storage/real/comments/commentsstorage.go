@@ -9,27 +9,33 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/chas3air/shared/grpcconn"
 	"github.com/google/uuid"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
 	cmv1 "github.com/chas3air/protos/gen/go/commentsManager"
 )
 
 type CommentsManageStorage struct {
-	log         *slog.Logger
-	ServiceHost string
-	ServicePost int
+	log  *slog.Logger
+	conn *grpcconn.Conn
 }
 
-func New(log *slog.Logger, serviceHost string, servicePort int) *CommentsManageStorage {
+// New builds the storage on conn, which is shared by all its calls.
+func New(log *slog.Logger, conn *grpcconn.Conn) *CommentsManageStorage {
 	return &CommentsManageStorage{
-		log:         log,
-		ServiceHost: serviceHost,
-		ServicePost: servicePort,
+		log:  log,
+		conn: conn,
 	}
 }
 
+// ReadMethods are the calls of the storage that only read and so may be
+// retried.
+var ReadMethods = []string{
+	cmv1.CommentsManager_GetCommentsByArticleId_FullMethodName,
+	cmv1.CommentsManager_GetCommentById_FullMethodName,
+	cmv1.CommentsManager_GetCommentsByOwnerId_FullMethodName,
+}
+
 func (cms *CommentsManageStorage) GetCommentById(ctx context.Context, cid uuid.UUID) (models.Comment, error) {
 	const op = "commentsManageStorage.getCommentById"
 	log := cms.log.With(
@@ -42,15 +48,11 @@ func (cms *CommentsManageStorage) GetCommentById(ctx context.Context, cid uuid.U
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", cms.ServiceHost, cms.ServicePost),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := cms.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return models.Comment{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := cmv1.NewCommentsManagerClient(conn)
 	res, err := c.GetCommentById(ctx, &cmv1.GetCommentByIdRequest{
@@ -87,15 +89,11 @@ func (cms *CommentsManageStorage) GetCommentsByArticleId(ctx context.Context, ai
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", cms.ServiceHost, cms.ServicePost),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := cms.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := cmv1.NewCommentsManagerClient(conn)
 	res, err := c.GetCommentsByArticleId(ctx, &cmv1.GetCommentsByArticleIdRequest{
@@ -125,6 +123,52 @@ func (cms *CommentsManageStorage) GetCommentsByArticleId(ctx context.Context, ai
 	return resComment, nil
 }
 
+func (cms *CommentsManageStorage) GetCommentsByOwnerId(ctx context.Context, uid uuid.UUID) ([]models.Comment, error) {
+	const op = "commentsManageStorage.getCommentsByOwnerId"
+	log := cms.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := cms.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := cmv1.NewCommentsManagerClient(conn)
+	res, err := c.GetCommentsByOwnerId(ctx, &cmv1.GetCommentsByOwnerIdRequest{
+		OwnerId: uid.String(),
+	})
+	if err != nil {
+		log.Error("failed to get comments by owner_id", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if res.GetComments() == nil {
+		log.Warn("No one comment")
+		return nil, nil
+	}
+
+	var resComment = make([]models.Comment, 0, 10)
+	for _, pbComment := range res.GetComments() {
+		comment, err := cmprofiles.ProtoComToCom(pbComment)
+		if err != nil {
+			log.Warn("Wrong structure", sl.Err(err))
+			continue
+		}
+
+		resComment = append(resComment, comment)
+	}
+
+	return resComment, nil
+}
+
 func (cms *CommentsManageStorage) Insert(ctx context.Context, comment models.Comment) (models.Comment, error) {
 	const op = "commentsManageStorage.insert"
 	log := cms.log.With(
@@ -137,15 +181,11 @@ func (cms *CommentsManageStorage) Insert(ctx context.Context, comment models.Com
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", cms.ServiceHost, cms.ServicePost),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := cms.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return models.Comment{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	commentForInsert, err := cmprofiles.ComToProtoCom(comment)
 	if err != nil {
@@ -177,15 +217,11 @@ func (cms *CommentsManageStorage) Delete(ctx context.Context, cid uuid.UUID) (mo
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", cms.ServiceHost, cms.ServicePost),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := cms.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return models.Comment{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := cmv1.NewCommentsManagerClient(conn)
 	res, err := c.Delete(ctx, &cmv1.DeleteRequest{
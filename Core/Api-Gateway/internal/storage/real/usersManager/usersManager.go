@@ -9,25 +9,31 @@ import (
 	"log/slog"
 
 	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"github.com/chas3air/shared/grpcconn"
 	"github.com/google/uuid"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type UsersManageService struct {
-	log         *slog.Logger
-	ServiceHost string
-	ServicePort int
+	log  *slog.Logger
+	conn *grpcconn.Conn
 }
 
-func New(log *slog.Logger, serviceHost string, servicePort int) *UsersManageService {
+// New builds the storage on conn, which is shared by all its calls.
+func New(log *slog.Logger, conn *grpcconn.Conn) *UsersManageService {
 	return &UsersManageService{
-		log:         log,
-		ServiceHost: serviceHost,
-		ServicePort: servicePort,
+		log:  log,
+		conn: conn,
 	}
 }
 
+// ReadMethods are the calls of the storage that only read and so may be
+// retried.
+var ReadMethods = []string{
+	umv1.UsersManager_GetUsers_FullMethodName,
+	umv1.UsersManager_GetUserById_FullMethodName,
+	umv1.UsersManager_GetUserByEmail_FullMethodName,
+}
+
 // GetUsers implements interfaces.UsersStorage.
 func (u *UsersManageService) GetUsers(ctx context.Context) ([]models.User, error) {
 	const op = "usersmanageservice.getUsers"
@@ -39,15 +45,11 @@ func (u *UsersManageService) GetUsers(ctx context.Context) ([]models.User, error
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", u.ServiceHost, u.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := u.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := umv1.NewUsersManagerClient(conn)
 	res, err := c.GetUsers(ctx, nil)
@@ -80,15 +82,11 @@ func (u *UsersManageService) GetUserById(ctx context.Context, uid uuid.UUID) (mo
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", u.ServiceHost, u.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := u.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := umv1.NewUsersManagerClient(conn)
 	res, err := c.GetUserById(ctx, &umv1.GetUserByIdRequest{Id: uid.String()})
@@ -117,15 +115,11 @@ func (u *UsersManageService) GetUserByEmail(ctx context.Context, email string) (
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", u.ServiceHost, u.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := u.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := umv1.NewUsersManagerClient(conn)
 	res, err := c.GetUserByEmail(ctx, &umv1.GetUserByEmailRequest{Email: email})
@@ -160,15 +154,11 @@ func (u *UsersManageService) Insert(ctx context.Context, user models.User) (mode
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", u.ServiceHost, u.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := u.conn.Get()
 	if err != nil {
 		log.Error("Failed to connect to gRPC server", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := umv1.NewUsersManagerClient(conn)
 	_, err = c.Insert(ctx, &umv1.InsertRequest{
@@ -193,15 +183,11 @@ func (u *UsersManageService) Update(ctx context.Context, uid uuid.UUID, user mod
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", u.ServiceHost, u.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := u.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := umv1.NewUsersManagerClient(conn)
 	userForUpdate, err := umprofiles.UsrToProtoUsr(user)
@@ -234,15 +220,11 @@ func (u *UsersManageService) Delete(ctx context.Context, uid uuid.UUID) (models.
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", u.ServiceHost, u.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := u.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := umv1.NewUsersManagerClient(conn)
 	res, err := c.Delete(ctx, &umv1.DeleteRequest{
@@ -5,6 +5,8 @@ import (
 	"os"
 	"time"
 
+	"github.com/chas3air/shared/mtls"
+	"github.com/chas3air/shared/resilience"
 	"github.com/ilyakaznacheev/cleanenv"
 	"gopkg.in/yaml.v3"
 )
@@ -24,12 +26,90 @@ type Config struct {
 	CommentsStorageHost string `yaml:"commentsStorageHost" env-defaul:"comments_service"`
 	CommentsStoragePort int    `yaml:"commentsStoragePort" env-defaul:"50051"`
 
-	API APIConfig `yaml:"api"`
+	API        APIConfig        `yaml:"api"`
+	Jwt        JwtConfig        `yaml:"jwt"`
+	Apps       AppsConfig       `yaml:"apps"`
+	TLS        TLSConfig        `yaml:"tls"`
+	GrpcClient GrpcClientConfig `yaml:"grpc_client"`
+	Resilience ResilienceConfig `yaml:"resilience"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit"`
 }
 
+// JwtConfig controls the check of access tokens. Their signature is checked
+// locally; whether their session is still active is asked of Auth at most
+// once per SessionCacheTTL, so a logged out session is refused within it.
+type JwtConfig struct {
+	Issuer              string        `yaml:"issuer" env-default:"auth"`
+	Audience            string        `yaml:"audience" env-default:"redhub"`
+	JwksRefreshInterval time.Duration `yaml:"jwks_refresh_interval" env-default:"5m"`
+	SessionCacheTTL     time.Duration `yaml:"session_cache_ttl" env-default:"15s"`
+}
+
+// AppsConfig controls the check of registered client applications. With
+// Required set, every /api/v1 request must name its app in X-App-Id.
+type AppsConfig struct {
+	Required bool          `yaml:"required" env-default:"false"`
+	CacheTTL time.Duration `yaml:"cache_ttl" env-default:"1m"`
+}
+
+// TLSConfig secures the gateway's connections to the gRPC services. With
+// Enabled unset they are plain text. CA verifies the services, and Cert and
+// Key are presented to them for mutual TLS.
+type TLSConfig = mtls.Config
+
+// GrpcClientConfig tunes the connections kept open to the gRPC services.
+// KeepaliveTime must not be below the 10s the services accept.
+type GrpcClientConfig struct {
+	KeepaliveTime    time.Duration `yaml:"keepalive_time" env-default:"30s"`
+	KeepaliveTimeout time.Duration `yaml:"keepalive_timeout" env-default:"10s"`
+}
+
+// ResilienceConfig holds the policy of each downstream service.
+type ResilienceConfig struct {
+	Users    ResiliencePolicy `yaml:"users"`
+	Articles ResiliencePolicy `yaml:"articles"`
+	Comments ResiliencePolicy `yaml:"comments"`
+	Auth     ResiliencePolicy `yaml:"auth"`
+}
+
+// ResiliencePolicy is the retry and circuit breaker policy of one
+// downstream service, described at resilience.Policy.
+type ResiliencePolicy = resilience.Policy
+
+// RateLimitConfig sets the request budget of each route group: "default"
+// for every request, counted per client IP, "auth" for logins,
+// registrations and other anonymous auth calls, "write" for creating and
+// changing articles and comments, counted per user. A group left out is
+// not limited. TrustedProxies lists the networks, in CIDR form, whose
+// X-Forwarded-For header is believed, for rate limits and for the client
+// addresses logins are throttled by; the bundled Nginx must be among them.
+type RateLimitConfig struct {
+	Enabled        bool                      `yaml:"enabled" env-default:"false"`
+	TrustedProxies []string                  `yaml:"trusted_proxies"`
+	Groups         map[string]RateLimitGroup `yaml:"groups"`
+}
+
+// RateLimitGroup lets a user, or an anonymous client IP, make Burst
+// requests at once and Requests more every Per. Burst defaults to Requests.
+type RateLimitGroup struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst"`
+}
+
+// APIConfig configures the HTTP server. Timeout is the deadline of every
+// request, passed on to the gRPC calls made for it; RouteTimeouts overrides
+// it for single routes, keyed by path template such as
+// "/api/v1/stats/articles". WriteTimeout must leave room for the longest of
+// them.
 type APIConfig struct {
-	Port    int           `yaml:"port" env-default:"50051"`
-	Timeout time.Duration `yaml:"timeout" env-default:"5s"`
+	Port              int                      `yaml:"port" env-default:"50051"`
+	Timeout           time.Duration            `yaml:"timeout" env-default:"5s"`
+	RouteTimeouts     map[string]time.Duration `yaml:"route_timeouts"`
+	ReadHeaderTimeout time.Duration            `yaml:"read_header_timeout" env-default:"5s"`
+	ReadTimeout       time.Duration            `yaml:"read_timeout" env-default:"10s"`
+	WriteTimeout      time.Duration            `yaml:"write_timeout" env-default:"30s"`
+	IdleTimeout       time.Duration            `yaml:"idle_timeout" env-default:"2m"`
 }
 
 func MustLoad() *Config {
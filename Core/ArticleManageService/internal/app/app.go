@@ -4,18 +4,38 @@ import (
 	grpcapp "articlesManageService/internal/app/grpc"
 	"articlesManageService/internal/domain/interfaces/storage"
 	articlemanager "articlesManageService/internal/services/articleManager"
+	"articlesManageService/pkg/lib/logger/sl"
 	"log/slog"
+	"time"
+
+	"github.com/chas3air/shared/authclient"
+	"google.golang.org/grpc/credentials"
 )
 
 type App struct {
+	log        *slog.Logger
 	GRPCServer *grpcapp.App
+	authClient *authclient.Client
 }
 
-func New(log *slog.Logger, port int, storage storage.Storage) *App {
+func New(log *slog.Logger, port int, timeout time.Duration, storage storage.Storage, authClient *authclient.Client, creds credentials.TransportCredentials, trustedClients []string) *App {
 	articleManager := articlemanager.New(log, storage)
 
-	grpcapp := grpcapp.New(log, articleManager, port)
+	grpcapp := grpcapp.New(log, articleManager, port, timeout, authClient, creds, trustedClients)
 	return &App{
+		log:        log,
 		GRPCServer: grpcapp,
+		authClient: authClient,
+	}
+}
+
+// Stop stops the gRPC server, then closes the connection to Auth it used.
+func (a *App) Stop() {
+	a.GRPCServer.Stop()
+
+	if a.authClient != nil {
+		if err := a.authClient.Close(); err != nil {
+			a.log.Error("Failed to close connection to Auth", sl.Err(err))
+		}
 	}
 }
@@ -10,9 +10,9 @@ import (
 type Article struct {
 	Id        uuid.UUID `json:"id,omitempty"`
 	CreatedAt time.Time `json:"created_at,omitempty"`
-	Title     string    `json:"title,omitempty"`
-	Content   string    `json:"content,omitempty"`
-	Tag       string    `json:"tag,omitempty"`
+	Title     string    `json:"title,omitempty" validate:"required,max=255"`
+	Content   string    `json:"content,omitempty" validate:"required"`
+	Tag       string    `json:"tag,omitempty" validate:"required,max=50"`
 	OwnerId   uuid.UUID `json:"owner_id,omitempty"`
 }
 
@@ -11,6 +11,7 @@ import (
 	"log/slog"
 
 	amv1 "github.com/chas3air/protos/gen/go/articlesManager"
+	"github.com/chas3air/shared/validation"
 	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -175,19 +176,39 @@ func (s *serverAPI) InsertArticle(ctx context.Context, req *amv1.InsertArticleRe
 		return nil, status.Error(codes.InvalidArgument, "failed to customize")
 	}
 
-	_, err = s.articlesManager.Insert(ctx, app_article)
+	if err := validation.Struct(app_article, "invalid article"); err != nil {
+		log.Warn("Invalid article", sl.Err(err))
+		return nil, err
+	}
+
+	inserted_article, err := s.articlesManager.Insert(ctx, app_article)
 	if err != nil {
 		if errors.Is(err, services.ErrAlreadyExists) {
 			log.Warn("Article already exists", sl.Err(err))
 			return nil, status.Error(codes.AlreadyExists, "article already exists")
 		}
+		if errors.Is(err, services.ErrUntrustedCaller) {
+			return nil, status.Error(codes.PermissionDenied, "caller is named by an untrusted client")
+		}
+		if errors.Is(err, services.ErrUnauthenticated) {
+			return nil, status.Error(codes.Unauthenticated, "caller is not authenticated")
+		}
+		if errors.Is(err, services.ErrPermissionDenied) {
+			return nil, status.Error(codes.PermissionDenied, "owner_id does not match the caller")
+		}
 
 		log.Error("Failed to insert article", sl.Err(err))
 		return nil, status.Error(codes.Internal, "failed to insert article")
 	}
 
+	resp_article, err := profiles.ArtToProtoArt(inserted_article)
+	if err != nil {
+		log.Error("Wrong structure, failed to customize", sl.Err(err))
+		return nil, status.Error(codes.Internal, "failed to customize")
+	}
+
 	return &amv1.InsertArticleResponse{
-		Article: req.GetArticle(),
+		Article: resp_article,
 	}, nil
 }
 
@@ -221,7 +242,11 @@ func (s *serverAPI) UpdateArticle(ctx context.Context, req *amv1.UpdateArticleRe
 		return nil, status.Error(codes.InvalidArgument, "article is required")
 	}
 
-	app_article := models.Article{Title: req.Article.Title, Content: req.Article.Content}
+	app_article := models.Article{Title: req.Article.Title, Content: req.Article.Content, Tag: req.Article.Tag}
+	if err := validation.Partial(app_article, "invalid article", "Title", "Content", "Tag"); err != nil {
+		log.Warn("Invalid article", sl.Err(err))
+		return nil, err
+	}
 
 	_, err = s.articlesManager.Update(ctx, parseUUID, app_article)
 	if err != nil {
@@ -229,6 +254,15 @@ func (s *serverAPI) UpdateArticle(ctx context.Context, req *amv1.UpdateArticleRe
 			log.Warn("Article with current id not found", sl.Err(err))
 			return nil, status.Error(codes.NotFound, "Article with current id not found")
 		}
+		if errors.Is(err, services.ErrUntrustedCaller) {
+			return nil, status.Error(codes.PermissionDenied, "caller is named by an untrusted client")
+		}
+		if errors.Is(err, services.ErrUnauthenticated) {
+			return nil, status.Error(codes.Unauthenticated, "caller is not authenticated")
+		}
+		if errors.Is(err, services.ErrPermissionDenied) {
+			return nil, status.Error(codes.PermissionDenied, "only the owner or a moderator may update the article")
+		}
 
 		log.Error("Failed to update article", sl.Err(err))
 		return nil, status.Error(codes.Internal, "failed to update article")
@@ -269,6 +303,15 @@ func (s *serverAPI) DeleteArticle(ctx context.Context, req *amv1.DeleteArticleRe
 			log.Warn("Article with current id not found", sl.Err(err))
 			return nil, status.Error(codes.NotFound, "Article with current id not found")
 		}
+		if errors.Is(err, services.ErrUntrustedCaller) {
+			return nil, status.Error(codes.PermissionDenied, "caller is named by an untrusted client")
+		}
+		if errors.Is(err, services.ErrUnauthenticated) {
+			return nil, status.Error(codes.Unauthenticated, "caller is not authenticated")
+		}
+		if errors.Is(err, services.ErrPermissionDenied) {
+			return nil, status.Error(codes.PermissionDenied, "only the owner or a moderator may delete the article")
+		}
 
 		log.Error("Failed to delete article", sl.Err(err))
 		return nil, status.Error(codes.Internal, "failed to delete article")
@@ -0,0 +1,60 @@
+package articlesmanager
+
+import (
+	"articlesManageService/internal/domain/interfaces/articlesservice"
+	"articlesManageService/internal/domain/models"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	amv1 "github.com/chas3air/protos/gen/go/articlesManager"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// updateManager remembers the article it was asked to store.
+type updateManager struct {
+	articlesservice.ArticlesManager
+	updated *models.Article
+}
+
+func (m *updateManager) Update(ctx context.Context, aid uuid.UUID, article models.Article) (models.Article, error) {
+	m.updated = &article
+	return article, nil
+}
+
+func TestUpdateArticle(t *testing.T) {
+	tests := []struct {
+		name     string
+		article  *amv1.Article
+		wantCode codes.Code
+	}{
+		{name: "title, content and tag", article: &amv1.Article{Title: "Title", Content: "Content", Tag: "go"}, wantCode: codes.OK},
+		{name: "without tag", article: &amv1.Article{Title: "Title", Content: "Content"}, wantCode: codes.InvalidArgument},
+		{name: "without title", article: &amv1.Article{Content: "Content", Tag: "go"}, wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := &updateManager{}
+			s := &serverAPI{articlesManager: manager, log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+			_, err := s.UpdateArticle(context.Background(), &amv1.UpdateArticleRequest{Id: uuid.NewString(), Article: tt.article})
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("code %v, want %v", got, tt.wantCode)
+			}
+
+			if tt.wantCode != codes.OK {
+				if manager.updated != nil {
+					t.Error("invalid article was stored")
+				}
+				return
+			}
+			if manager.updated == nil || manager.updated.Tag != tt.article.Tag {
+				t.Errorf("stored %+v, want tag %q", manager.updated, tt.article.Tag)
+			}
+		})
+	}
+}
@@ -12,9 +12,13 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/chas3air/shared/caller"
 	"github.com/google/uuid"
 )
 
+// moderatePermission lets a caller edit and delete articles of other users.
+const moderatePermission = "articles.moderate"
+
 type ArticleManager struct {
 	log     *slog.Logger
 	storage storage.Storage
@@ -106,7 +110,21 @@ func (am *ArticleManager) Insert(ctx context.Context, article models.Article) (m
 	default:
 	}
 
-	article, err := am.storage.Insert(ctx, article)
+	who, err := caller.FromContext(ctx)
+	if err != nil {
+		log.Warn("Caller is not known", sl.Err(err))
+		return models.Article{}, fmt.Errorf("%s: %w", op, callerError(err))
+	}
+
+	// The author is whoever is authenticated; an owner given by the client
+	// must agree with that.
+	if article.OwnerId != uuid.Nil && article.OwnerId != who.Id {
+		log.Warn("Owner does not match caller", slog.String("caller", who.Id.String()))
+		return models.Article{}, fmt.Errorf("%s: %w", op, services.ErrPermissionDenied)
+	}
+	article.OwnerId = who.Id
+
+	article, err = am.storage.Insert(ctx, article)
 	if err != nil {
 		if errors.Is(err, storage_error.ErrAlreadyExists) {
 			log.Error("Article already exists", sl.Err(err))
@@ -132,6 +150,10 @@ func (am *ArticleManager) Update(ctx context.Context, aid uuid.UUID, article mod
 	default:
 	}
 
+	if err := am.authorize(ctx, aid, log); err != nil {
+		return models.Article{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	article, err := am.storage.Update(ctx, aid, article)
 	if err != nil {
 		if errors.Is(err, storage_error.ErrNotFound) {
@@ -157,6 +179,10 @@ func (am *ArticleManager) Delete(ctx context.Context, aid uuid.UUID) (models.Art
 	default:
 	}
 
+	if err := am.authorize(ctx, aid, log); err != nil {
+		return models.Article{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	deletedArticle, err := am.storage.Delete(ctx, aid)
 	if err != nil {
 		if errors.Is(err, storage_error.ErrNotFound) {
@@ -171,3 +197,40 @@ func (am *ArticleManager) Delete(ctx context.Context, aid uuid.UUID) (models.Art
 	log.Info("Successfully deleted article")
 	return deletedArticle, nil
 }
+
+// authorize allows changing the article to its owner and to callers who may
+// moderate articles.
+func (am *ArticleManager) authorize(ctx context.Context, aid uuid.UUID, log *slog.Logger) error {
+	who, err := caller.FromContext(ctx)
+	if err != nil {
+		log.Warn("Caller is not known", sl.Err(err))
+		return callerError(err)
+	}
+
+	article, err := am.storage.GetArticleById(ctx, aid)
+	if err != nil {
+		if errors.Is(err, storage_error.ErrNotFound) {
+			log.Error("Article not found", sl.Err(err))
+			return services.ErrNotFound
+		}
+
+		log.Error("Error retrieving article by id", sl.Err(err))
+		return err
+	}
+
+	if article.OwnerId != who.Id && !who.Can(moderatePermission) {
+		log.Warn("Caller may not change article", slog.String("caller", who.Id.String()))
+		return services.ErrPermissionDenied
+	}
+
+	return nil
+}
+
+// callerError tells a caller named by an untrusted client from one that is
+// not known at all.
+func callerError(err error) error {
+	if errors.Is(err, caller.ErrUntrusted) {
+		return services.ErrUntrustedCaller
+	}
+	return services.ErrUnauthenticated
+}
@@ -0,0 +1,199 @@
+package articlemanager
+
+import (
+	"articlesManageService/internal/domain/models"
+	"articlesManageService/internal/services"
+	storage_error "articlesManageService/internal/storage"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/chas3air/shared/caller"
+	"github.com/chas3air/shared/mtls"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// memStorage keeps articles in a map.
+type memStorage struct {
+	articles map[uuid.UUID]models.Article
+}
+
+func (s *memStorage) GetArticles(ctx context.Context) ([]models.Article, error) {
+	articles := make([]models.Article, 0, len(s.articles))
+	for _, article := range s.articles {
+		articles = append(articles, article)
+	}
+	return articles, nil
+}
+
+func (s *memStorage) GetArticleById(ctx context.Context, aid uuid.UUID) (models.Article, error) {
+	article, ok := s.articles[aid]
+	if !ok {
+		return models.Article{}, storage_error.ErrNotFound
+	}
+	return article, nil
+}
+
+func (s *memStorage) GetArticlesByOwnerId(ctx context.Context, uid uuid.UUID) ([]models.Article, error) {
+	var articles []models.Article
+	for _, article := range s.articles {
+		if article.OwnerId == uid {
+			articles = append(articles, article)
+		}
+	}
+	return articles, nil
+}
+
+func (s *memStorage) Insert(ctx context.Context, article models.Article) (models.Article, error) {
+	s.articles[article.Id] = article
+	return article, nil
+}
+
+func (s *memStorage) Update(ctx context.Context, aid uuid.UUID, article models.Article) (models.Article, error) {
+	existing, ok := s.articles[aid]
+	if !ok {
+		return models.Article{}, storage_error.ErrNotFound
+	}
+	existing.Title = article.Title
+	existing.Content = article.Content
+	existing.Tag = article.Tag
+	s.articles[aid] = existing
+	return existing, nil
+}
+
+func (s *memStorage) Delete(ctx context.Context, aid uuid.UUID) (models.Article, error) {
+	article, ok := s.articles[aid]
+	if !ok {
+		return models.Article{}, storage_error.ErrNotFound
+	}
+	delete(s.articles, aid)
+	return article, nil
+}
+
+// as returns a context carrying the metadata the gateway sends for a caller.
+func as(id uuid.UUID, permissions ...string) context.Context {
+	md := metadata.Pairs(caller.IdKey, id.String())
+	for _, permission := range permissions {
+		md.Append(caller.PermissionsKey, permission)
+	}
+	return fromGateway(metadata.NewIncomingContext(context.Background(), md))
+}
+
+// fromGateway marks ctx as a call the gateway made over mutual TLS.
+func fromGateway(ctx context.Context) context.Context {
+	ctx = peer.NewContext(ctx, &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "api-gateway"}}}},
+		}},
+	})
+
+	interceptor := mtls.UnaryServerInterceptor([]string{"api-gateway"})
+	_, _ = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(marked context.Context, req any) (any, error) {
+		ctx = marked
+		return nil, nil
+	})
+	return ctx
+}
+
+func TestChangeArticle(t *testing.T) {
+	owner := uuid.New()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		missing bool
+		wantErr error
+	}{
+		{name: "owner", ctx: as(owner)},
+		{name: "moderator", ctx: as(uuid.New(), moderatePermission)},
+		{name: "other user", ctx: as(uuid.New(), "articles.create"), wantErr: services.ErrPermissionDenied},
+		{name: "no caller", ctx: context.Background(), wantErr: services.ErrUnauthenticated},
+		{name: "malformed caller", ctx: fromGateway(metadata.NewIncomingContext(context.Background(), metadata.Pairs(caller.IdKey, "nobody"))), wantErr: services.ErrUnauthenticated},
+		{name: "caller named without tls", ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(caller.IdKey, uuid.NewString())), wantErr: services.ErrUntrustedCaller},
+		{name: "missing article", ctx: as(owner), missing: true, wantErr: services.ErrNotFound},
+	}
+
+	changes := map[string]func(am *ArticleManager, ctx context.Context, aid uuid.UUID) error{
+		"update": func(am *ArticleManager, ctx context.Context, aid uuid.UUID) error {
+			_, err := am.Update(ctx, aid, models.Article{Title: "new title", Content: "new content"})
+			return err
+		},
+		"delete": func(am *ArticleManager, ctx context.Context, aid uuid.UUID) error {
+			_, err := am.Delete(ctx, aid)
+			return err
+		},
+	}
+
+	for change, do := range changes {
+		for _, tt := range tests {
+			t.Run(change+"/"+tt.name, func(t *testing.T) {
+				article := models.Article{Id: uuid.New(), Title: "title", Content: "content", OwnerId: owner}
+				storage := &memStorage{articles: map[uuid.UUID]models.Article{article.Id: article}}
+				am := New(slog.New(slog.NewTextHandler(io.Discard, nil)), storage)
+
+				aid := article.Id
+				if tt.missing {
+					aid = uuid.New()
+				}
+
+				err := do(am, tt.ctx, aid)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error %v, want %v", err, tt.wantErr)
+				}
+
+				_, kept := storage.articles[article.Id]
+				if changed := storage.articles[article.Id] != article; tt.wantErr != nil && (!kept || changed) {
+					t.Error("article changed although the request was refused")
+				}
+			})
+		}
+	}
+}
+
+func TestInsert(t *testing.T) {
+	author := uuid.New()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		ownerId   uuid.UUID
+		wantOwner uuid.UUID
+		wantErr   error
+	}{
+		{name: "owner taken from caller", ctx: as(author), wantOwner: author},
+		{name: "owner matches caller", ctx: as(author), ownerId: author, wantOwner: author},
+		{name: "owner of another user", ctx: as(author), ownerId: uuid.New(), wantErr: services.ErrPermissionDenied},
+		{name: "no caller", ctx: context.Background(), wantErr: services.ErrUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memStorage{articles: map[uuid.UUID]models.Article{}}
+			am := New(slog.New(slog.NewTextHandler(io.Discard, nil)), storage)
+
+			article, err := am.Insert(tt.ctx, models.Article{Id: uuid.New(), Title: "title", Content: "content", OwnerId: tt.ownerId})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(storage.articles) != 0 {
+					t.Error("article stored although the request was refused")
+				}
+				return
+			}
+
+			if article.OwnerId != tt.wantOwner || storage.articles[article.Id].OwnerId != tt.wantOwner {
+				t.Errorf("owner %s, want %s", article.OwnerId, tt.wantOwner)
+			}
+		})
+	}
+}
@@ -3,6 +3,9 @@ package services
 import "errors"
 
 var (
-	ErrNotFound      = errors.New("resource not found")
-	ErrAlreadyExists = errors.New("resource already exists")
+	ErrNotFound         = errors.New("resource not found")
+	ErrAlreadyExists    = errors.New("resource already exists")
+	ErrUnauthenticated  = errors.New("caller is not authenticated")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrUntrustedCaller  = errors.New("caller is named by an untrusted client")
 )
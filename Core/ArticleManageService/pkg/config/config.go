@@ -5,18 +5,37 @@ import (
 	"os"
 	"time"
 
+	"github.com/chas3air/shared/mtls"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
 	Env            string        `yaml:"env" env-default:"local"`
 	Grpc           GrpcConfig    `yaml:"grpc"`
+	TLS            TLSConfig     `yaml:"tls"`
+	Auth           AuthConfig    `yaml:"auth"`
 	ExpirationTime time.Duration `yaml:"expiration_time"`
 }
 
+// AuthConfig points at Auth, which checks the bearer tokens of incoming
+// calls. With Host empty tokens are not checked and the caller named by the
+// gateway in the metadata is trusted, but only if the gateway is one of the
+// trusted clients of the TLS config. The connection to Auth is kept open
+// and pinged every KeepaliveTime, which must not be below the 10s Auth
+// accepts.
+type AuthConfig struct {
+	Host             string        `yaml:"host"`
+	Port             int           `yaml:"port" env-default:"50051"`
+	KeepaliveTime    time.Duration `yaml:"keepalive_time" env-default:"30s"`
+	KeepaliveTimeout time.Duration `yaml:"keepalive_timeout" env-default:"10s"`
+}
+
+// TLSConfig secures the gRPC connections of the service, see mtls.Config.
+type TLSConfig = mtls.Config
+
 type GrpcConfig struct {
 	Port    int           `yaml:"port"`
-	Timeout time.Duration `yaml:"timeout"`
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
 }
 
 func MustLoad() *Config {
@@ -4,6 +4,8 @@ import (
 	"auth/internal/app"
 	"auth/pkg/config"
 	"auth/pkg/lib/logger"
+	"auth/pkg/lib/logger/sl"
+	"context"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -35,11 +37,18 @@ func main() {
 		application.GRPCSrv.MustRun()
 	}()
 
+	ctx, cancel := context.WithCancel(context.Background())
+	go application.KeySet.RunRotation(ctx)
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
 
 	<-stop
 
+	cancel()
 	application.GRPCSrv.Stop()
+	if err := application.UsersConn.Close(); err != nil {
+		log.Error("Failed to close connection to UsersManageService", sl.Err(err))
+	}
 	log.Info("Gracefully stopped")
 }
@@ -0,0 +1,193 @@
+// Command devcerts creates a local certificate authority and a certificate
+// for every service, for running the services with mutual TLS on a
+// developer machine. Run it from Core/Auth:
+//
+//	go run ./cmd/devcerts --root=..
+//
+// The CA is kept in <root>/certs and reused on later runs; each service gets
+// ca.crt, service.crt and service.key in its own certs directory.
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// services maps each service directory to the identity its certificate is
+// issued to, which is also its host name in docker-compose.
+var services = map[string]string{
+	"Api-Gateway":           "api-gateway",
+	"Auth":                  "auth",
+	"UsersManageService":    "user_service",
+	"ArticleManageService":  "article_service",
+	"CommentsManageService": "comment_service",
+}
+
+const (
+	caValidity   = 10 * 365 * 24 * time.Hour
+	certValidity = 825 * 24 * time.Hour
+)
+
+func main() {
+	var root string
+	flag.StringVar(&root, "root", "..", "path to the Core directory")
+	flag.Parse()
+
+	caCert, caKey, err := loadOrCreateCA(filepath.Join(root, "certs"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "devcerts:", err)
+		os.Exit(1)
+	}
+
+	for dir, identity := range services {
+		if err := issue(filepath.Join(root, dir, "certs"), identity, caCert, caKey); err != nil {
+			fmt.Fprintln(os.Stderr, "devcerts:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("issued %s for %s\n", identity, dir)
+	}
+}
+
+func loadOrCreateCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPath := filepath.Join(dir, "ca.crt")
+	keyPath := filepath.Join(dir, "ca.key")
+
+	certPEM, certErr := os.ReadFile(certPath)
+	keyPEM, keyErr := os.ReadFile(keyPath)
+	if certErr == nil && keyErr == nil {
+		// Keys written by earlier versions were readable by everyone.
+		if err := os.Chmod(keyPath, 0o600); err != nil {
+			return nil, nil, err
+		}
+		return parsePair(certPEM, keyPEM)
+	}
+	if !errors.Is(certErr, os.ErrNotExist) && certErr != nil {
+		return nil, nil, certErr
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template, err := newTemplate("Redhub dev CA", caValidity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := write(dir, "ca", der, key); err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+func issue(dir string, identity string, caCert *x509.Certificate, caKey *ecdsa.PrivateKey) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	template, err := newTemplate(identity, certValidity)
+	if err != nil {
+		return err
+	}
+	template.DNSNames = []string{identity, "localhost"}
+	template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1)}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+
+	if err := write(dir, "service", der, key); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, "ca.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}), 0o644)
+}
+
+func newTemplate(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"Redhub"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(validity),
+	}, nil
+}
+
+// write stores the certificate as <name>.crt and its key as <name>.key.
+func write(dir string, name string, der []byte, key *ecdsa.PrivateKey) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		return err
+	}
+	// Only the owner may read the key; the Dockerfiles hand the certs
+	// directory to the user the service runs as.
+	return writePrivate(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+// writePrivate writes data with mode 0600, also tightening a file left by
+// an earlier run with wider permissions.
+func writePrivate(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0o600)
+}
+
+func parsePair(certPEM []byte, keyPEM []byte) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, errors.New("ca.crt or ca.key is not PEM")
+	}
+
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, key, nil
+}
@@ -2,23 +2,93 @@ package app
 
 import (
 	grpcapp "auth/internal/app/grpc"
+	"auth/internal/domain/interfaces"
+	"auth/internal/lib/jwt"
+	"auth/internal/lib/keyset"
+	filemailer "auth/internal/mailer/file"
+	smtpmailer "auth/internal/mailer/smtp"
 	authservice "auth/internal/services/auth"
+	psqlstorage "auth/internal/storage/real/psql"
 	"auth/internal/storage/real/usersmanageservice"
 	"auth/pkg/config"
+	"fmt"
 	"log/slog"
+	"os"
+
+	"github.com/chas3air/shared/grpcconn"
+	"github.com/chas3air/shared/mtls"
+	"github.com/chas3air/shared/resilience"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 )
 
 type App struct {
-	GRPCSrv *grpcapp.App
+	GRPCSrv   *grpcapp.App
+	KeySet    *keyset.KeySet
+	UsersConn *grpcconn.Conn
 }
 
 func New(log *slog.Logger, cfg *config.Config) *App {
-	usersStorage := usersmanageservice.New(log, cfg.UsersStorageHost, cfg.UsersStoragePort)
+	serverCreds, err := mtls.ServerCredentials(cfg.TLS)
+	if err != nil {
+		panic(err)
+	}
+	// Every method but Introspect acts for the user named in the request, so
+	// it is only served to clients verified over mutual TLS.
+	if !cfg.TLS.VerifiesClients() {
+		panic("tls must be enabled with ca and trusted_clients")
+	}
+	clientCreds, err := mtls.ClientCredentials(cfg.TLS)
+	if err != nil {
+		panic(err)
+	}
+
+	usersConn := grpcconn.New(log,
+		fmt.Sprintf("%s:%d", cfg.UsersStorageHost, cfg.UsersStoragePort),
+		keepalive.ClientParameters{
+			Time:                cfg.GrpcClient.KeepaliveTime,
+			Timeout:             cfg.GrpcClient.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		},
+		grpc.WithTransportCredentials(clientCreds),
+		grpc.WithUnaryInterceptor(resilience.New(log, "user_service", cfg.UsersResilience, usersmanageservice.ReadMethods...).UnaryClientInterceptor),
+	)
+
+	usersStorage := usersmanageservice.New(log, usersConn)
+	sessionsStorage := psqlstorage.New(log, os.Getenv("DATABASE_URL"))
+	//sessionsStorage := mocksessions.New()
+	tokensStorage := psqlstorage.NewTokensStorage(log, sessionsStorage.DB)
+	//tokensStorage := mocktokens.New()
+	totpStorage := psqlstorage.NewTotpStorage(log, sessionsStorage.DB)
+	//totpStorage := mocktotp.New()
+	attemptsStorage := psqlstorage.NewAttemptsStorage(log, sessionsStorage.DB)
+	//attemptsStorage := mockattempts.New()
+	patStorage := psqlstorage.NewPersonalAccessTokensStorage(log, sessionsStorage.DB)
+	//patStorage := mockpersonalaccesstokens.New()
+	appsStorage := psqlstorage.NewAppsStorage(log, sessionsStorage.DB)
+	//appsStorage := mockapps.New()
+
+	// Retired keys stay published until the longest-lived token they signed expires.
+	keySet, err := keyset.New(log, cfg.Jwt.KeysPath, cfg.Jwt.KeyRotationPeriod, max(cfg.AccessTokenTTL, cfg.RefreshTokenTTL))
+	if err != nil {
+		panic(err)
+	}
+	tokens := jwt.New(keySet, cfg.Jwt.Issuer, cfg.Jwt.Audience)
+
+	var mailer interfaces.Mailer
+	switch cfg.Mailer.Kind {
+	case "smtp":
+		mailer = smtpmailer.New(log, cfg.Mailer.SmtpHost, cfg.Mailer.SmtpPort, cfg.Mailer.SmtpUsername, os.Getenv("SMTP_PASSWORD"), cfg.Mailer.From)
+	default:
+		mailer = filemailer.New(log, cfg.Mailer.FilePath)
+	}
 
-	authservice := authservice.New(log, usersStorage, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
-	grpcapp := grpcapp.New(log, authservice, cfg.Grpc.Port)
+	authservice := authservice.New(log, usersStorage, sessionsStorage, tokensStorage, totpStorage, attemptsStorage, patStorage, appsStorage, mailer, tokens, cfg)
+	grpcapp := grpcapp.New(log, authservice, cfg.Grpc.Port, cfg.Grpc.Timeout, serverCreds, cfg.TLS.TrustedClients)
 
 	return &App{
-		GRPCSrv: grpcapp,
+		GRPCSrv:   grpcapp,
+		KeySet:    keySet,
+		UsersConn: usersConn,
 	}
 }
@@ -3,21 +3,55 @@ package grpcapp
 import (
 	"auth/internal/domain/interfaces"
 	grpcauth "auth/internal/grpc/auth"
+	"context"
 	"fmt"
 	"log/slog"
 	"net"
+	"slices"
+	"time"
 
+	authv1 "github.com/chas3air/protos/gen/go/auth"
+	"github.com/chas3air/shared/deadline"
+	"github.com/chas3air/shared/mtls"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
+// keepaliveMinTime is the shortest keepalive ping interval accepted from
+// clients; more frequent pings close the connection.
+const keepaliveMinTime = 10 * time.Second
+
+// openMethods may be called by every client allowed to connect, such as the
+// services checking tokens. The other methods act for the user named in the
+// request, whom only a trusted client, the gateway, has authenticated.
+var openMethods = []string{authv1.Auth_Introspect_FullMethodName}
+
 type App struct {
 	log        *slog.Logger
 	gRPCServer *grpc.Server
 	port       int
 }
 
-func New(log *slog.Logger, authService interfaces.Auth, port int) *App {
-	gRPCServer := grpc.NewServer()
+// New builds the gRPC server with creds, giving every call at most timeout.
+// Methods other than openMethods are served to trustedClients only, which
+// must be verified over mutual TLS.
+func New(log *slog.Logger, authService interfaces.Auth, port int, timeout time.Duration, creds credentials.TransportCredentials, trustedClients []string) *App {
+	gRPCServer := grpc.NewServer(
+		grpc.Creds(creds),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			// Clients keep their connections open and ping them when idle.
+			MinTime:             keepaliveMinTime,
+			PermitWithoutStream: true,
+		}),
+		grpc.ChainUnaryInterceptor(
+			deadline.UnaryServerInterceptor(timeout),
+			mtls.UnaryServerInterceptor(trustedClients),
+			requireTrustedClient(log),
+		),
+	)
 
 	grpcauth.Register(gRPCServer, authService)
 
@@ -28,6 +62,18 @@ func New(log *slog.Logger, authService interfaces.Auth, port int) *App {
 	}
 }
 
+// requireTrustedClient refuses calls of methods other than openMethods from
+// clients that are not trusted.
+func requireTrustedClient(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, ok := mtls.TrustedClient(ctx); !ok && !slices.Contains(openMethods, info.FullMethod) {
+			log.Warn("Untrusted client called restricted method", slog.String("method", info.FullMethod))
+			return nil, status.Error(codes.PermissionDenied, "method is only available to trusted clients")
+		}
+		return handler(ctx, req)
+	}
+}
+
 func (a *App) MustRun() {
 	if err := a.Run(); err != nil {
 		panic(err)
@@ -0,0 +1,69 @@
+package grpcapp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log/slog"
+	"testing"
+
+	authv1 "github.com/chas3air/protos/gen/go/auth"
+	"github.com/chas3air/shared/mtls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// from returns a context of a call made over mutual TLS by client.
+func from(client string) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: client}}}},
+		}},
+	})
+}
+
+func TestRequireTrustedClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		method   string
+		wantCode codes.Code
+	}{
+		{name: "introspect from service", ctx: from("article_service"), method: authv1.Auth_Introspect_FullMethodName, wantCode: codes.OK},
+		{name: "introspect without tls", ctx: context.Background(), method: authv1.Auth_Introspect_FullMethodName, wantCode: codes.OK},
+		{name: "logout all from gateway", ctx: from("api-gateway"), method: authv1.Auth_LogoutAll_FullMethodName, wantCode: codes.OK},
+		{name: "logout all from service", ctx: from("article_service"), method: authv1.Auth_LogoutAll_FullMethodName, wantCode: codes.PermissionDenied},
+		{name: "change password without tls", ctx: context.Background(), method: authv1.Auth_ChangePassword_FullMethodName, wantCode: codes.PermissionDenied},
+		{name: "unlock account from service", ctx: from("user_service"), method: authv1.Auth_UnlockAccount_FullMethodName, wantCode: codes.PermissionDenied},
+		{name: "create token from service", ctx: from("comment_service"), method: authv1.Auth_CreatePersonalAccessToken_FullMethodName, wantCode: codes.PermissionDenied},
+		{name: "create app from service", ctx: from("article_service"), method: authv1.Auth_CreateApp_FullMethodName, wantCode: codes.PermissionDenied},
+		{name: "revoke app from gateway", ctx: from("api-gateway"), method: authv1.Auth_RevokeApp_FullMethodName, wantCode: codes.OK},
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	chain := []grpc.UnaryServerInterceptor{
+		mtls.UnaryServerInterceptor([]string{"api-gateway"}),
+		requireTrustedClient(log),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &grpc.UnaryServerInfo{FullMethod: tt.method}
+			handler := func(ctx context.Context, req any) (any, error) {
+				return chain[1](ctx, req, info, func(ctx context.Context, req any) (any, error) {
+					return nil, nil
+				})
+			}
+
+			_, err := chain[0](tt.ctx, nil, info, handler)
+			if got := status.Code(err); got != tt.wantCode {
+				t.Errorf("code %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AppsStorage interface {
+	Insert(ctx context.Context, app models.App) error
+	GetById(ctx context.Context, id uuid.UUID) (models.App, error)
+	GetAll(ctx context.Context) ([]models.App, error)
+	UpdateSecret(ctx context.Context, id uuid.UUID, secretHash string, rotatedAt time.Time) (models.App, error)
+	Disable(ctx context.Context, id uuid.UUID) (models.App, error)
+}
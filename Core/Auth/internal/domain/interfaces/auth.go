@@ -3,12 +3,40 @@ package interfaces
 import (
 	"auth/internal/domain/models"
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Auth interface {
-	Login(ctx context.Context, email string, password string) (accessToken string, refreshToken string, err error)
+	Login(ctx context.Context, email string, password string, app models.AppCredentials, device models.Device) (models.LoginResult, error)
 	Register(ctx context.Context, user models.User) (models.User, error)
 	IsAdmin(ctx context.Context, user_id uuid.UUID) (isAdmin bool, err error)
+	Refresh(ctx context.Context, refreshToken string) (accessToken string, newRefreshToken string, err error)
+	Logout(ctx context.Context, uid uuid.UUID, sid uuid.UUID) error
+	LogoutAll(ctx context.Context, uid uuid.UUID) (revoked int64, err error)
+	GetSessions(ctx context.Context, uid uuid.UUID) ([]models.Session, error)
+	GetJWKS(ctx context.Context) ([]models.Jwk, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerificationEmail(ctx context.Context, email string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ConfirmPasswordReset(ctx context.Context, token string, newPassword string) error
+	ChangePassword(ctx context.Context, uid uuid.UUID, sid uuid.UUID, currentPassword string, newPassword string) (revoked int64, err error)
+	BeginTotpEnrollment(ctx context.Context, uid uuid.UUID) (secret string, uri string, err error)
+	BeginTotpEnrollmentWithChallenge(ctx context.Context, challengeToken string) (secret string, uri string, err error)
+	ConfirmTotpEnrollment(ctx context.Context, uid uuid.UUID, code string) (recoveryCodes []string, err error)
+	DisableTotp(ctx context.Context, uid uuid.UUID, code string) error
+	VerifyTotpLogin(ctx context.Context, challengeToken string, code string, device models.Device) (models.LoginResult, error)
+	UnlockAccount(ctx context.Context, uid uuid.UUID) error
+	CreatePersonalAccessToken(ctx context.Context, uid uuid.UUID, name string, scopes []string, expiresAt time.Time) (token string, pat models.PersonalAccessToken, err error)
+	ListPersonalAccessTokens(ctx context.Context, uid uuid.UUID) ([]models.PersonalAccessToken, error)
+	RevokePersonalAccessToken(ctx context.Context, uid uuid.UUID, id uuid.UUID) error
+	VerifyPersonalAccessToken(ctx context.Context, token string) (models.PersonalAccessToken, models.User, error)
+	CreateApp(ctx context.Context, name string, redirectUris []string, scopes []string) (secret string, app models.App, err error)
+	GetApp(ctx context.Context, id uuid.UUID) (models.App, error)
+	ListApps(ctx context.Context) ([]models.App, error)
+	RotateAppSecret(ctx context.Context, id uuid.UUID) (secret string, app models.App, err error)
+	RevokeApp(ctx context.Context, id uuid.UUID) error
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	Introspect(ctx context.Context, token string) (models.Introspection, error)
 }
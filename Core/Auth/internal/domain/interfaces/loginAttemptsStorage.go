@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"time"
+)
+
+type LoginAttemptsStorage interface {
+	Get(ctx context.Context, key string) (models.LoginAttempts, error)
+	RegisterFailure(ctx context.Context, key string, window time.Duration) (models.LoginAttempts, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
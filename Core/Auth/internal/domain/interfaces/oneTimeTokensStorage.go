@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OneTimeTokensStorage interface {
+	Insert(ctx context.Context, token models.OneTimeToken) error
+	Consume(ctx context.Context, hash string, purpose string) (models.OneTimeToken, error)
+	DeleteByUserId(ctx context.Context, uid uuid.UUID, purpose string) error
+	CountByUserIdSince(ctx context.Context, uid uuid.UUID, purpose string, since time.Time) (int, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PersonalAccessTokensStorage interface {
+	Insert(ctx context.Context, token models.PersonalAccessToken) error
+	GetByHash(ctx context.Context, hash string) (models.PersonalAccessToken, error)
+	GetByUserId(ctx context.Context, uid uuid.UUID) ([]models.PersonalAccessToken, error)
+	Delete(ctx context.Context, uid uuid.UUID, id uuid.UUID) error
+	Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
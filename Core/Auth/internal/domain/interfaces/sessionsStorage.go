@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SessionsStorage interface {
+	Insert(ctx context.Context, session models.Session) (models.Session, error)
+	GetSessionById(ctx context.Context, sid uuid.UUID) (models.Session, error)
+	GetActiveSessionsByUserId(ctx context.Context, uid uuid.UUID) ([]models.Session, error)
+	Rotate(ctx context.Context, sid uuid.UUID, oldTokenId uuid.UUID, newTokenId uuid.UUID, expiresAt time.Time) (models.Session, error)
+	Revoke(ctx context.Context, sid uuid.UUID) error
+	RevokeAllByUserId(ctx context.Context, uid uuid.UUID) (int64, error)
+}
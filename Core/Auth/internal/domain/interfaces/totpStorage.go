@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"auth/internal/domain/models"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type TotpStorage interface {
+	GetByUserId(ctx context.Context, uid uuid.UUID) (models.Totp, error)
+	SavePending(ctx context.Context, totp models.Totp) error
+	Enable(ctx context.Context, uid uuid.UUID, step int64, recoveryCodeHashes []string) error
+	UseStep(ctx context.Context, uid uuid.UUID, step int64) error
+	ConsumeRecoveryCode(ctx context.Context, uid uuid.UUID, hash string) error
+	Delete(ctx context.Context, uid uuid.UUID) error
+}
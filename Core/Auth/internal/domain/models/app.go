@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// App is a registered client application. Logins made through an app carry
+// its id in the issued tokens, and those tokens can only reach routes
+// covered by Scopes. Only the hash of the secret is stored.
+type App struct {
+	Id              uuid.UUID `json:"id"`
+	Name            string    `json:"name"`
+	SecretHash      string    `json:"-"`
+	RedirectUris    []string  `json:"redirect_uris"`
+	Scopes          []string  `json:"scopes"`
+	Disabled        bool      `json:"disabled"`
+	CreatedAt       time.Time `json:"created_at"`
+	SecretRotatedAt time.Time `json:"secret_rotated_at"`
+}
+
+// AppCredentials identify the app a login is made through. A zero Id means
+// the login is made without one.
+type AppCredentials struct {
+	Id     uuid.UUID
+	Secret string
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Introspection is what Auth says about a token presented to another
+// service. Only Active is set for a token that cannot be used. SessionId is
+// set for access tokens, TokenId for personal access tokens.
+type Introspection struct {
+	Active      bool
+	Subject     uuid.UUID
+	Roles       []string
+	Permissions []string
+	ExpiresAt   time.Time
+	SessionId   uuid.UUID
+	TokenId     uuid.UUID
+	AppId       uuid.UUID
+	Scopes      []string
+}
@@ -0,0 +1,11 @@
+package models
+
+// Jwk is a public signing key in JSON Web Key form (RFC 7517).
+type Jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	X   string `json:"x"`
+}
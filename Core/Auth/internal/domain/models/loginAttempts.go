@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// LoginAttempts counts recent failed logins for one account or one client
+// address. Key is "account:<email>" or "ip:<address>".
+type LoginAttempts struct {
+	Key           string    `json:"key"`
+	Failures      int       `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+	LockedUntil   time.Time `json:"locked_until"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposePasswordReset     = "password_reset"
+)
+
+// OneTimeToken is a single-use secret sent to the user by email. Only the
+// hash of the token is stored.
+type OneTimeToken struct {
+	Hash      string    `json:"hash"`
+	UserId    uuid.UUID `json:"user_id"`
+	Purpose   string    `json:"purpose"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
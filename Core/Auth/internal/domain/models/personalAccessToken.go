@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token, which lets
+// the gateway tell them apart from JWTs without parsing.
+const PersonalAccessTokenPrefix = "rh_pat_"
+
+const (
+	ScopeArticlesWrite  = "articles:write"
+	ScopeCommentsWrite  = "comments:write"
+	ScopeStatsRead      = "stats:read"
+	ScopeUsersWrite     = "users:write"
+	ScopeFavoritesWrite = "favorites:write"
+)
+
+// Scopes lists every scope a personal access token or an app can be
+// granted.
+var Scopes = []string{
+	ScopeArticlesWrite,
+	ScopeCommentsWrite,
+	ScopeStatsRead,
+	ScopeUsersWrite,
+	ScopeFavoritesWrite,
+}
+
+// PersonalAccessToken is a long-lived credential a user creates for scripts.
+// It acts as the user, limited to Scopes. Only the hash of the token is
+// stored.
+type PersonalAccessToken struct {
+	Id         uuid.UUID `json:"id"`
+	UserId     uuid.UUID `json:"user_id"`
+	Name       string    `json:"name"`
+	Hash       string    `json:"-"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
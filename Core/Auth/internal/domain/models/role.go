@@ -0,0 +1,29 @@
+package models
+
+const (
+	RoleUser         = "user"
+	RoleAnalyst      = "analyst"
+	RoleModerator    = "moderator"
+	RoleArticleAdmin = "article_admin"
+	RoleUserAdmin    = "user_admin"
+	RoleAdmin        = "admin"
+)
+
+const (
+	PermArticlesCreate   = "articles.create"
+	PermArticlesModerate = "articles.moderate"
+	PermCommentsCreate   = "comments.create"
+	PermCommentsModerate = "comments.moderate"
+	PermFavoritesManage  = "favorites.manage"
+	PermModerationManage = "moderation.manage"
+	PermStatsRead        = "stats.read"
+	PermUsersManage      = "users.manage"
+	PermAppsManage       = "apps.manage"
+)
+
+// Role grants Permissions and everything granted by the roles it Inherits.
+type Role struct {
+	Name        string   `json:"name"`
+	Inherits    []string `json:"inherits"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a refresh token family: every refresh rotates RefreshTokenId,
+// and presenting any earlier token of the family revokes the whole session.
+type Session struct {
+	Id             uuid.UUID `json:"id"`
+	UserId         uuid.UUID `json:"user_id"`
+	RefreshTokenId uuid.UUID `json:"refresh_token_id"`
+	UserAgent      string    `json:"user_agent"`
+	Ip             string    `json:"ip"`
+	CreatedAt      time.Time `json:"created_at"`
+	LastUsedAt     time.Time `json:"last_used_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	Revoked        bool      `json:"revoked"`
+	AppId          uuid.UUID `json:"app_id"`
+}
+
+// Device describes the client a session was opened from.
+type Device struct {
+	UserAgent string
+	Ip        string
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ChallengeTotp           = "totp"
+	ChallengeTotpEnrollment = "totp_enrollment"
+)
+
+// Totp is the authenticator app secret of a user. It only protects logins
+// once Enabled, which happens after the user has proven they can generate
+// codes for it.
+type Totp struct {
+	UserId       uuid.UUID `json:"user_id"`
+	Secret       string    `json:"-"`
+	Enabled      bool      `json:"enabled"`
+	LastUsedStep int64     `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	ConfirmedAt  time.Time `json:"confirmed_at"`
+}
+
+// LoginResult is what a login step produces: either a pair of tokens, or a
+// challenge that has to be answered with a second factor first.
+type LoginResult struct {
+	AccessToken    string
+	RefreshToken   string
+	ChallengeToken string
+	ChallengeType  string
+	RecoveryCodes  []string
+}
@@ -2,6 +2,7 @@ package authprofiles
 
 import (
 	"auth/internal/domain/models"
+	"auth/internal/lib/rbac"
 	"time"
 
 	authv1 "github.com/chas3air/protos/gen/go/auth"
@@ -18,8 +19,7 @@ func UsrToProtoUsr(user models.User) (*authv1.User, error) {
 	return &authv1.User{
 		Id:          user.Id.String(),
 		Email:       user.Email,
-		Password:    user.Password,
-		Role:        user.Role,
+		Roles:       user.Roles,
 		Nick:        user.Nick,
 		Birthday:    birthday,
 		Description: user.Description,
@@ -41,9 +41,96 @@ func ProtoUsrToUsr(proto_usr *authv1.User) (models.User, error) {
 		Id:          parsedUUID,
 		Email:       proto_usr.GetEmail(),
 		Password:    proto_usr.GetPassword(),
-		Role:        proto_usr.GetRole(),
+		Roles:       proto_usr.GetRoles(),
 		Nick:        proto_usr.GetNick(),
 		Description: proto_usr.GetDescription(),
 		Birthday:    birthday,
 	}, nil
 }
+
+func SessionToProtoSession(session models.Session) *authv1.Session {
+	return &authv1.Session{
+		Id:         session.Id.String(),
+		UserId:     session.UserId.String(),
+		UserAgent:  session.UserAgent,
+		Ip:         session.Ip,
+		CreatedAt:  timestamppb.New(session.CreatedAt),
+		LastUsedAt: timestamppb.New(session.LastUsedAt),
+		ExpiresAt:  timestamppb.New(session.ExpiresAt),
+	}
+}
+
+func JwkToProtoJwk(jwk models.Jwk) *authv1.Jwk {
+	return &authv1.Jwk{
+		Kid: jwk.Kid,
+		Kty: jwk.Kty,
+		Crv: jwk.Crv,
+		Alg: jwk.Alg,
+		Use: jwk.Use,
+		X:   jwk.X,
+	}
+}
+
+func PersonalAccessTokenToProto(pat models.PersonalAccessToken) *authv1.PersonalAccessToken {
+	var lastUsedAt *timestamppb.Timestamp
+	if !pat.LastUsedAt.IsZero() {
+		lastUsedAt = timestamppb.New(pat.LastUsedAt)
+	}
+
+	return &authv1.PersonalAccessToken{
+		Id:         pat.Id.String(),
+		UserId:     pat.UserId.String(),
+		Name:       pat.Name,
+		Scopes:     pat.Scopes,
+		CreatedAt:  timestamppb.New(pat.CreatedAt),
+		ExpiresAt:  timestamppb.New(pat.ExpiresAt),
+		LastUsedAt: lastUsedAt,
+	}
+}
+
+func AppToProto(app models.App) *authv1.App {
+	return &authv1.App{
+		Id:              app.Id.String(),
+		Name:            app.Name,
+		RedirectUris:    app.RedirectUris,
+		Scopes:          app.Scopes,
+		Disabled:        app.Disabled,
+		CreatedAt:       timestamppb.New(app.CreatedAt),
+		SecretRotatedAt: timestamppb.New(app.SecretRotatedAt),
+	}
+}
+
+func RoleToProto(role models.Role) *authv1.Role {
+	return &authv1.Role{
+		Name:                 role.Name,
+		Inherits:             role.Inherits,
+		Permissions:          role.Permissions,
+		EffectivePermissions: rbac.Permissions([]string{role.Name}),
+	}
+}
+
+func IntrospectionToProto(introspection models.Introspection) *authv1.IntrospectResponse {
+	if !introspection.Active {
+		return &authv1.IntrospectResponse{}
+	}
+
+	resp := &authv1.IntrospectResponse{
+		Active:      true,
+		Subject:     introspection.Subject.String(),
+		Roles:       introspection.Roles,
+		Permissions: introspection.Permissions,
+		ExpiresAt:   timestamppb.New(introspection.ExpiresAt),
+		Scopes:      introspection.Scopes,
+	}
+	if introspection.SessionId != uuid.Nil {
+		resp.SessionId = introspection.SessionId.String()
+	}
+	if introspection.TokenId != uuid.Nil {
+		resp.TokenId = introspection.TokenId.String()
+	}
+	if introspection.AppId != uuid.Nil {
+		resp.AppId = introspection.AppId.String()
+	}
+
+	return resp
+}
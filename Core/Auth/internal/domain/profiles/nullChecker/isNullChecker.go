@@ -10,6 +10,5 @@ func IsUserNullChecker(user models.User) bool {
 	return user.Id != uuid.UUID{} &&
 		user.Email != "" &&
 		user.Password != "" &&
-		user.Role != "" &&
 		user.Nick != ""
 }
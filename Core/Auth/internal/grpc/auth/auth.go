@@ -2,18 +2,25 @@ package grpcauth
 
 import (
 	"auth/internal/domain/interfaces"
+	"auth/internal/domain/models"
 	authprofiles "auth/internal/domain/profiles/auth_profiles"
 	nullchecker "auth/internal/domain/profiles/nullChecker"
+	"auth/internal/lib/rbac"
 	authservice "auth/internal/services/auth"
 	"auth/internal/storage"
 	"context"
 	"errors"
+	"time"
 
 	authv1 "github.com/chas3air/protos/gen/go/auth"
+	"github.com/chas3air/shared/validation"
 	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type serverAPI struct {
@@ -38,17 +45,43 @@ func (s *serverAPI) Login(ctx context.Context, in *authv1.LoginRequest) (*authv1
 		return nil, status.Error(codes.InvalidArgument, "email and password are required")
 	}
 
-	accessToken, refreshToken, err := s.auth.Login(ctx, email, password)
+	var app models.AppCredentials
+	if in.GetAppId() != "" {
+		appId, err := uuid.Parse(in.GetAppId())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "app_id must be uuid")
+		}
+		app = models.AppCredentials{
+			Id:     appId,
+			Secret: in.GetAppSecret(),
+		}
+	}
+
+	result, err := s.auth.Login(ctx, email, password, app, models.Device{
+		UserAgent: in.GetUserAgent(),
+		Ip:        in.GetIp(),
+	})
 	if err != nil {
+		if errors.Is(err, authservice.ErrInvalidAppID) {
+			return nil, status.Error(codes.Unauthenticated, "unknown app or wrong app secret")
+		}
 		if errors.Is(err, authservice.ErrInvalidCredentials) {
-			return nil, status.Error(codes.NotFound, "invalid email or password")
+			return nil, status.Error(codes.Unauthenticated, "invalid email or password")
+		}
+		if errors.Is(err, authservice.ErrEmailNotVerified) {
+			return nil, status.Error(codes.FailedPrecondition, "email is not verified")
+		}
+		if errors.Is(err, authservice.ErrTooManyAttempts) {
+			return nil, lockoutStatus(err)
 		}
 		return nil, status.Error(codes.Internal, "failed to login")
 	}
 
 	return &authv1.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		AccessToken:    result.AccessToken,
+		RefreshToken:   result.RefreshToken,
+		ChallengeToken: result.ChallengeToken,
+		ChallengeType:  result.ChallengeType,
 	}, nil
 }
 
@@ -59,20 +92,20 @@ func (s *serverAPI) Register(ctx context.Context, in *authv1.RegisterRequest) (*
 	default:
 	}
 
-	req_user := in.GetUser()
-	if req_user.GetEmail() == "" || req_user.GetPassword() == "" {
-		return nil, status.Error(codes.InvalidArgument, "email and password are required")
-	}
-	user, err := authprofiles.ProtoUsrToUsr(req_user)
+	user, err := authprofiles.ProtoUsrToUsr(in.GetUser())
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, "wrong parametr")
 	}
 
+	if err := validation.Struct(user, "invalid user"); err != nil {
+		return nil, err
+	}
+
 	if !nullchecker.IsUserNullChecker(user) {
 		return nil, status.Error(codes.InvalidArgument, "not all user fields are feeled")
 	}
 
-	_, err = s.auth.Register(ctx, user)
+	registered, err := s.auth.Register(ctx, user)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserExists) {
 			return nil, status.Error(codes.AlreadyExists, "user already exists")
@@ -80,8 +113,13 @@ func (s *serverAPI) Register(ctx context.Context, in *authv1.RegisterRequest) (*
 		return nil, status.Error(codes.Internal, "failed to register user")
 	}
 
+	resUser, err := authprofiles.UsrToProtoUsr(registered)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "wrong structure")
+	}
+
 	return &authv1.RegisterResponse{
-		User: in.GetUser(),
+		User: resUser,
 	}, nil
 }
 
@@ -113,3 +151,704 @@ func (s *serverAPI) IsAdmin(ctx context.Context, in *authv1.IsAdminRequest) (*au
 		IsAdmin: isAdmin,
 	}, nil
 }
+
+func (s *serverAPI) Refresh(ctx context.Context, in *authv1.RefreshRequest) (*authv1.RefreshResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	if in.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh token is required")
+	}
+
+	accessToken, refreshToken, err := s.auth.Refresh(ctx, in.GetRefreshToken())
+	if err != nil {
+		if errors.Is(err, authservice.ErrTokenReused) {
+			return nil, status.Error(codes.Unauthenticated, "refresh token reused, session revoked")
+		}
+		if errors.Is(err, authservice.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+		}
+		return nil, status.Error(codes.Internal, "failed to refresh tokens")
+	}
+
+	return &authv1.RefreshResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (s *serverAPI) Logout(ctx context.Context, in *authv1.LogoutRequest) (*authv1.LogoutResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	uid, err := uuid.Parse(in.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be uuid")
+	}
+
+	sid, err := uuid.Parse(in.GetSessionId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "session_id must be uuid")
+	}
+
+	if err := s.auth.Logout(ctx, uid, sid); err != nil {
+		if errors.Is(err, authservice.ErrSessionNotFound) {
+			return nil, status.Error(codes.NotFound, "session not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to logout")
+	}
+
+	return &authv1.LogoutResponse{}, nil
+}
+
+func (s *serverAPI) LogoutAll(ctx context.Context, in *authv1.LogoutAllRequest) (*authv1.LogoutAllResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	uid, err := uuid.Parse(in.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be uuid")
+	}
+
+	revoked, err := s.auth.LogoutAll(ctx, uid)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to logout from all sessions")
+	}
+
+	return &authv1.LogoutAllResponse{
+		Revoked: revoked,
+	}, nil
+}
+
+func (s *serverAPI) GetSessions(ctx context.Context, in *authv1.GetSessionsRequest) (*authv1.GetSessionsResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	uid, err := uuid.Parse(in.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be uuid")
+	}
+
+	sessions, err := s.auth.GetSessions(ctx, uid)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get sessions")
+	}
+
+	protoSessions := make([]*authv1.Session, 0, len(sessions))
+	for _, session := range sessions {
+		protoSessions = append(protoSessions, authprofiles.SessionToProtoSession(session))
+	}
+
+	return &authv1.GetSessionsResponse{
+		Sessions: protoSessions,
+	}, nil
+}
+
+func (s *serverAPI) GetJWKS(ctx context.Context, in *authv1.GetJWKSRequest) (*authv1.GetJWKSResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	jwks, err := s.auth.GetJWKS(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get signing keys")
+	}
+
+	protoKeys := make([]*authv1.Jwk, 0, len(jwks))
+	for _, jwk := range jwks {
+		protoKeys = append(protoKeys, authprofiles.JwkToProtoJwk(jwk))
+	}
+
+	return &authv1.GetJWKSResponse{
+		Keys: protoKeys,
+	}, nil
+}
+
+func (s *serverAPI) VerifyEmail(ctx context.Context, in *authv1.VerifyEmailRequest) (*authv1.VerifyEmailResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	if in.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	if err := s.auth.VerifyEmail(ctx, in.GetToken()); err != nil {
+		if errors.Is(err, authservice.ErrInvalidToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired token")
+		}
+		return nil, status.Error(codes.Internal, "failed to verify email")
+	}
+
+	return &authv1.VerifyEmailResponse{}, nil
+}
+
+func (s *serverAPI) ResendVerificationEmail(ctx context.Context, in *authv1.ResendVerificationEmailRequest) (*authv1.ResendVerificationEmailResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	if in.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	if err := s.auth.ResendVerificationEmail(ctx, in.GetEmail()); err != nil {
+		return nil, status.Error(codes.Internal, "failed to send verification email")
+	}
+
+	return &authv1.ResendVerificationEmailResponse{}, nil
+}
+
+func (s *serverAPI) RequestPasswordReset(ctx context.Context, in *authv1.RequestPasswordResetRequest) (*authv1.RequestPasswordResetResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	if in.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	if err := s.auth.RequestPasswordReset(ctx, in.GetEmail()); err != nil {
+		return nil, status.Error(codes.Internal, "failed to request password reset")
+	}
+
+	return &authv1.RequestPasswordResetResponse{}, nil
+}
+
+func (s *serverAPI) ConfirmPasswordReset(ctx context.Context, in *authv1.ConfirmPasswordResetRequest) (*authv1.ConfirmPasswordResetResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	if in.GetToken() == "" || in.GetNewPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token and new password are required")
+	}
+
+	if err := s.auth.ConfirmPasswordReset(ctx, in.GetToken(), in.GetNewPassword()); err != nil {
+		if errors.Is(err, authservice.ErrInvalidToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired token")
+		}
+		return nil, status.Error(codes.Internal, "failed to reset password")
+	}
+
+	return &authv1.ConfirmPasswordResetResponse{}, nil
+}
+
+func (s *serverAPI) ChangePassword(ctx context.Context, in *authv1.ChangePasswordRequest) (*authv1.ChangePasswordResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	uid, err := uuid.Parse(in.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be uuid")
+	}
+	sid, err := uuid.Parse(in.GetSessionId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "session_id must be uuid")
+	}
+	if in.GetCurrentPassword() == "" || in.GetNewPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "current and new password are required")
+	}
+
+	revoked, err := s.auth.ChangePassword(ctx, uid, sid, in.GetCurrentPassword(), in.GetNewPassword())
+	if err != nil {
+		if errors.Is(err, authservice.ErrInvalidCredentials) {
+			return nil, status.Error(codes.PermissionDenied, "current password is incorrect")
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to change password")
+	}
+
+	return &authv1.ChangePasswordResponse{
+		RevokedSessions: revoked,
+	}, nil
+}
+
+func (s *serverAPI) BeginTotpEnrollment(ctx context.Context, in *authv1.BeginTotpEnrollmentRequest) (*authv1.BeginTotpEnrollmentResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	var secret, uri string
+	var err error
+	if in.GetChallengeToken() != "" {
+		secret, uri, err = s.auth.BeginTotpEnrollmentWithChallenge(ctx, in.GetChallengeToken())
+	} else {
+		uid, parseErr := uuid.Parse(in.GetUserId())
+		if parseErr != nil {
+			return nil, status.Error(codes.InvalidArgument, "user_id must be uuid")
+		}
+		secret, uri, err = s.auth.BeginTotpEnrollment(ctx, uid)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, authservice.ErrInvalidToken):
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired challenge")
+		case errors.Is(err, authservice.ErrTotpAlreadyEnabled):
+			return nil, status.Error(codes.AlreadyExists, "totp is already enabled")
+		case errors.Is(err, storage.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to start totp enrollment")
+	}
+
+	return &authv1.BeginTotpEnrollmentResponse{
+		Secret:     secret,
+		OtpauthUri: uri,
+	}, nil
+}
+
+func (s *serverAPI) ConfirmTotpEnrollment(ctx context.Context, in *authv1.ConfirmTotpEnrollmentRequest) (*authv1.ConfirmTotpEnrollmentResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	uid, err := uuid.Parse(in.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be uuid")
+	}
+
+	if in.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	recoveryCodes, err := s.auth.ConfirmTotpEnrollment(ctx, uid, in.GetCode())
+	if err != nil {
+		switch {
+		case errors.Is(err, authservice.ErrInvalidTotpCode):
+			return nil, status.Error(codes.InvalidArgument, "invalid code")
+		case errors.Is(err, authservice.ErrTotpNotEnrolled):
+			return nil, status.Error(codes.FailedPrecondition, "totp enrollment not started")
+		case errors.Is(err, authservice.ErrTotpAlreadyEnabled):
+			return nil, status.Error(codes.AlreadyExists, "totp is already enabled")
+		}
+		return nil, status.Error(codes.Internal, "failed to confirm totp enrollment")
+	}
+
+	return &authv1.ConfirmTotpEnrollmentResponse{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+func (s *serverAPI) DisableTotp(ctx context.Context, in *authv1.DisableTotpRequest) (*authv1.DisableTotpResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	uid, err := uuid.Parse(in.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be uuid")
+	}
+
+	if err := s.auth.DisableTotp(ctx, uid, in.GetCode()); err != nil {
+		switch {
+		case errors.Is(err, authservice.ErrInvalidTotpCode):
+			return nil, status.Error(codes.InvalidArgument, "invalid code")
+		case errors.Is(err, authservice.ErrTotpNotEnrolled):
+			return nil, status.Error(codes.FailedPrecondition, "totp is not set up")
+		case errors.Is(err, authservice.ErrTotpRequired):
+			return nil, status.Error(codes.PermissionDenied, "totp is required for this role")
+		case errors.Is(err, storage.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to disable totp")
+	}
+
+	return &authv1.DisableTotpResponse{}, nil
+}
+
+func (s *serverAPI) VerifyTotpLogin(ctx context.Context, in *authv1.VerifyTotpLoginRequest) (*authv1.VerifyTotpLoginResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	if in.GetChallengeToken() == "" || in.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "challenge token and code are required")
+	}
+
+	result, err := s.auth.VerifyTotpLogin(ctx, in.GetChallengeToken(), in.GetCode(), models.Device{
+		UserAgent: in.GetUserAgent(),
+		Ip:        in.GetIp(),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, authservice.ErrInvalidToken), errors.Is(err, authservice.ErrInvalidAppID):
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired challenge")
+		case errors.Is(err, authservice.ErrInvalidTotpCode):
+			return nil, status.Error(codes.InvalidArgument, "invalid code")
+		case errors.Is(err, authservice.ErrTotpNotEnrolled):
+			return nil, status.Error(codes.FailedPrecondition, "totp enrollment not started")
+		case errors.Is(err, authservice.ErrTooManyAttempts):
+			return nil, lockoutStatus(err)
+		}
+		return nil, status.Error(codes.Internal, "failed to verify second factor")
+	}
+
+	return &authv1.VerifyTotpLoginResponse{
+		AccessToken:   result.AccessToken,
+		RefreshToken:  result.RefreshToken,
+		RecoveryCodes: result.RecoveryCodes,
+	}, nil
+}
+
+func (s *serverAPI) UnlockAccount(ctx context.Context, in *authv1.UnlockAccountRequest) (*authv1.UnlockAccountResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	uid, err := uuid.Parse(in.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be uuid")
+	}
+
+	if err := s.auth.UnlockAccount(ctx, uid); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to unlock account")
+	}
+
+	return &authv1.UnlockAccountResponse{}, nil
+}
+
+func (s *serverAPI) CreatePersonalAccessToken(ctx context.Context, in *authv1.CreatePersonalAccessTokenRequest) (*authv1.CreatePersonalAccessTokenResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	uid, err := uuid.Parse(in.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be uuid")
+	}
+
+	if in.GetName() == "" || len(in.GetScopes()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "name and scopes are required")
+	}
+
+	var expiresAt time.Time
+	if in.GetExpiresAt() != nil {
+		expiresAt = in.GetExpiresAt().AsTime()
+	}
+
+	token, pat, err := s.auth.CreatePersonalAccessToken(ctx, uid, in.GetName(), in.GetScopes(), expiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, authservice.ErrInvalidScope):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, authservice.ErrInvalidExpiry):
+			return nil, status.Error(codes.InvalidArgument, "expiry is in the past or too far away")
+		case errors.Is(err, authservice.ErrTooManyPersonalAccessTokens):
+			return nil, status.Error(codes.FailedPrecondition, "too many personal access tokens")
+		case errors.Is(err, storage.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to create personal access token")
+	}
+
+	return &authv1.CreatePersonalAccessTokenResponse{
+		Token:               token,
+		PersonalAccessToken: authprofiles.PersonalAccessTokenToProto(pat),
+	}, nil
+}
+
+func (s *serverAPI) ListPersonalAccessTokens(ctx context.Context, in *authv1.ListPersonalAccessTokensRequest) (*authv1.ListPersonalAccessTokensResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	uid, err := uuid.Parse(in.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be uuid")
+	}
+
+	pats, err := s.auth.ListPersonalAccessTokens(ctx, uid)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get personal access tokens")
+	}
+
+	protoPats := make([]*authv1.PersonalAccessToken, 0, len(pats))
+	for _, pat := range pats {
+		protoPats = append(protoPats, authprofiles.PersonalAccessTokenToProto(pat))
+	}
+
+	return &authv1.ListPersonalAccessTokensResponse{
+		PersonalAccessTokens: protoPats,
+	}, nil
+}
+
+func (s *serverAPI) RevokePersonalAccessToken(ctx context.Context, in *authv1.RevokePersonalAccessTokenRequest) (*authv1.RevokePersonalAccessTokenResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	uid, err := uuid.Parse(in.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be uuid")
+	}
+
+	id, err := uuid.Parse(in.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "id must be uuid")
+	}
+
+	if err := s.auth.RevokePersonalAccessToken(ctx, uid, id); err != nil {
+		if errors.Is(err, authservice.ErrPersonalAccessTokenNotFound) {
+			return nil, status.Error(codes.NotFound, "personal access token not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to revoke personal access token")
+	}
+
+	return &authv1.RevokePersonalAccessTokenResponse{}, nil
+}
+
+func (s *serverAPI) VerifyPersonalAccessToken(ctx context.Context, in *authv1.VerifyPersonalAccessTokenRequest) (*authv1.VerifyPersonalAccessTokenResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	if in.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	pat, user, err := s.auth.VerifyPersonalAccessToken(ctx, in.GetToken())
+	if err != nil {
+		if errors.Is(err, authservice.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid personal access token")
+		}
+		return nil, status.Error(codes.Internal, "failed to verify personal access token")
+	}
+
+	return &authv1.VerifyPersonalAccessTokenResponse{
+		UserId:      user.Id.String(),
+		Roles:       user.Roles,
+		Permissions: rbac.Permissions(user.Roles),
+		Scopes:      pat.Scopes,
+		TokenId:     pat.Id.String(),
+		ExpiresAt:   timestamppb.New(pat.ExpiresAt),
+	}, nil
+}
+
+func (s *serverAPI) CreateApp(ctx context.Context, in *authv1.CreateAppRequest) (*authv1.CreateAppResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	if in.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	secret, app, err := s.auth.CreateApp(ctx, in.GetName(), in.GetRedirectUris(), in.GetScopes())
+	if err != nil {
+		if errors.Is(err, authservice.ErrInvalidScope) || errors.Is(err, authservice.ErrInvalidRedirectUri) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to create app")
+	}
+
+	return &authv1.CreateAppResponse{
+		Secret: secret,
+		App:    authprofiles.AppToProto(app),
+	}, nil
+}
+
+func (s *serverAPI) GetApp(ctx context.Context, in *authv1.GetAppRequest) (*authv1.GetAppResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	id, err := uuid.Parse(in.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "id must be uuid")
+	}
+
+	app, err := s.auth.GetApp(ctx, id)
+	if err != nil {
+		if errors.Is(err, authservice.ErrAppNotFound) {
+			return nil, status.Error(codes.NotFound, "app not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to get app")
+	}
+
+	return &authv1.GetAppResponse{
+		App: authprofiles.AppToProto(app),
+	}, nil
+}
+
+func (s *serverAPI) ListApps(ctx context.Context, in *authv1.ListAppsRequest) (*authv1.ListAppsResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	apps, err := s.auth.ListApps(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get apps")
+	}
+
+	protoApps := make([]*authv1.App, 0, len(apps))
+	for _, app := range apps {
+		protoApps = append(protoApps, authprofiles.AppToProto(app))
+	}
+
+	return &authv1.ListAppsResponse{
+		Apps: protoApps,
+	}, nil
+}
+
+func (s *serverAPI) RotateAppSecret(ctx context.Context, in *authv1.RotateAppSecretRequest) (*authv1.RotateAppSecretResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	id, err := uuid.Parse(in.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "id must be uuid")
+	}
+
+	secret, app, err := s.auth.RotateAppSecret(ctx, id)
+	if err != nil {
+		if errors.Is(err, authservice.ErrAppNotFound) {
+			return nil, status.Error(codes.NotFound, "app not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to rotate app secret")
+	}
+
+	return &authv1.RotateAppSecretResponse{
+		Secret: secret,
+		App:    authprofiles.AppToProto(app),
+	}, nil
+}
+
+func (s *serverAPI) RevokeApp(ctx context.Context, in *authv1.RevokeAppRequest) (*authv1.RevokeAppResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	id, err := uuid.Parse(in.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "id must be uuid")
+	}
+
+	if err := s.auth.RevokeApp(ctx, id); err != nil {
+		if errors.Is(err, authservice.ErrAppNotFound) {
+			return nil, status.Error(codes.NotFound, "app not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to revoke app")
+	}
+
+	return &authv1.RevokeAppResponse{}, nil
+}
+
+// lockoutStatus reports a lockout as ResourceExhausted, with the time left
+// attached as RetryInfo so the gateway can set Retry-After.
+func lockoutStatus(err error) error {
+	st := status.New(codes.ResourceExhausted, "too many failed login attempts")
+
+	var lockout *authservice.LockoutError
+	if !errors.As(err, &lockout) {
+		return st.Err()
+	}
+
+	detailed, detailsErr := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(lockout.RetryAfter().Round(time.Second)),
+	})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func (s *serverAPI) ListRoles(ctx context.Context, in *authv1.ListRolesRequest) (*authv1.ListRolesResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	roles, err := s.auth.ListRoles(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get roles")
+	}
+
+	protoRoles := make([]*authv1.Role, 0, len(roles))
+	for _, role := range roles {
+		protoRoles = append(protoRoles, authprofiles.RoleToProto(role))
+	}
+
+	return &authv1.ListRolesResponse{
+		Roles: protoRoles,
+	}, nil
+}
+
+func (s *serverAPI) Introspect(ctx context.Context, in *authv1.IntrospectRequest) (*authv1.IntrospectResponse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	if in.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	introspection, err := s.auth.Introspect(ctx, in.GetToken())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to introspect token")
+	}
+
+	return authprofiles.IntrospectionToProto(introspection), nil
+}
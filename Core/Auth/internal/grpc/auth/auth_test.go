@@ -0,0 +1,51 @@
+package grpcauth
+
+import (
+	"auth/internal/domain/interfaces"
+	"auth/internal/domain/models"
+	authservice "auth/internal/services/auth"
+	"context"
+	"fmt"
+	"testing"
+
+	authv1 "github.com/chas3air/protos/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// loginAuth answers every login with err.
+type loginAuth struct {
+	interfaces.Auth
+	err error
+}
+
+func (a loginAuth) Login(ctx context.Context, email string, password string, app models.AppCredentials, device models.Device) (models.LoginResult, error) {
+	if a.err != nil {
+		return models.LoginResult{}, a.err
+	}
+	return models.LoginResult{AccessToken: "access", RefreshToken: "refresh"}, nil
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode codes.Code
+	}{
+		{name: "valid credentials", wantCode: codes.OK},
+		{name: "wrong password", err: fmt.Errorf("auth.Login: %w", authservice.ErrInvalidCredentials), wantCode: codes.Unauthenticated},
+		{name: "unknown app", err: fmt.Errorf("auth.Login: %w", authservice.ErrInvalidAppID), wantCode: codes.Unauthenticated},
+		{name: "email not verified", err: fmt.Errorf("auth.Login: %w", authservice.ErrEmailNotVerified), wantCode: codes.FailedPrecondition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &serverAPI{auth: loginAuth{err: tt.err}}
+
+			_, err := s.Login(context.Background(), &authv1.LoginRequest{Email: "user@example.com", Password: "wrong"})
+			if got := status.Code(err); got != tt.wantCode {
+				t.Errorf("code %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package hasher
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestVerify(t *testing.T) {
+	hash, err := Hash("secret", bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == "secret" {
+		t.Fatal("Hash returned the password")
+	}
+
+	tests := []struct {
+		name            string
+		stored          string
+		password        string
+		cost            int
+		wantOk          bool
+		wantNeedsRehash bool
+	}{
+		{name: "hash", stored: hash, password: "secret", cost: bcrypt.MinCost, wantOk: true},
+		{name: "wrong password", stored: hash, password: "Secret", cost: bcrypt.MinCost},
+		{name: "other cost", stored: hash, password: "secret", cost: bcrypt.MinCost + 1, wantOk: true, wantNeedsRehash: true},
+		{name: "plain text", stored: "secret", password: "secret", cost: bcrypt.MinCost, wantOk: true, wantNeedsRehash: true},
+		{name: "wrong plain text", stored: "secret", password: "other", cost: bcrypt.MinCost},
+		{name: "empty", stored: "", password: "", cost: bcrypt.MinCost, wantOk: true, wantNeedsRehash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash := Verify(tt.stored, tt.password, tt.cost)
+			if ok != tt.wantOk || needsRehash != tt.wantNeedsRehash {
+				t.Errorf("Verify = %v, %v; want %v, %v", ok, needsRehash, tt.wantOk, tt.wantNeedsRehash)
+			}
+		})
+	}
+}
+
+func TestHashIsSalted(t *testing.T) {
+	first, err := Hash("secret", bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Hash("secret", bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first == second {
+		t.Error("two hashes of the same password are equal")
+	}
+}
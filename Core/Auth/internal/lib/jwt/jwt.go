@@ -2,33 +2,304 @@ package jwt
 
 import (
 	"auth/internal/domain/models"
-	"os"
+	"auth/internal/lib/keyset"
+	"auth/internal/lib/rbac"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func NewTokens(user models.User, accessDuration, refreshDuration time.Duration) (string, string, error) {
-	accessToken := jwt.New(jwt.SigningMethodHS256)
-	accessClaims := accessToken.Claims.(jwt.MapClaims)
-	accessClaims["uid"] = user.Id
-	accessClaims["role"] = user.Role
-	accessClaims["exp"] = time.Now().Add(accessDuration).Unix()
+const (
+	refreshTokenType   = "refresh"
+	challengeTokenType = "challenge"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type RefreshClaims struct {
+	Uid uuid.UUID
+	Sid uuid.UUID
+	Jti uuid.UUID
+	Exp time.Time
+}
+
+// AccessClaims are what an access token says about its user and session.
+type AccessClaims struct {
+	Uid         uuid.UUID
+	Sid         uuid.UUID
+	AppId       uuid.UUID
+	Roles       []string
+	Permissions []string
+	Exp         time.Time
+}
 
-	accessTokenString, err := accessToken.SignedString([]byte(os.Getenv("AUTH_SECRET")))
+// ChallengeClaims identify the user who passed the password step of a
+// login and what they still have to do to finish it.
+type ChallengeClaims struct {
+	Uid     uuid.UUID
+	AppId   uuid.UUID
+	Purpose string
+}
+
+// TokenManager issues tokens signed with the current key of the key set.
+// Access tokens are addressed to audience, refresh tokens only to the
+// issuer itself, so one can never be used in place of the other.
+type TokenManager struct {
+	keys     *keyset.KeySet
+	issuer   string
+	audience string
+}
+
+func New(keys *keyset.KeySet, issuer string, audience string) *TokenManager {
+	return &TokenManager{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+	}
+}
+
+func (tm *TokenManager) NewTokens(user models.User, session models.Session, accessDuration, refreshDuration time.Duration) (string, string, error) {
+	now := time.Now()
+
+	accessClaims := jwt.MapClaims{
+		"iss":   tm.issuer,
+		"aud":   tm.audience,
+		"uid":   user.Id,
+		"roles": user.Roles,
+		"perms": rbac.Permissions(user.Roles),
+		"sid":   session.Id,
+		"iat":   now.Unix(),
+		"exp":   now.Add(accessDuration).Unix(),
+	}
+	if session.AppId != uuid.Nil {
+		accessClaims["app_id"] = session.AppId
+	}
+
+	accessTokenString, err := tm.sign(accessClaims)
 	if err != nil {
 		return "", "", err
 	}
 
-	refreshToken := jwt.New(jwt.SigningMethodHS256)
-	refreshClaims := refreshToken.Claims.(jwt.MapClaims)
-	refreshClaims["uid"] = user.Id
-	refreshClaims["exp"] = time.Now().Add(refreshDuration).Unix()
-
-	refreshTokenString, err := refreshToken.SignedString([]byte(os.Getenv("AUTH_SECRET")))
+	refreshTokenString, err := tm.sign(jwt.MapClaims{
+		"iss": tm.issuer,
+		"aud": tm.issuer,
+		"uid": user.Id,
+		"sid": session.Id,
+		"jti": session.RefreshTokenId,
+		"typ": refreshTokenType,
+		"iat": now.Unix(),
+		"exp": now.Add(refreshDuration).Unix(),
+	})
 	if err != nil {
 		return "", "", err
 	}
 
 	return accessTokenString, refreshTokenString, nil
 }
+
+// NewChallengeToken issues a short-lived token that proves the password
+// step of a login succeeded. Like refresh tokens it is addressed to the
+// issuer only, so it is never accepted as an access token. appId, if set,
+// is the app the login was started through.
+func (tm *TokenManager) NewChallengeToken(uid uuid.UUID, appId uuid.UUID, purpose string, duration time.Duration) (string, error) {
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"iss": tm.issuer,
+		"aud": tm.issuer,
+		"uid": uid,
+		"pur": purpose,
+		"typ": challengeTokenType,
+		"iat": now.Unix(),
+		"exp": now.Add(duration).Unix(),
+	}
+	if appId != uuid.Nil {
+		claims["app_id"] = appId
+	}
+
+	return tm.sign(claims)
+}
+
+// ParseRefreshToken verifies the signature, issuer and expiry of a refresh
+// token and returns its claims.
+func (tm *TokenManager) ParseRefreshToken(tokenString string) (RefreshClaims, error) {
+	claims, err := tm.parseOwn(tokenString, refreshTokenType)
+	if err != nil {
+		return RefreshClaims{}, err
+	}
+
+	uid, err := uuidClaim(claims, "uid")
+	if err != nil {
+		return RefreshClaims{}, err
+	}
+	sid, err := uuidClaim(claims, "sid")
+	if err != nil {
+		return RefreshClaims{}, err
+	}
+	jti, err := uuidClaim(claims, "jti")
+	if err != nil {
+		return RefreshClaims{}, err
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return RefreshClaims{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	return RefreshClaims{
+		Uid: uid,
+		Sid: sid,
+		Jti: jti,
+		Exp: exp.Time,
+	}, nil
+}
+
+// ParseChallengeToken verifies a token issued by NewChallengeToken.
+func (tm *TokenManager) ParseChallengeToken(tokenString string) (ChallengeClaims, error) {
+	claims, err := tm.parseOwn(tokenString, challengeTokenType)
+	if err != nil {
+		return ChallengeClaims{}, err
+	}
+
+	uid, err := uuidClaim(claims, "uid")
+	if err != nil {
+		return ChallengeClaims{}, err
+	}
+	purpose, ok := claims["pur"].(string)
+	if !ok {
+		return ChallengeClaims{}, fmt.Errorf("%w: missing pur", ErrInvalidToken)
+	}
+	appId := uuid.Nil
+	if _, ok := claims["app_id"]; ok {
+		if appId, err = uuidClaim(claims, "app_id"); err != nil {
+			return ChallengeClaims{}, err
+		}
+	}
+
+	return ChallengeClaims{
+		Uid:     uid,
+		AppId:   appId,
+		Purpose: purpose,
+	}, nil
+}
+
+// ParseAccessToken verifies the signature, issuer, audience and expiry of
+// an access token and returns its claims. Refresh and challenge tokens are
+// rejected.
+func (tm *TokenManager) ParseAccessToken(tokenString string) (AccessClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return tm.keys.PublicKey(kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(tm.issuer),
+		jwt.WithAudience(tm.audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return AccessClaims{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return AccessClaims{}, fmt.Errorf("%w: unexpected claims", ErrInvalidToken)
+	}
+	if _, ok := claims["typ"]; ok {
+		return AccessClaims{}, fmt.Errorf("%w: not an access token", ErrInvalidToken)
+	}
+
+	uid, err := uuidClaim(claims, "uid")
+	if err != nil {
+		return AccessClaims{}, err
+	}
+	sid, err := uuidClaim(claims, "sid")
+	if err != nil {
+		return AccessClaims{}, err
+	}
+	appId := uuid.Nil
+	if _, ok := claims["app_id"]; ok {
+		if appId, err = uuidClaim(claims, "app_id"); err != nil {
+			return AccessClaims{}, err
+		}
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return AccessClaims{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	return AccessClaims{
+		Uid:         uid,
+		Sid:         sid,
+		AppId:       appId,
+		Roles:       stringsClaim(claims, "roles"),
+		Permissions: stringsClaim(claims, "perms"),
+		Exp:         exp.Time,
+	}, nil
+}
+
+// JWKS returns the public keys tokens issued by this manager verify against.
+func (tm *TokenManager) JWKS() []models.Jwk {
+	return tm.keys.JWKS()
+}
+
+func (tm *TokenManager) sign(claims jwt.MapClaims) (string, error) {
+	key := tm.keys.SigningKey()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = key.Id
+
+	return token.SignedString(key.Private)
+}
+
+// parseOwn verifies a token the issuer addressed to itself and checks that
+// it is of the expected type.
+func (tm *TokenManager) parseOwn(tokenString string, typ string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return tm.keys.PublicKey(kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(tm.issuer),
+		jwt.WithAudience(tm.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != typ {
+		return nil, fmt.Errorf("%w: not a %s token", ErrInvalidToken, typ)
+	}
+
+	return claims, nil
+}
+
+func uuidClaim(claims jwt.MapClaims, key string) (uuid.UUID, error) {
+	s, ok := claims[key].(string)
+	if !ok {
+		return uuid.Nil, fmt.Errorf("%w: missing %s", ErrInvalidToken, key)
+	}
+
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %s must be uuid", ErrInvalidToken, key)
+	}
+
+	return id, nil
+}
+
+func stringsClaim(claims jwt.MapClaims, key string) []string {
+	raw, _ := claims[key].([]interface{})
+
+	values := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			values = append(values, s)
+		}
+	}
+
+	return values
+}
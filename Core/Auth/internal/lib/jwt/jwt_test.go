@@ -0,0 +1,322 @@
+package jwt
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/lib/keyset"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func newManager(t *testing.T, issuer string) *TokenManager {
+	t.Helper()
+
+	keys, err := keyset.New(slog.New(slog.NewTextHandler(io.Discard, nil)), t.TempDir(), time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(keys, issuer, "redhub")
+}
+
+func TestParseRefreshToken(t *testing.T) {
+	user := models.User{Id: uuid.New(), Roles: []string{"user"}}
+	session := models.Session{Id: uuid.New(), UserId: user.Id, RefreshTokenId: uuid.New()}
+
+	issue := func(t *testing.T, tm *TokenManager, refreshTTL time.Duration) (string, string) {
+		t.Helper()
+		access, refresh, err := tm.NewTokens(user, session, time.Minute, refreshTTL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return access, refresh
+	}
+
+	tests := []struct {
+		name string
+		// token returns the token to parse with tm.
+		token   func(t *testing.T, tm *TokenManager) string
+		wantErr bool
+	}{
+		{
+			name: "refresh token",
+			token: func(t *testing.T, tm *TokenManager) string {
+				_, refresh := issue(t, tm, time.Hour)
+				return refresh
+			},
+		},
+		{
+			name: "access token",
+			token: func(t *testing.T, tm *TokenManager) string {
+				access, _ := issue(t, tm, time.Hour)
+				return access
+			},
+			wantErr: true,
+		},
+		{
+			name: "challenge token",
+			token: func(t *testing.T, tm *TokenManager) string {
+				challenge, err := tm.NewChallengeToken(user.Id, uuid.Nil, models.ChallengeTotp, time.Minute)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return challenge
+			},
+			wantErr: true,
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T, tm *TokenManager) string {
+				_, refresh := issue(t, tm, -time.Minute)
+				return refresh
+			},
+			wantErr: true,
+		},
+		{
+			name: "other issuer",
+			token: func(t *testing.T, tm *TokenManager) string {
+				_, refresh := issue(t, New(tm.keys, "other", "redhub"), time.Hour)
+				return refresh
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown key",
+			token: func(t *testing.T, tm *TokenManager) string {
+				_, refresh := issue(t, newManager(t, "auth"), time.Hour)
+				return refresh
+			},
+			wantErr: true,
+		},
+		{
+			name:    "not a token",
+			token:   func(t *testing.T, tm *TokenManager) string { return "not-a-token" },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := newManager(t, "auth")
+
+			claims, err := tm.ParseRefreshToken(tt.token(t, tm))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("error %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if claims.Uid != user.Id || claims.Sid != session.Id || claims.Jti != session.RefreshTokenId {
+				t.Errorf("claims %+v do not match the session", claims)
+			}
+		})
+	}
+}
+
+func TestParseChallengeToken(t *testing.T) {
+	uid, appId := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name string
+		// token returns the token to parse with tm.
+		token   func(t *testing.T, tm *TokenManager) (string, error)
+		wantErr bool
+	}{
+		{
+			name: "challenge token",
+			token: func(t *testing.T, tm *TokenManager) (string, error) {
+				return tm.NewChallengeToken(uid, appId, models.ChallengeTotp, time.Minute)
+			},
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T, tm *TokenManager) (string, error) {
+				return tm.NewChallengeToken(uid, appId, models.ChallengeTotp, -time.Minute)
+			},
+			wantErr: true,
+		},
+		{
+			name: "refresh token",
+			token: func(t *testing.T, tm *TokenManager) (string, error) {
+				_, refresh, err := tm.NewTokens(models.User{Id: uid, Roles: []string{"user"}}, models.Session{Id: uuid.New()}, time.Minute, time.Hour)
+				return refresh, err
+			},
+			wantErr: true,
+		},
+		{
+			name: "access token",
+			token: func(t *testing.T, tm *TokenManager) (string, error) {
+				access, _, err := tm.NewTokens(models.User{Id: uid, Roles: []string{"user"}}, models.Session{Id: uuid.New()}, time.Minute, time.Hour)
+				return access, err
+			},
+			wantErr: true,
+		},
+		{
+			name: "other issuer",
+			token: func(t *testing.T, tm *TokenManager) (string, error) {
+				return New(tm.keys, "other", "redhub").NewChallengeToken(uid, appId, models.ChallengeTotp, time.Minute)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := newManager(t, "auth")
+			token, err := tt.token(t, tm)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims, err := tm.ParseChallengeToken(token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("error %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if claims.Uid != uid || claims.AppId != appId || claims.Purpose != models.ChallengeTotp {
+				t.Errorf("claims %+v, want %v of app %v and %q", claims, uid, appId, models.ChallengeTotp)
+			}
+		})
+	}
+}
+
+func TestParseAccessToken(t *testing.T) {
+	user := models.User{Id: uuid.New(), Roles: []string{"user"}}
+	session := models.Session{Id: uuid.New(), AppId: uuid.New()}
+
+	tests := []struct {
+		name string
+		// token returns the token to parse with tm.
+		token   func(t *testing.T, tm *TokenManager) (string, error)
+		wantErr bool
+	}{
+		{
+			name: "access token",
+			token: func(t *testing.T, tm *TokenManager) (string, error) {
+				access, _, err := tm.NewTokens(user, session, time.Minute, time.Hour)
+				return access, err
+			},
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T, tm *TokenManager) (string, error) {
+				access, _, err := tm.NewTokens(user, session, -time.Minute, time.Hour)
+				return access, err
+			},
+			wantErr: true,
+		},
+		{
+			name: "refresh token",
+			token: func(t *testing.T, tm *TokenManager) (string, error) {
+				_, refresh, err := tm.NewTokens(user, session, time.Minute, time.Hour)
+				return refresh, err
+			},
+			wantErr: true,
+		},
+		{
+			name: "challenge token",
+			token: func(t *testing.T, tm *TokenManager) (string, error) {
+				return tm.NewChallengeToken(user.Id, uuid.Nil, models.ChallengeTotp, time.Minute)
+			},
+			wantErr: true,
+		},
+		{
+			name: "other issuer",
+			token: func(t *testing.T, tm *TokenManager) (string, error) {
+				access, _, err := New(tm.keys, "other", "redhub").NewTokens(user, session, time.Minute, time.Hour)
+				return access, err
+			},
+			wantErr: true,
+		},
+		{
+			name: "other audience",
+			token: func(t *testing.T, tm *TokenManager) (string, error) {
+				access, _, err := New(tm.keys, "auth", "other").NewTokens(user, session, time.Minute, time.Hour)
+				return access, err
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := newManager(t, "auth")
+			token, err := tt.token(t, tm)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims, err := tm.ParseAccessToken(token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("error %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if claims.Uid != user.Id || claims.Sid != session.Id || claims.AppId != session.AppId {
+				t.Errorf("claims %+v, want user %v, session %v of app %v", claims, user.Id, session.Id, session.AppId)
+			}
+			if len(claims.Permissions) == 0 {
+				t.Error("claims carry no permissions")
+			}
+		})
+	}
+}
+
+func TestTokenAudience(t *testing.T) {
+	tm := newManager(t, "auth")
+	access, refresh, err := tm.NewTokens(models.User{Id: uuid.New(), Roles: []string{"user"}}, models.Session{Id: uuid.New()}, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge, err := tm.NewChallengeToken(uuid.New(), uuid.Nil, models.ChallengeTotp, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		wantAud  string
+		wantType any
+	}{
+		{name: "access token", token: access, wantAud: "redhub"},
+		{name: "refresh token", token: refresh, wantAud: "auth", wantType: refreshTokenType},
+		{name: "challenge token", token: challenge, wantAud: "auth", wantType: challengeTokenType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims jwt.MapClaims
+			if _, _, err := jwt.NewParser().ParseUnverified(tt.token, &claims); err != nil {
+				t.Fatal(err)
+			}
+
+			aud, err := claims.GetAudience()
+			if err != nil || len(aud) != 1 || aud[0] != tt.wantAud {
+				t.Errorf("audience %v, want %q", aud, tt.wantAud)
+			}
+			if claims["typ"] != tt.wantType {
+				t.Errorf("type %v, want %v", claims["typ"], tt.wantType)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package keyset
+
+import (
+	"auth/internal/domain/models"
+	"auth/pkg/lib/logger/sl"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const keyFileExt = ".pem"
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+type Key struct {
+	Id        string
+	Private   ed25519.PrivateKey
+	CreatedAt time.Time
+}
+
+// KeySet holds the Ed25519 keys tokens are signed with. The newest key signs,
+// older ones are kept for verification until every token they signed has
+// expired. Keys are stored as PKCS #8 PEM files so they survive restarts.
+type KeySet struct {
+	log            *slog.Logger
+	dir            string
+	rotationPeriod time.Duration
+	retention      time.Duration
+
+	mu   sync.RWMutex
+	keys []Key
+}
+
+func New(log *slog.Logger, dir string, rotationPeriod time.Duration, retention time.Duration) (*KeySet, error) {
+	const op = "keyset.New"
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ks := &KeySet{
+		log:            log,
+		dir:            dir,
+		rotationPeriod: rotationPeriod,
+		retention:      retention,
+	}
+
+	if err := ks.load(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(ks.keys) == 0 || time.Since(ks.keys[len(ks.keys)-1].CreatedAt) >= rotationPeriod {
+		if err := ks.Rotate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return ks, nil
+}
+
+// RunRotation rotates the signing key every rotation period until ctx is done.
+func (ks *KeySet) RunRotation(ctx context.Context) {
+	const op = "keyset.RunRotation"
+	log := ks.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(ks.rotationPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ks.Rotate(); err != nil {
+				log.Error("failed to rotate signing key", sl.Err(err))
+			}
+		}
+	}
+}
+
+// Rotate generates a new signing key and drops keys that can no longer have
+// valid tokens.
+func (ks *KeySet) Rotate() error {
+	const op = "keyset.Rotate"
+	log := ks.log.With(slog.String("op", op))
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	key := Key{
+		Id:        thumbprint(private.Public().(ed25519.PublicKey)),
+		Private:   private,
+		CreatedAt: time.Now(),
+	}
+
+	if err := ks.save(key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ks.mu.Lock()
+	ks.keys = append(ks.keys, key)
+	ks.mu.Unlock()
+
+	ks.prune()
+
+	log.Info("signing key rotated", slog.String("kid", key.Id))
+	return nil
+}
+
+// SigningKey returns the key new tokens must be signed with.
+func (ks *KeySet) SigningKey() Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return ks.keys[len(ks.keys)-1]
+}
+
+// PublicKey returns the verification key with the given id.
+func (ks *KeySet) PublicKey(kid string) (ed25519.PublicKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	for _, key := range ks.keys {
+		if key.Id == kid {
+			return key.Private.Public().(ed25519.PublicKey), nil
+		}
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// JWKS returns every key still accepted for verification.
+func (ks *KeySet) JWKS() []models.Jwk {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	jwks := make([]models.Jwk, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		jwks = append(jwks, models.Jwk{
+			Kid: key.Id,
+			Kty: "OKP",
+			Crv: "Ed25519",
+			Alg: "EdDSA",
+			Use: "sig",
+			X:   base64.RawURLEncoding.EncodeToString(key.Private.Public().(ed25519.PublicKey)),
+		})
+	}
+
+	return jwks
+}
+
+func (ks *KeySet) load() error {
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != keyFileExt {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(filepath.Join(ks.dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return fmt.Errorf("%s: not a PEM file", entry.Name())
+		}
+
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		private, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return fmt.Errorf("%s: not an Ed25519 key", entry.Name())
+		}
+
+		ks.keys = append(ks.keys, Key{
+			Id:        strings.TrimSuffix(entry.Name(), keyFileExt),
+			Private:   private,
+			CreatedAt: info.ModTime(),
+		})
+	}
+
+	sort.Slice(ks.keys, func(i, j int) bool {
+		return ks.keys[i].CreatedAt.Before(ks.keys[j].CreatedAt)
+	})
+	ks.prune()
+
+	return nil
+}
+
+func (ks *KeySet) save(key Key) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(ks.dir, key.Id+keyFileExt)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// prune removes keys retired more than the retention period ago. A key is
+// retired when the next one is created.
+func (ks *KeySet) prune() {
+	const op = "keyset.prune"
+	log := ks.log.With(slog.String("op", op))
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if len(ks.keys) == 0 {
+		return
+	}
+
+	kept := ks.keys[:0]
+	for i, key := range ks.keys {
+		isActive := i == len(ks.keys)-1
+		if !isActive && time.Since(ks.keys[i+1].CreatedAt) > ks.retention {
+			if err := os.Remove(filepath.Join(ks.dir, key.Id+keyFileExt)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Warn("failed to remove expired key", slog.String("kid", key.Id), sl.Err(err))
+			}
+			continue
+		}
+		kept = append(kept, key)
+	}
+	ks.keys = kept
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint used as the key id.
+func thumbprint(public ed25519.PublicKey) string {
+	canonical := fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, base64.RawURLEncoding.EncodeToString(public))
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
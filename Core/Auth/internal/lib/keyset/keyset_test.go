@@ -0,0 +1,105 @@
+package keyset
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newKeySet(t *testing.T, dir string, retention time.Duration) *KeySet {
+	t.Helper()
+
+	ks, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)), dir, time.Hour, retention)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func TestRotate(t *testing.T) {
+	tests := []struct {
+		name         string
+		retention    time.Duration
+		wantRetained bool
+	}{
+		{name: "retired key kept for verification", retention: time.Hour, wantRetained: true},
+		{name: "retired key dropped after retention", retention: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			ks := newKeySet(t, dir, tt.retention)
+			retired := ks.SigningKey()
+
+			if err := ks.Rotate(); err != nil {
+				t.Fatal(err)
+			}
+
+			if ks.SigningKey().Id == retired.Id {
+				t.Fatal("signing key did not change")
+			}
+			if _, err := ks.PublicKey(ks.SigningKey().Id); err != nil {
+				t.Errorf("new key: %v", err)
+			}
+
+			_, err := ks.PublicKey(retired.Id)
+			_, statErr := os.Stat(filepath.Join(dir, retired.Id+keyFileExt))
+			if tt.wantRetained {
+				if err != nil || statErr != nil || len(ks.JWKS()) != 2 {
+					t.Errorf("retired key dropped: %v, %v, %d keys published", err, statErr, len(ks.JWKS()))
+				}
+				return
+			}
+			if !errors.Is(err, ErrUnknownKey) || !errors.Is(statErr, os.ErrNotExist) || len(ks.JWKS()) != 1 {
+				t.Errorf("retired key kept: %v, %v, %d keys published", err, statErr, len(ks.JWKS()))
+			}
+		})
+	}
+}
+
+func TestNewLoadsKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		age         time.Duration
+		wantRotated bool
+	}{
+		{name: "fresh key", age: time.Minute},
+		{name: "key older than the rotation period", age: 2 * time.Hour, wantRotated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			saved := newKeySet(t, dir, time.Hour).SigningKey()
+
+			created := time.Now().Add(-tt.age)
+			if err := os.Chtimes(filepath.Join(dir, saved.Id+keyFileExt), created, created); err != nil {
+				t.Fatal(err)
+			}
+
+			ks := newKeySet(t, dir, time.Hour)
+			if rotated := ks.SigningKey().Id != saved.Id; rotated != tt.wantRotated {
+				t.Errorf("rotated %v, want %v", rotated, tt.wantRotated)
+			}
+			if _, err := ks.PublicKey(saved.Id); err != nil {
+				t.Errorf("saved key: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewRejectsMalformedKeys(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken"+keyFileExt), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)), dir, time.Hour, time.Hour); err == nil {
+		t.Error("New loaded a malformed key file")
+	}
+}
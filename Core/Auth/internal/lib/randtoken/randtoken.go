@@ -0,0 +1,28 @@
+package randtoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const tokenBytes = 32
+
+// New returns a random URL-safe token and the hash it should be stored as.
+func New() (token string, hash string, err error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, Hash(token), nil
+}
+
+// Hash returns the hex encoded SHA-256 of token. Tokens carry enough entropy
+// that a fast hash is sufficient.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,92 @@
+package rbac
+
+import (
+	"auth/internal/domain/models"
+	"slices"
+)
+
+// Roles is the role catalog. A user may hold several roles; what they are
+// allowed to do is the union of the permissions of all of them.
+var Roles = []models.Role{
+	{
+		Name: models.RoleUser,
+		Permissions: []string{
+			models.PermArticlesCreate,
+			models.PermCommentsCreate,
+			models.PermFavoritesManage,
+		},
+	},
+	{
+		Name:        models.RoleAnalyst,
+		Inherits:    []string{models.RoleUser},
+		Permissions: []string{models.PermStatsRead},
+	},
+	{
+		Name:     models.RoleModerator,
+		Inherits: []string{models.RoleUser},
+		Permissions: []string{
+			models.PermCommentsModerate,
+			models.PermModerationManage,
+		},
+	},
+	{
+		Name:        models.RoleArticleAdmin,
+		Inherits:    []string{models.RoleModerator},
+		Permissions: []string{models.PermArticlesModerate},
+	},
+	{
+		Name:     models.RoleUserAdmin,
+		Inherits: []string{models.RoleUser},
+		Permissions: []string{
+			models.PermUsersManage,
+			models.PermAppsManage,
+		},
+	},
+	{
+		Name:     models.RoleAdmin,
+		Inherits: []string{models.RoleAnalyst, models.RoleArticleAdmin, models.RoleUserAdmin},
+	},
+}
+
+// Known reports whether role is in the catalog.
+func Known(role string) bool {
+	_, ok := find(role)
+	return ok
+}
+
+// Permissions returns the sorted set of permissions granted by roles,
+// following inheritance. Unknown roles grant nothing.
+func Permissions(roles []string) []string {
+	perms := make([]string, 0)
+	seen := make(map[string]bool)
+
+	var walk func(name string)
+	walk = func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+
+		role, ok := find(name)
+		if !ok {
+			return
+		}
+		perms = append(perms, role.Permissions...)
+		for _, parent := range role.Inherits {
+			walk(parent)
+		}
+	}
+	for _, role := range roles {
+		walk(role)
+	}
+
+	return slices.Compact(slices.Sorted(slices.Values(perms)))
+}
+
+func find(name string) (models.Role, bool) {
+	i := slices.IndexFunc(Roles, func(r models.Role) bool { return r.Name == name })
+	if i < 0 {
+		return models.Role{}, false
+	}
+	return Roles[i], true
+}
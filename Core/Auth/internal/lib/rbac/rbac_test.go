@@ -0,0 +1,74 @@
+package rbac
+
+import (
+	"auth/internal/domain/models"
+	"slices"
+	"testing"
+)
+
+func TestPermissions(t *testing.T) {
+	user := []string{models.PermArticlesCreate, models.PermCommentsCreate, models.PermFavoritesManage}
+	moderator := []string{models.PermCommentsModerate, models.PermModerationManage}
+
+	tests := []struct {
+		name  string
+		roles []string
+		want  []string
+	}{
+		{name: "no roles", roles: nil, want: []string{}},
+		{name: "user", roles: []string{models.RoleUser}, want: user},
+		{name: "analyst inherits user", roles: []string{models.RoleAnalyst}, want: append([]string{models.PermStatsRead}, user...)},
+		{name: "moderator inherits user", roles: []string{models.RoleModerator}, want: append(slices.Clone(moderator), user...)},
+		{
+			name:  "article admin inherits moderator",
+			roles: []string{models.RoleArticleAdmin},
+			want:  append(append([]string{models.PermArticlesModerate}, moderator...), user...),
+		},
+		{
+			name:  "roles add up",
+			roles: []string{models.RoleAnalyst, models.RoleUserAdmin},
+			want:  append([]string{models.PermStatsRead, models.PermUsersManage, models.PermAppsManage}, user...),
+		},
+		{name: "unknown role", roles: []string{"root"}, want: []string{}},
+		{name: "unknown role next to a known one", roles: []string{"root", models.RoleUser}, want: user},
+		{name: "duplicates", roles: []string{models.RoleUser, models.RoleUser, models.RoleAnalyst}, want: append([]string{models.PermStatsRead}, user...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := slices.Sorted(slices.Values(tt.want))
+			if got := Permissions(tt.roles); !slices.Equal(got, want) {
+				t.Errorf("Permissions(%v) = %v, want %v", tt.roles, got, want)
+			}
+		})
+	}
+}
+
+func TestAdminHoldsEveryPermission(t *testing.T) {
+	var all []string
+	for _, role := range Roles {
+		all = append(all, role.Permissions...)
+	}
+	all = slices.Compact(slices.Sorted(slices.Values(all)))
+
+	if got := Permissions([]string{models.RoleAdmin}); !slices.Equal(got, all) {
+		t.Errorf("admin holds %v, want %v", got, all)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	for _, role := range Roles {
+		if !Known(role.Name) {
+			t.Errorf("role %q is not known", role.Name)
+		}
+		for _, parent := range role.Inherits {
+			if !Known(parent) {
+				t.Errorf("role %q inherits unknown role %q", role.Name, parent)
+			}
+		}
+	}
+
+	if Known("root") {
+		t.Error("unknown role reported as known")
+	}
+}
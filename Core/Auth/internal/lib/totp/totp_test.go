@@ -0,0 +1,103 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors, base32 encoded.
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestValidate(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+	step := at.Unix() / period
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		at       time.Time
+		wantOk   bool
+		wantStep int64
+	}{
+		// RFC 6238 appendix B, truncated to 6 digits.
+		{name: "rfc vector 59", secret: rfcSecret, code: "287082", at: time.Unix(59, 0), wantOk: true, wantStep: 1},
+		{name: "rfc vector 1111111109", secret: rfcSecret, code: "081804", at: at, wantOk: true, wantStep: step},
+		{name: "rfc vector 2000000000", secret: rfcSecret, code: "279037", at: time.Unix(2000000000, 0), wantOk: true, wantStep: 2000000000 / period},
+		{name: "lower case secret", secret: strings.ToLower(rfcSecret), code: "081804", at: at, wantOk: true, wantStep: step},
+		{name: "code of the previous period", secret: rfcSecret, code: "081804", at: at.Add(period * time.Second), wantOk: true, wantStep: step},
+		{name: "code of the next period", secret: rfcSecret, code: "081804", at: at.Add(-period * time.Second), wantOk: true, wantStep: step},
+		{name: "code two periods old", secret: rfcSecret, code: "081804", at: at.Add(2 * period * time.Second)},
+		{name: "wrong code", secret: rfcSecret, code: "081805", at: at},
+		{name: "too short", secret: rfcSecret, code: "08180", at: at},
+		{name: "too long", secret: rfcSecret, code: "0818040", at: at},
+		{name: "invalid secret", secret: "not base32!", code: "081804", at: at},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(tt.secret, tt.code, tt.at)
+			if ok != tt.wantOk || step != tt.wantStep {
+				t.Errorf("Validate = %d, %v; want %d, %v", step, ok, tt.wantStep, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil || len(key) != secretSize {
+		t.Fatalf("secret %q decodes to %d bytes (%v), want %d", secret, len(key), err, secretSize)
+	}
+
+	if _, ok := Validate(secret, hotp(key, time.Now().Unix()/period), time.Now()); !ok {
+		t.Error("current code of a generated secret is rejected")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := NewRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("%d codes, want 10", len(codes))
+	}
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' || code != strings.ToLower(code) {
+			t.Errorf("code %q is not formatted as xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q issued twice", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{code: "abcde-fghij", want: "abcdefghij"},
+		{code: "ABCDE-FGHIJ", want: "abcdefghij"},
+		{code: " abcde fghij ", want: "abcdefghij"},
+		{code: "abcdefghij", want: "abcdefghij"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := NormalizeRecoveryCode(tt.code); got != tt.want {
+				t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package filemailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Mailer appends messages to a file instead of sending them, for local runs
+// where no SMTP server is available. An empty path only logs the message.
+type Mailer struct {
+	log  *slog.Logger
+	path string
+	mu   sync.Mutex
+}
+
+func New(log *slog.Logger, path string) *Mailer {
+	return &Mailer{
+		log:  log,
+		path: path,
+	}
+}
+
+// Send implements interfaces.Mailer.
+func (m *Mailer) Send(ctx context.Context, to string, subject string, body string) error {
+	const op = "mailer.file.send"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.log.With(slog.String("op", op)).Info("mail",
+		slog.String("to", to),
+		slog.String("subject", subject),
+		slog.String("body", body),
+	)
+
+	if m.path == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z), to, subject, body); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package smtpmailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+type Mailer struct {
+	log  *slog.Logger
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func New(log *slog.Logger, host string, port int, username string, password string, from string) *Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &Mailer{
+		log:  log,
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth: auth,
+		from: from,
+	}
+}
+
+// Send implements interfaces.Mailer. STARTTLS is used whenever the server
+// offers it.
+func (m *Mailer) Send(ctx context.Context, to string, subject string, body string) error {
+	const op = "mailer.smtp.send"
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return fmt.Errorf("%s: header contains a line break", op)
+	}
+
+	msg := "From: " + m.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.log.With(slog.String("op", op)).Info("mail sent", slog.String("subject", subject))
+	return nil
+}
@@ -0,0 +1,248 @@
+package authservice
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/lib/randtoken"
+	"auth/internal/storage"
+	"auth/pkg/lib/logger/sl"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAppNotFound        = errors.New("app not found")
+	ErrInvalidRedirectUri = errors.New("invalid redirect uri")
+)
+
+// CreateApp implements interfaces.Auth. The secret is returned in plain
+// text only here and by RotateAppSecret.
+func (a AuthService) CreateApp(ctx context.Context, name string, redirectUris []string, scopes []string) (string, models.App, error) {
+	const op = "service.auth.createApp"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("name", name),
+	)
+
+	select {
+	case <-ctx.Done():
+		return "", models.App{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(models.Scopes, scope) {
+			log.Warn("unknown scope", slog.String("scope", scope))
+			return "", models.App{}, fmt.Errorf("%s: %w: %s", op, ErrInvalidScope, scope)
+		}
+	}
+	scopes = slices.Compact(slices.Sorted(slices.Values(scopes)))
+
+	for _, redirectUri := range redirectUris {
+		if !validRedirectUri(redirectUri) {
+			log.Warn("invalid redirect uri", slog.String("redirect_uri", redirectUri))
+			return "", models.App{}, fmt.Errorf("%s: %w: %s", op, ErrInvalidRedirectUri, redirectUri)
+		}
+	}
+
+	secret, hash, err := randtoken.New()
+	if err != nil {
+		log.Error("failed to generate secret", sl.Err(err))
+		return "", models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	app := models.App{
+		Id:              uuid.New(),
+		Name:            name,
+		SecretHash:      hash,
+		RedirectUris:    redirectUris,
+		Scopes:          scopes,
+		CreatedAt:       now,
+		SecretRotatedAt: now,
+	}
+	if err := a.appsstorage.Insert(ctx, app); err != nil {
+		log.Error("failed to save app", sl.Err(err))
+		return "", models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("app created", slog.String("app_id", app.Id.String()))
+	return secret, app, nil
+}
+
+// GetApp implements interfaces.Auth.
+func (a AuthService) GetApp(ctx context.Context, id uuid.UUID) (models.App, error) {
+	const op = "service.auth.getApp"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("app_id", id.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.App{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	app, err := a.appsstorage.GetById(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return models.App{}, fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+
+		log.Error("failed to get app", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return app, nil
+}
+
+// ListApps implements interfaces.Auth.
+func (a AuthService) ListApps(ctx context.Context) ([]models.App, error) {
+	const op = "service.auth.listApps"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	apps, err := a.appsstorage.GetAll(ctx)
+	if err != nil {
+		log.Error("failed to get apps", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return apps, nil
+}
+
+// RotateAppSecret implements interfaces.Auth. The old secret stops
+// working immediately; sessions already opened through the app are kept.
+func (a AuthService) RotateAppSecret(ctx context.Context, id uuid.UUID) (string, models.App, error) {
+	const op = "service.auth.rotateAppSecret"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("app_id", id.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return "", models.App{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	secret, hash, err := randtoken.New()
+	if err != nil {
+		log.Error("failed to generate secret", sl.Err(err))
+		return "", models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app, err := a.appsstorage.UpdateSecret(ctx, id, hash, time.Now())
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found")
+			return "", models.App{}, fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+
+		log.Error("failed to update app secret", sl.Err(err))
+		return "", models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("app secret rotated")
+	return secret, app, nil
+}
+
+// RevokeApp implements interfaces.Auth. A revoked app is kept disabled
+// rather than deleted, so tokens that name it keep being rejected.
+func (a AuthService) RevokeApp(ctx context.Context, id uuid.UUID) error {
+	const op = "service.auth.revokeApp"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("app_id", id.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if _, err := a.appsstorage.Disable(ctx, id); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found")
+			return fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+
+		log.Error("failed to disable app", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("app revoked")
+	return nil
+}
+
+// authenticateApp checks the app a login is made through. Logins without an
+// app are allowed unless AppsConfig.Required is set.
+func (a AuthService) authenticateApp(ctx context.Context, credentials models.AppCredentials) error {
+	if credentials.Id == uuid.Nil {
+		if a.apps.Required {
+			return ErrInvalidAppID
+		}
+		return nil
+	}
+
+	app, err := a.appsstorage.GetById(ctx, credentials.Id)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return ErrInvalidAppID
+		}
+		return err
+	}
+
+	if app.Disabled || subtle.ConstantTimeCompare([]byte(app.SecretHash), []byte(randtoken.Hash(credentials.Secret))) != 1 {
+		return ErrInvalidAppID
+	}
+
+	return nil
+}
+
+// checkAppActive reports ErrInvalidAppID if the app a session or challenge
+// was started through has since been revoked.
+func (a AuthService) checkAppActive(ctx context.Context, id uuid.UUID) error {
+	if id == uuid.Nil {
+		return nil
+	}
+
+	app, err := a.appsstorage.GetById(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return ErrInvalidAppID
+		}
+		return err
+	}
+
+	if app.Disabled {
+		return ErrInvalidAppID
+	}
+
+	return nil
+}
+
+func validRedirectUri(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "https" || u.Scheme == "http") && u.Host != "" && u.Fragment == ""
+}
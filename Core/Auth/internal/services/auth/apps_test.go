@@ -0,0 +1,160 @@
+package authservice
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateApp(t *testing.T) {
+	tests := []struct {
+		name         string
+		redirectUris []string
+		scopes       []string
+		wantErr      error
+	}{
+		{name: "valid", redirectUris: []string{"https://app.example.com/callback", "http://localhost:3000/cb"}, scopes: []string{models.ScopeStatsRead}},
+		{name: "no redirect uris"},
+		{name: "relative redirect uri", redirectUris: []string{"/callback"}, wantErr: ErrInvalidRedirectUri},
+		{name: "other scheme", redirectUris: []string{"ftp://app.example.com/callback"}, wantErr: ErrInvalidRedirectUri},
+		{name: "fragment", redirectUris: []string{"https://app.example.com/callback#token"}, wantErr: ErrInvalidRedirectUri},
+		{name: "unknown scope", scopes: []string{"admin"}, wantErr: ErrInvalidScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+
+			secret, app, err := e.auth.CreateApp(context.Background(), "app", tt.redirectUris, tt.scopes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if secret == "" || app.SecretHash == secret {
+				t.Error("secret is not stored as its hash")
+			}
+		})
+	}
+}
+
+func TestLoginThroughApp(t *testing.T) {
+	tests := []struct {
+		name     string
+		required bool
+		// credentials returns the app credentials to log in with, given a
+		// registered app and its secret.
+		credentials func(t *testing.T, e *testEnv, app models.App, secret string) models.AppCredentials
+		wantErr     error
+	}{
+		{
+			name: "without an app",
+			credentials: func(t *testing.T, e *testEnv, app models.App, secret string) models.AppCredentials {
+				return models.AppCredentials{}
+			},
+		},
+		{
+			name:     "without an app when one is required",
+			required: true,
+			credentials: func(t *testing.T, e *testEnv, app models.App, secret string) models.AppCredentials {
+				return models.AppCredentials{}
+			},
+			wantErr: ErrInvalidAppID,
+		},
+		{
+			name:     "right secret",
+			required: true,
+			credentials: func(t *testing.T, e *testEnv, app models.App, secret string) models.AppCredentials {
+				return models.AppCredentials{Id: app.Id, Secret: secret}
+			},
+		},
+		{
+			name: "wrong secret",
+			credentials: func(t *testing.T, e *testEnv, app models.App, secret string) models.AppCredentials {
+				return models.AppCredentials{Id: app.Id, Secret: "wrong"}
+			},
+			wantErr: ErrInvalidAppID,
+		},
+		{
+			name: "unknown app",
+			credentials: func(t *testing.T, e *testEnv, app models.App, secret string) models.AppCredentials {
+				return models.AppCredentials{Id: uuid.New(), Secret: secret}
+			},
+			wantErr: ErrInvalidAppID,
+		},
+		{
+			name: "secret before rotation",
+			credentials: func(t *testing.T, e *testEnv, app models.App, secret string) models.AppCredentials {
+				if _, _, err := e.auth.RotateAppSecret(context.Background(), app.Id); err != nil {
+					t.Fatal(err)
+				}
+				return models.AppCredentials{Id: app.Id, Secret: secret}
+			},
+			wantErr: ErrInvalidAppID,
+		},
+		{
+			name: "secret after rotation",
+			credentials: func(t *testing.T, e *testEnv, app models.App, secret string) models.AppCredentials {
+				rotated, _, err := e.auth.RotateAppSecret(context.Background(), app.Id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return models.AppCredentials{Id: app.Id, Secret: rotated}
+			},
+		},
+		{
+			name: "revoked app",
+			credentials: func(t *testing.T, e *testEnv, app models.App, secret string) models.AppCredentials {
+				if err := e.auth.RevokeApp(context.Background(), app.Id); err != nil {
+					t.Fatal(err)
+				}
+				return models.AppCredentials{Id: app.Id, Secret: secret}
+			},
+			wantErr: ErrInvalidAppID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.auth.apps.Required = tt.required
+
+			secret, app, err := e.auth.CreateApp(context.Background(), "app", nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			credentials := tt.credentials(t, e, app, secret)
+			_, err = e.auth.Login(context.Background(), testEmail, testPassword, credentials, models.Device{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Login: %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRevokeAppEndsSessions(t *testing.T) {
+	e := newTestEnv(t)
+	ctx := context.Background()
+
+	secret, app, err := e.auth.CreateApp(ctx, "app", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := e.auth.Login(ctx, testEmail, testPassword, models.AppCredentials{Id: app.Id, Secret: secret}, models.Device{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.auth.RevokeApp(ctx, app.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := e.auth.Refresh(ctx, result.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Refresh after revoking the app: %v, want ErrInvalidToken", err)
+	}
+}
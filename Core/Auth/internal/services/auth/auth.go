@@ -4,30 +4,78 @@ import (
 	"auth/internal/domain/interfaces"
 	"auth/internal/domain/models"
 	"auth/internal/lib/jwt"
+	"auth/internal/lib/randtoken"
+	"auth/internal/lib/totp"
 	"auth/internal/storage"
+	"auth/pkg/config"
 	"auth/pkg/lib/logger/sl"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type AuthService struct {
-	log             *slog.Logger
-	usersstorage    interfaces.UsersStorage
-	accessTokenTTL  time.Duration
-	refreshTokenTTL time.Duration
+	log                  *slog.Logger
+	usersstorage         interfaces.UsersStorage
+	sessionsstorage      interfaces.SessionsStorage
+	tokensstorage        interfaces.OneTimeTokensStorage
+	totpstorage          interfaces.TotpStorage
+	attemptsstorage      interfaces.LoginAttemptsStorage
+	patstorage           interfaces.PersonalAccessTokensStorage
+	appsstorage          interfaces.AppsStorage
+	mailer               interfaces.Mailer
+	tokens               *jwt.TokenManager
+	accessTokenTTL       time.Duration
+	refreshTokenTTL      time.Duration
+	emailVerificationTTL time.Duration
+	passwordReset        config.PasswordResetConfig
+	totp                 config.TotpConfig
+	lockout              config.LockoutConfig
+	pat                  config.PersonalAccessTokensConfig
+	apps                 config.AppsConfig
+	publicURL            string
 }
 
-func New(log *slog.Logger, usersStorage interfaces.UsersStorage, accessTokenTTL time.Duration, refreshTokenTTL time.Duration) *AuthService {
+func New(
+	log *slog.Logger,
+	usersStorage interfaces.UsersStorage,
+	sessionsStorage interfaces.SessionsStorage,
+	tokensStorage interfaces.OneTimeTokensStorage,
+	totpStorage interfaces.TotpStorage,
+	attemptsStorage interfaces.LoginAttemptsStorage,
+	patStorage interfaces.PersonalAccessTokensStorage,
+	appsStorage interfaces.AppsStorage,
+	mailer interfaces.Mailer,
+	tokens *jwt.TokenManager,
+	cfg *config.Config,
+) *AuthService {
 	return &AuthService{
-		log:             log,
-		usersstorage:    usersStorage,
-		accessTokenTTL:  accessTokenTTL,
-		refreshTokenTTL: refreshTokenTTL,
+		log:                  log,
+		usersstorage:         usersStorage,
+		sessionsstorage:      sessionsStorage,
+		tokensstorage:        tokensStorage,
+		totpstorage:          totpStorage,
+		attemptsstorage:      attemptsStorage,
+		patstorage:           patStorage,
+		appsstorage:          appsStorage,
+		mailer:               mailer,
+		tokens:               tokens,
+		accessTokenTTL:       cfg.AccessTokenTTL,
+		refreshTokenTTL:      cfg.RefreshTokenTTL,
+		emailVerificationTTL: cfg.EmailVerificationTTL,
+		passwordReset:        cfg.PasswordReset,
+		totp:                 cfg.Totp,
+		lockout:              cfg.Lockout,
+		pat:                  cfg.PersonalAccessTokens,
+		apps:                 cfg.Apps,
+		publicURL:            strings.TrimRight(cfg.PublicURL, "/"),
 	}
 }
 
@@ -35,10 +83,22 @@ var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInvalidAppID       = errors.New("invalid app id")
 	ErrUserExists         = errors.New("user already exists")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrTokenReused        = errors.New("refresh token reused")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrEmailNotVerified   = errors.New("email is not verified")
+	ErrTotpAlreadyEnabled = errors.New("totp is already enabled")
+	ErrTotpNotEnrolled    = errors.New("totp is not set up")
+	ErrTotpRequired       = errors.New("totp is required for this role")
+	ErrInvalidTotpCode    = errors.New("invalid totp code")
 )
 
-// Login implements interfaces.Auth.
-func (a AuthService) Login(ctx context.Context, email string, password string) (string, string, error) {
+// Login implements interfaces.Auth. Users with TOTP enabled, and users the
+// policy requires to enroll, get a challenge token instead of tokens and
+// finish the login with VerifyTotpLogin. Failed attempts are counted per
+// account and per client address, see LockoutConfig. The tokens, and the
+// challenge, are bound to the app the login is made through.
+func (a AuthService) Login(ctx context.Context, email string, password string, app models.AppCredentials, device models.Device) (models.LoginResult, error) {
 	const op = "service.auth.login"
 	log := a.log.With(
 		slog.String("op", op),
@@ -49,31 +109,387 @@ func (a AuthService) Login(ctx context.Context, email string, password string) (
 
 	select {
 	case <-ctx.Done():
-		return "", "", fmt.Errorf("%s: %w", op, ctx.Err())
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	user, err := a.usersstorage.GetUserByEmail(ctx, email)
+	if err := a.authenticateApp(ctx, app); err != nil {
+		if errors.Is(err, ErrInvalidAppID) {
+			log.Warn("app rejected", slog.String("app_id", app.Id.String()))
+			return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Error("failed to check app", sl.Err(err))
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	attemptKeys := a.attemptKeys(email, device.Ip)
+	if err := a.checkLockout(ctx, attemptKeys); err != nil {
+		if errors.Is(err, ErrTooManyAttempts) {
+			log.Warn("login is locked out")
+			return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Error("failed to check login attempts", sl.Err(err))
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The password is checked by UsersManageService, the user comes back
+	// without it.
+	user, err := a.usersstorage.VerifyCredentials(ctx, email, password)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidCredentials) {
+			log.Warn("invalid credentials")
+			a.registerFailure(ctx, attemptKeys)
+			return models.LoginResult{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}
+
+		log.Error("failed to verify credentials", sl.Err(err))
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("credentials verified", slog.String("uid", user.Id.String()))
+
+	if !user.EmailVerified {
+		log.Warn("email is not verified")
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, ErrEmailNotVerified)
+	}
+
+	challenge := ""
+	userTotp, err := a.totpstorage.GetByUserId(ctx, user.Id)
+	switch {
+	case err == nil && userTotp.Enabled:
+		challenge = models.ChallengeTotp
+	case err != nil && !errors.Is(err, storage.ErrTotpNotFound):
+		log.Error("failed to get totp", sl.Err(err))
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	case a.totpRequired(user.Roles):
+		challenge = models.ChallengeTotpEnrollment
+	}
+
+	if challenge != "" {
+		challengeToken, err := a.tokens.NewChallengeToken(user.Id, app.Id, challenge, a.totp.ChallengeTTL)
+		if err != nil {
+			log.Error("failed to generate challenge token", sl.Err(err))
+			return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Info("second factor required", slog.String("challenge", challenge))
+		return models.LoginResult{
+			ChallengeToken: challengeToken,
+			ChallengeType:  challenge,
+		}, nil
+	}
+
+	// Only a complete login clears the counter, a right password alone
+	// must not give more tries at the second factor.
+	a.resetAttempts(ctx, email)
+
+	accessToken, refreshToken, err := a.startSession(ctx, user, app.Id, device)
+	if err != nil {
+		log.Error("failed to start session", sl.Err(err))
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.LoginResult{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// VerifyTotpLogin implements interfaces.Auth. It finishes a login started by
+// Login: a "totp" challenge is answered with a code or a recovery code, a
+// "totp_enrollment" challenge with the first code of the secret created by
+// BeginTotpEnrollmentWithChallenge, which also enables it.
+func (a AuthService) VerifyTotpLogin(ctx context.Context, challengeToken string, code string, device models.Device) (models.LoginResult, error) {
+	const op = "service.auth.verifyTotpLogin"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	claims, err := a.tokens.ParseChallengeToken(challengeToken)
+	if err != nil {
+		log.Warn("failed to parse challenge token", sl.Err(err))
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+	log = log.With(slog.String("uid", claims.Uid.String()))
+
+	if err := a.checkAppActive(ctx, claims.AppId); err != nil {
+		if errors.Is(err, ErrInvalidAppID) {
+			log.Warn("app was revoked", slog.String("app_id", claims.AppId.String()))
+			return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Error("failed to check app", sl.Err(err))
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := a.usersstorage.GetUserById(ctx, claims.Uid)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			log.Warn("user not found")
-			return "", "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return models.LoginResult{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
 		}
+
+		log.Error("failed to get user by id", sl.Err(err))
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	attemptKeys := a.attemptKeys(user.Email, device.Ip)
+	if err := a.checkLockout(ctx, attemptKeys); err != nil {
+		if errors.Is(err, ErrTooManyAttempts) {
+			log.Warn("login is locked out")
+			return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Error("failed to check login attempts", sl.Err(err))
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
 	}
-	log.Info("fetched user:", slog.Any("user", user))
 
-	if user.Password != password {
-		log.Warn("user not found")
-		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	userTotp, err := a.totpstorage.GetByUserId(ctx, user.Id)
+	if err != nil && !errors.Is(err, storage.ErrTotpNotFound) {
+		log.Error("failed to get totp", sl.Err(err))
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	accessToken, refreshToken, err := jwt.NewTokens(user, a.accessTokenTTL, a.refreshTokenTTL)
+	var result models.LoginResult
+	switch {
+	case userTotp.Enabled:
+		if err := a.checkSecondFactor(ctx, userTotp, code); err != nil {
+			if errors.Is(err, ErrInvalidTotpCode) {
+				a.registerFailure(ctx, attemptKeys)
+			}
+			log.Warn("second factor rejected", sl.Err(err))
+			return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+	case claims.Purpose == models.ChallengeTotpEnrollment:
+		recoveryCodes, err := a.ConfirmTotpEnrollment(ctx, user.Id, code)
+		if err != nil {
+			if errors.Is(err, ErrInvalidTotpCode) {
+				a.registerFailure(ctx, attemptKeys)
+			}
+			log.Warn("enrollment not confirmed", sl.Err(err))
+			return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		result.RecoveryCodes = recoveryCodes
+	default:
+		// TOTP was turned off after the challenge was issued.
+		log.Warn("totp is not enabled")
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	a.resetAttempts(ctx, user.Email)
+
+	result.AccessToken, result.RefreshToken, err = a.startSession(ctx, user, claims.AppId, device)
+	if err != nil {
+		log.Error("failed to start session", sl.Err(err))
+		return models.LoginResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("second factor accepted")
+	return result, nil
+}
+
+// startSession saves a new session for user, opened through appId, and
+// issues its tokens.
+func (a AuthService) startSession(ctx context.Context, user models.User, appId uuid.UUID, device models.Device) (string, string, error) {
+	now := time.Now()
+	session, err := a.sessionsstorage.Insert(ctx, models.Session{
+		Id:             uuid.New(),
+		UserId:         user.Id,
+		RefreshTokenId: uuid.New(),
+		UserAgent:      device.UserAgent,
+		Ip:             device.Ip,
+		CreatedAt:      now,
+		LastUsedAt:     now,
+		ExpiresAt:      now.Add(a.refreshTokenTTL),
+		AppId:          appId,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return a.tokens.NewTokens(user, session, a.accessTokenTTL, a.refreshTokenTTL)
+}
+
+// Refresh implements interfaces.Auth.
+func (a AuthService) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	const op = "service.auth.refresh"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return "", "", fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	claims, err := a.tokens.ParseRefreshToken(refreshToken)
+	if err != nil {
+		log.Warn("failed to parse refresh token", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+	log = log.With(slog.String("sid", claims.Sid.String()))
+
+	session, err := a.sessionsstorage.Rotate(ctx, claims.Sid, claims.Jti, uuid.New(), time.Now().Add(a.refreshTokenTTL))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrTokenMismatch):
+			log.Warn("refresh token reuse detected, revoking session")
+			if err := a.sessionsstorage.Revoke(ctx, claims.Sid); err != nil {
+				log.Error("failed to revoke session", sl.Err(err))
+			}
+			return "", "", fmt.Errorf("%s: %w", op, ErrTokenReused)
+		case errors.Is(err, storage.ErrSessionNotFound), errors.Is(err, storage.ErrSessionRevoked):
+			log.Warn("session is not active", sl.Err(err))
+			return "", "", fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		log.Error("failed to rotate refresh token", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.checkAppActive(ctx, session.AppId); err != nil {
+		if errors.Is(err, ErrInvalidAppID) {
+			log.Warn("app was revoked, revoking session", slog.String("app_id", session.AppId.String()))
+			if err := a.sessionsstorage.Revoke(ctx, session.Id); err != nil {
+				log.Error("failed to revoke session", sl.Err(err))
+			}
+			return "", "", fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		log.Error("failed to check app", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := a.usersstorage.GetUserById(ctx, session.UserId)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("session owner not found, revoking session")
+			if err := a.sessionsstorage.Revoke(ctx, session.Id); err != nil {
+				log.Error("failed to revoke session", sl.Err(err))
+			}
+			return "", "", fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		log.Error("failed to get user by id", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	accessToken, newRefreshToken, err := a.tokens.NewTokens(user, session, a.accessTokenTTL, a.refreshTokenTTL)
 	if err != nil {
 		log.Error("failed to generate token", sl.Err(err))
 		return "", "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	return accessToken, refreshToken, nil
+	return accessToken, newRefreshToken, nil
+}
+
+// Logout implements interfaces.Auth.
+func (a AuthService) Logout(ctx context.Context, uid uuid.UUID, sid uuid.UUID) error {
+	const op = "service.auth.logout"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("uid", uid.String()),
+		slog.String("sid", sid.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	session, err := a.sessionsstorage.GetSessionById(ctx, sid)
+	if err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			log.Warn("session not found")
+			return fmt.Errorf("%s: %w", op, ErrSessionNotFound)
+		}
+
+		log.Error("failed to get session by id", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Someone else's session is reported as missing so session ids cannot be probed.
+	if session.UserId != uid {
+		log.Warn("session belongs to another user")
+		return fmt.Errorf("%s: %w", op, ErrSessionNotFound)
+	}
+
+	if err := a.sessionsstorage.Revoke(ctx, sid); err != nil {
+		log.Error("failed to revoke session", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("session revoked")
+	return nil
+}
+
+// LogoutAll implements interfaces.Auth.
+func (a AuthService) LogoutAll(ctx context.Context, uid uuid.UUID) (int64, error) {
+	const op = "service.auth.logoutAll"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("uid", uid.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	revoked, err := a.sessionsstorage.RevokeAllByUserId(ctx, uid)
+	if err != nil {
+		log.Error("failed to revoke sessions", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("sessions revoked", slog.Int64("count", revoked))
+	return revoked, nil
+}
+
+// GetSessions implements interfaces.Auth.
+func (a AuthService) GetSessions(ctx context.Context, uid uuid.UUID) ([]models.Session, error) {
+	const op = "service.auth.getSessions"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("uid", uid.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	sessions, err := a.sessionsstorage.GetActiveSessionsByUserId(ctx, uid)
+	if err != nil {
+		log.Error("failed to get sessions", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+// GetJWKS implements interfaces.Auth.
+func (a AuthService) GetJWKS(ctx context.Context) ([]models.Jwk, error) {
+	const op = "service.auth.getJWKS"
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	return a.tokens.JWKS(), nil
 }
 
 // Register implements interfaces.Auth.
@@ -90,6 +506,12 @@ func (a AuthService) Register(ctx context.Context, user models.User) (models.Use
 	default:
 	}
 
+	// UsersManageService hashes the password itself.
+	user.EmailVerified = false
+	// Roles are granted by user admins; whatever the caller asked for,
+	// a new account starts as a plain user.
+	user.Roles = []string{models.RoleUser}
+
 	user, err := a.usersstorage.Insert(ctx, user)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserExists) {
@@ -101,9 +523,599 @@ func (a AuthService) Register(ctx context.Context, user models.User) (models.Use
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	// The account exists either way; a lost email can be requested again.
+	if err := a.sendVerificationEmail(ctx, user); err != nil {
+		log.Error("failed to send verification email", sl.Err(err))
+	}
+
 	return user, nil
 }
 
+// VerifyEmail implements interfaces.Auth.
+func (a AuthService) VerifyEmail(ctx context.Context, token string) error {
+	const op = "service.auth.verifyEmail"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	oneTimeToken, err := a.tokensstorage.Consume(ctx, randtoken.Hash(token), models.PurposeEmailVerification)
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			log.Warn("verification token not found")
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		log.Error("failed to consume verification token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.String("uid", oneTimeToken.UserId.String()))
+
+	if time.Now().After(oneTimeToken.ExpiresAt) {
+		log.Warn("verification token expired")
+		return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	if _, err := a.usersstorage.SetEmailVerified(ctx, oneTimeToken.UserId, true); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		log.Error("failed to mark email as verified", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("email verified")
+	return nil
+}
+
+// ResendVerificationEmail implements interfaces.Auth. Unknown and already
+// verified addresses are silently ignored so the result does not reveal
+// which emails are registered.
+func (a AuthService) ResendVerificationEmail(ctx context.Context, email string) error {
+	const op = "service.auth.resendVerificationEmail"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, err := a.usersstorage.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return nil
+		}
+
+		log.Error("failed to get user by email", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	if err := a.sendVerificationEmail(ctx, user); err != nil {
+		log.Error("failed to send verification email", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RequestPasswordReset implements interfaces.Auth. Like
+// ResendVerificationEmail it reports success for unknown emails, and for
+// accounts that have hit the reset limit.
+func (a AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	const op = "service.auth.requestPasswordReset"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, err := a.usersstorage.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return nil
+		}
+
+		log.Error("failed to get user by email", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.String("uid", user.Id.String()))
+
+	sent, err := a.tokensstorage.CountByUserIdSince(ctx, user.Id, models.PurposePasswordReset, time.Now().Add(-a.passwordReset.Window))
+	if err != nil {
+		log.Error("failed to count reset tokens", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if sent >= a.passwordReset.Limit {
+		log.Warn("password reset limit reached")
+		return nil
+	}
+
+	token, hash, err := randtoken.New()
+	if err != nil {
+		log.Error("failed to generate reset token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	if err := a.tokensstorage.Insert(ctx, models.OneTimeToken{
+		Hash:      hash,
+		UserId:    user.Id,
+		Purpose:   models.PurposePasswordReset,
+		CreatedAt: now,
+		ExpiresAt: now.Add(a.passwordReset.TTL),
+	}); err != nil {
+		log.Error("failed to save reset token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	link := a.publicURL + "/password-reset?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Hello, %s!\n\nSomeone asked to reset the password of your account. If it was you, open the link below to choose a new one:\n\n%s\n\nThe link is valid for %s. If you did not ask for a reset, ignore this email.\n", user.Nick, link, a.passwordReset.TTL)
+
+	if err := a.mailer.Send(ctx, user.Email, "Reset your password", body); err != nil {
+		log.Error("failed to send reset email", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("password reset requested")
+	return nil
+}
+
+// ConfirmPasswordReset implements interfaces.Auth. On success every other
+// reset token of the user is dropped and all sessions are revoked.
+func (a AuthService) ConfirmPasswordReset(ctx context.Context, token string, newPassword string) error {
+	const op = "service.auth.confirmPasswordReset"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	oneTimeToken, err := a.tokensstorage.Consume(ctx, randtoken.Hash(token), models.PurposePasswordReset)
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			log.Warn("reset token not found")
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		log.Error("failed to consume reset token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.String("uid", oneTimeToken.UserId.String()))
+
+	if time.Now().After(oneTimeToken.ExpiresAt) {
+		log.Warn("reset token expired")
+		return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	user, err := a.usersstorage.GetUserById(ctx, oneTimeToken.UserId)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		log.Error("failed to get user by id", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	user.Password = newPassword
+
+	if _, err := a.usersstorage.Update(ctx, user.Id, user); err != nil {
+		log.Error("failed to save new password", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The reset link reached the mailbox, which proves the address as well.
+	if !user.EmailVerified {
+		if _, err := a.usersstorage.SetEmailVerified(ctx, user.Id, true); err != nil {
+			log.Error("failed to mark email as verified", sl.Err(err))
+		}
+	}
+
+	if err := a.tokensstorage.DeleteByUserId(ctx, user.Id, models.PurposePasswordReset); err != nil {
+		log.Error("failed to delete reset tokens", sl.Err(err))
+	}
+
+	revoked, err := a.sessionsstorage.RevokeAllByUserId(ctx, user.Id)
+	if err != nil {
+		log.Error("failed to revoke sessions", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("password reset", slog.Int64("revoked_sessions", revoked))
+	return nil
+}
+
+// ChangePassword implements interfaces.Auth. The current password must be
+// given; every session but sid is revoked afterwards.
+func (a AuthService) ChangePassword(ctx context.Context, uid uuid.UUID, sid uuid.UUID, currentPassword string, newPassword string) (int64, error) {
+	const op = "service.auth.changePassword"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("uid", uid.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, err := a.usersstorage.GetUserById(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Error("failed to get user by id", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	owner, err := a.usersstorage.VerifyCredentials(ctx, user.Email, currentPassword)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidCredentials) {
+			log.Warn("invalid current password")
+			return 0, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}
+
+		log.Error("failed to verify current password", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if owner.Id != uid {
+		log.Warn("credentials belong to another user")
+		return 0, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	user.Password = newPassword
+
+	if _, err := a.usersstorage.Update(ctx, user.Id, user); err != nil {
+		log.Error("failed to save new password", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sessions, err := a.sessionsstorage.GetActiveSessionsByUserId(ctx, uid)
+	if err != nil {
+		log.Error("failed to get sessions", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var revoked int64
+	for _, session := range sessions {
+		if session.Id == sid {
+			continue
+		}
+		if err := a.sessionsstorage.Revoke(ctx, session.Id); err != nil {
+			log.Error("failed to revoke session", slog.String("sid", session.Id.String()), sl.Err(err))
+			continue
+		}
+		revoked++
+	}
+
+	log.Info("password changed", slog.Int64("revoked_sessions", revoked))
+	return revoked, nil
+}
+
+// BeginTotpEnrollment implements interfaces.Auth. It creates a new secret
+// for the user, replacing any enrollment that was never confirmed.
+func (a AuthService) BeginTotpEnrollment(ctx context.Context, uid uuid.UUID) (string, string, error) {
+	const op = "service.auth.beginTotpEnrollment"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("uid", uid.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return "", "", fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, err := a.usersstorage.GetUserById(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return "", "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Error("failed to get user by id", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Error("failed to generate totp secret", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.totpstorage.SavePending(ctx, models.Totp{
+		UserId:    uid,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		if errors.Is(err, storage.ErrTotpEnabled) {
+			log.Warn("totp is already enabled")
+			return "", "", fmt.Errorf("%s: %w", op, ErrTotpAlreadyEnabled)
+		}
+
+		log.Error("failed to save totp secret", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp enrollment started")
+	return secret, totp.URI(a.totp.Issuer, user.Email, secret), nil
+}
+
+// BeginTotpEnrollmentWithChallenge implements interfaces.Auth. It is the
+// way in for users who must enroll before their first login can finish.
+func (a AuthService) BeginTotpEnrollmentWithChallenge(ctx context.Context, challengeToken string) (string, string, error) {
+	const op = "service.auth.beginTotpEnrollmentWithChallenge"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := a.tokens.ParseChallengeToken(challengeToken)
+	if err != nil || claims.Purpose != models.ChallengeTotpEnrollment {
+		log.Warn("invalid enrollment challenge", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	secret, uri, err := a.BeginTotpEnrollment(ctx, claims.Uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return "", "", fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return secret, uri, nil
+}
+
+// ConfirmTotpEnrollment implements interfaces.Auth. A valid code enables the
+// pending secret and the recovery codes are returned in plain text, once.
+func (a AuthService) ConfirmTotpEnrollment(ctx context.Context, uid uuid.UUID, code string) ([]string, error) {
+	const op = "service.auth.confirmTotpEnrollment"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("uid", uid.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	userTotp, err := a.totpstorage.GetByUserId(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrTotpNotFound) {
+			log.Warn("totp enrollment not started")
+			return nil, fmt.Errorf("%s: %w", op, ErrTotpNotEnrolled)
+		}
+
+		log.Error("failed to get totp", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if userTotp.Enabled {
+		log.Warn("totp is already enabled")
+		return nil, fmt.Errorf("%s: %w", op, ErrTotpAlreadyEnabled)
+	}
+
+	step, ok := totp.Validate(userTotp.Secret, code, time.Now())
+	if !ok {
+		log.Warn("invalid totp code")
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidTotpCode)
+	}
+
+	recoveryCodes, err := totp.NewRecoveryCodes(a.totp.RecoveryCodes)
+	if err != nil {
+		log.Error("failed to generate recovery codes", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hashes := make([]string, 0, len(recoveryCodes))
+	for _, recoveryCode := range recoveryCodes {
+		hashes = append(hashes, randtoken.Hash(totp.NormalizeRecoveryCode(recoveryCode)))
+	}
+
+	if err := a.totpstorage.Enable(ctx, uid, step, hashes); err != nil {
+		if errors.Is(err, storage.ErrTotpNotFound) {
+			log.Warn("totp enrollment was replaced or confirmed concurrently")
+			return nil, fmt.Errorf("%s: %w", op, ErrTotpNotEnrolled)
+		}
+
+		log.Error("failed to enable totp", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp enabled")
+	return recoveryCodes, nil
+}
+
+// DisableTotp implements interfaces.Auth. Turning TOTP off takes a current
+// code or a recovery code, and is refused where the policy requires it.
+func (a AuthService) DisableTotp(ctx context.Context, uid uuid.UUID, code string) error {
+	const op = "service.auth.disableTotp"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("uid", uid.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, err := a.usersstorage.GetUserById(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Error("failed to get user by id", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if a.totpRequired(user.Roles) {
+		log.Warn("totp is required for role", slog.Any("roles", user.Roles))
+		return fmt.Errorf("%s: %w", op, ErrTotpRequired)
+	}
+
+	userTotp, err := a.totpstorage.GetByUserId(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrTotpNotFound) {
+			log.Warn("totp is not set up")
+			return fmt.Errorf("%s: %w", op, ErrTotpNotEnrolled)
+		}
+
+		log.Error("failed to get totp", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// A pending secret protects nothing yet, so it can go without a code.
+	if userTotp.Enabled {
+		if err := a.checkSecondFactor(ctx, userTotp, code); err != nil {
+			log.Warn("second factor rejected", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := a.totpstorage.Delete(ctx, uid); err != nil {
+		log.Error("failed to delete totp", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp disabled")
+	return nil
+}
+
+// checkSecondFactor accepts either a TOTP code of an enabled secret, at most
+// once per time step, or one of the user's unused recovery codes.
+func (a AuthService) checkSecondFactor(ctx context.Context, userTotp models.Totp, code string) error {
+	if step, ok := totp.Validate(userTotp.Secret, code, time.Now()); ok {
+		if err := a.totpstorage.UseStep(ctx, userTotp.UserId, step); err != nil {
+			if errors.Is(err, storage.ErrTotpStepUsed) {
+				return ErrInvalidTotpCode
+			}
+			return err
+		}
+		return nil
+	}
+
+	err := a.totpstorage.ConsumeRecoveryCode(ctx, userTotp.UserId, randtoken.Hash(totp.NormalizeRecoveryCode(code)))
+	if err != nil {
+		if errors.Is(err, storage.ErrRecoveryCodeNotFound) {
+			return ErrInvalidTotpCode
+		}
+		return err
+	}
+
+	a.log.Info("recovery code used", slog.String("uid", userTotp.UserId.String()))
+	return nil
+}
+
+// totpRequired reports whether the policy makes TOTP mandatory for a user
+// holding roles.
+func (a AuthService) totpRequired(roles []string) bool {
+	if !a.totp.RequireForPrivileged {
+		return false
+	}
+	return slices.ContainsFunc(roles, func(role string) bool {
+		return slices.Contains(a.totp.PrivilegedRoles, role)
+	})
+}
+
+// sendVerificationEmail replaces any pending verification token of the user
+// with a new one and mails the link to it.
+func (a AuthService) sendVerificationEmail(ctx context.Context, user models.User) error {
+	if err := a.tokensstorage.DeleteByUserId(ctx, user.Id, models.PurposeEmailVerification); err != nil {
+		return err
+	}
+
+	token, hash, err := randtoken.New()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := a.tokensstorage.Insert(ctx, models.OneTimeToken{
+		Hash:      hash,
+		UserId:    user.Id,
+		Purpose:   models.PurposeEmailVerification,
+		CreatedAt: now,
+		ExpiresAt: now.Add(a.emailVerificationTTL),
+	}); err != nil {
+		return err
+	}
+
+	link := a.publicURL + "/api/v1/verify-email?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Hello, %s!\n\nConfirm your email address by opening the link below:\n\n%s\n\nThe link is valid for %s.\n", user.Nick, link, a.emailVerificationTTL)
+
+	return a.mailer.Send(ctx, user.Email, "Confirm your email", body)
+}
+
+// UnlockAccount implements interfaces.Auth. It lifts the lockout of the
+// account and forgets its failed attempts; address lockouts stay in place.
+func (a AuthService) UnlockAccount(ctx context.Context, uid uuid.UUID) error {
+	const op = "service.auth.unlockAccount"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("uid", uid.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, err := a.usersstorage.GetUserById(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Error("failed to get user by id", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.attemptsstorage.Reset(ctx, accountAttemptKey(user.Email)); err != nil {
+		log.Error("failed to reset login attempts", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("account unlocked")
+	return nil
+}
+
 // IsAdmin implements interfaces.Auth.
 func (a AuthService) IsAdmin(ctx context.Context, user_id uuid.UUID) (bool, error) {
 	const op = "service.auth.isAdmin"
@@ -128,9 +1140,5 @@ func (a AuthService) IsAdmin(ctx context.Context, user_id uuid.UUID) (bool, erro
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
 
-	if user.Role == "admin" {
-		return true, nil
-	} else {
-		return false, nil
-	}
+	return slices.Contains(user.Roles, models.RoleAdmin), nil
 }
@@ -0,0 +1,250 @@
+package authservice
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/lib/jwt"
+	"auth/internal/lib/keyset"
+	filemailer "auth/internal/mailer/file"
+	mockapps "auth/internal/storage/mock/apps"
+	mockattempts "auth/internal/storage/mock/attempts"
+	mockpersonalaccesstokens "auth/internal/storage/mock/personalAccessTokens"
+	mocksessions "auth/internal/storage/mock/sessions"
+	mocktokens "auth/internal/storage/mock/tokens"
+	mocktotp "auth/internal/storage/mock/totp"
+	mockusers "auth/internal/storage/mock/users"
+	"auth/pkg/config"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	testEmail    = "user@example.com"
+	testPassword = "correct horse"
+)
+
+// testEnv is an AuthService over mock storages with one verified user.
+type testEnv struct {
+	auth AuthService
+	user models.User
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	keys, err := keyset.New(log, t.TempDir(), time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+		Lockout: config.LockoutConfig{
+			AccountThreshold: 3,
+			IpThreshold:      5,
+			BaseDelay:        time.Minute,
+			MaxDelay:         4 * time.Minute,
+			Window:           time.Hour,
+		},
+		PersonalAccessTokens: config.PersonalAccessTokensConfig{
+			DefaultTTL: time.Hour,
+			MaxTTL:     24 * time.Hour,
+			MaxPerUser: 2,
+		},
+		Totp: config.TotpConfig{
+			Issuer:        "Redhub",
+			ChallengeTTL:  time.Minute,
+			RecoveryCodes: 2,
+		},
+	}
+
+	users := mockusers.New()
+	user, err := users.Insert(context.Background(), models.User{
+		Id:            uuid.New(),
+		Email:         testEmail,
+		Password:      testPassword,
+		Roles:         []string{"user"},
+		Nick:          "user",
+		EmailVerified: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mailer := filemailer.New(log, filepath.Join(t.TempDir(), "mail.log"))
+	auth := *New(log, users, mocksessions.New(), mocktokens.New(), mocktotp.New(), mockattempts.New(),
+		mockpersonalaccesstokens.New(), mockapps.New(), mailer, jwt.New(keys, "auth", "redhub"), cfg)
+
+	return &testEnv{auth: auth, user: user}
+}
+
+// login signs the test user in and returns its refresh token.
+func (e *testEnv) login(t *testing.T) string {
+	t.Helper()
+
+	result, err := e.auth.Login(context.Background(), testEmail, testPassword, models.AppCredentials{}, models.Device{Ip: "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return result.RefreshToken
+}
+
+func TestRefresh(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare returns the refresh token to present, given the one the
+		// login issued.
+		prepare func(t *testing.T, e *testEnv, issued string) string
+		wantErr error
+	}{
+		{
+			name:    "issued token",
+			prepare: func(t *testing.T, e *testEnv, issued string) string { return issued },
+		},
+		{
+			name: "rotated token",
+			prepare: func(t *testing.T, e *testEnv, issued string) string {
+				_, next, err := e.auth.Refresh(context.Background(), issued)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return next
+			},
+		},
+		{
+			name: "token used twice",
+			prepare: func(t *testing.T, e *testEnv, issued string) string {
+				if _, _, err := e.auth.Refresh(context.Background(), issued); err != nil {
+					t.Fatal(err)
+				}
+				return issued
+			},
+			wantErr: ErrTokenReused,
+		},
+		{
+			name: "successor of a reused token",
+			prepare: func(t *testing.T, e *testEnv, issued string) string {
+				_, next, err := e.auth.Refresh(context.Background(), issued)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, _, err := e.auth.Refresh(context.Background(), issued); !errors.Is(err, ErrTokenReused) {
+					t.Fatalf("reuse: %v, want ErrTokenReused", err)
+				}
+				return next
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "logged out session",
+			prepare: func(t *testing.T, e *testEnv, issued string) string {
+				claims, err := e.auth.tokens.ParseRefreshToken(issued)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := e.auth.Logout(context.Background(), e.user.Id, claims.Sid); err != nil {
+					t.Fatal(err)
+				}
+				return issued
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "access token",
+			prepare: func(t *testing.T, e *testEnv, issued string) string {
+				access, _, err := e.auth.Refresh(context.Background(), issued)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return access
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "not a token",
+			prepare: func(t *testing.T, e *testEnv, issued string) string { return "not-a-token" },
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			token := tt.prepare(t, e, e.login(t))
+
+			access, next, err := e.auth.Refresh(context.Background(), token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Refresh: %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if access == "" || next == "" || next == token {
+				t.Errorf("Refresh did not issue a new pair of tokens")
+			}
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     string
+		wantErr     error
+		wantRevoked int64
+	}{
+		{name: "current password", current: testPassword, wantRevoked: 2},
+		{name: "wrong current password", current: "wrong", wantErr: ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+
+			var sids []uuid.UUID
+			for range 3 {
+				claims, err := e.auth.tokens.ParseRefreshToken(e.login(t))
+				if err != nil {
+					t.Fatal(err)
+				}
+				sids = append(sids, claims.Sid)
+			}
+
+			revoked, err := e.auth.ChangePassword(context.Background(), e.user.Id, sids[0], tt.current, "new password")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if revoked != tt.wantRevoked {
+				t.Errorf("revoked %d sessions, want %d", revoked, tt.wantRevoked)
+			}
+
+			newPasswordWorks := tt.wantErr == nil
+			_, err = e.auth.Login(context.Background(), testEmail, "new password", models.AppCredentials{}, models.Device{Ip: "192.0.2.1"})
+			if (err == nil) != newPasswordWorks {
+				t.Errorf("login with new password: %v", err)
+			}
+
+			sessions, err := e.auth.GetSessions(context.Background(), e.user.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			active := make(map[uuid.UUID]bool)
+			for _, session := range sessions {
+				active[session.Id] = true
+			}
+			for i, sid := range sids {
+				if want := i == 0 || tt.wantErr != nil; active[sid] != want {
+					t.Errorf("session %d active %t, want %t", i, active[sid], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package authservice
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/lib/rbac"
+	"auth/internal/storage"
+	"auth/pkg/lib/logger/sl"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Introspect implements interfaces.Auth. It accepts access tokens and
+// personal access tokens. Unlike a local check of the signature it honours
+// revocation: a token of a logged out session, of a revoked personal access
+// token or issued through a revoked app is inactive. Only failures to look
+// the token up are returned as errors.
+func (a AuthService) Introspect(ctx context.Context, token string) (models.Introspection, error) {
+	const op = "service.auth.introspect"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.Introspection{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if strings.HasPrefix(token, models.PersonalAccessTokenPrefix) {
+		pat, user, err := a.VerifyPersonalAccessToken(ctx, token)
+		if err != nil {
+			if errors.Is(err, ErrInvalidToken) {
+				return models.Introspection{}, nil
+			}
+
+			log.Error("failed to verify personal access token", sl.Err(err))
+			return models.Introspection{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return models.Introspection{
+			Active:      true,
+			Subject:     user.Id,
+			Roles:       user.Roles,
+			Permissions: rbac.Permissions(user.Roles),
+			ExpiresAt:   pat.ExpiresAt,
+			TokenId:     pat.Id,
+			Scopes:      pat.Scopes,
+		}, nil
+	}
+
+	claims, err := a.tokens.ParseAccessToken(token)
+	if err != nil {
+		log.Warn("failed to parse access token", sl.Err(err))
+		return models.Introspection{}, nil
+	}
+	log = log.With(slog.String("sid", claims.Sid.String()))
+
+	session, err := a.sessionsstorage.GetSessionById(ctx, claims.Sid)
+	if err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			log.Warn("session not found")
+			return models.Introspection{}, nil
+		}
+
+		log.Error("failed to get session", sl.Err(err))
+		return models.Introspection{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if session.Revoked || session.UserId != claims.Uid || time.Now().After(session.ExpiresAt) {
+		log.Warn("session is no longer active")
+		return models.Introspection{}, nil
+	}
+
+	if claims.AppId != session.AppId {
+		log.Warn("token app does not match session")
+		return models.Introspection{}, nil
+	}
+
+	var scopes []string
+	if claims.AppId != uuid.Nil {
+		app, err := a.appsstorage.GetById(ctx, claims.AppId)
+		if err != nil {
+			if errors.Is(err, storage.ErrAppNotFound) {
+				log.Warn("app not found")
+				return models.Introspection{}, nil
+			}
+
+			log.Error("failed to get app", sl.Err(err))
+			return models.Introspection{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if app.Disabled {
+			log.Warn("app is revoked")
+			return models.Introspection{}, nil
+		}
+		scopes = app.Scopes
+	}
+
+	return models.Introspection{
+		Active:      true,
+		Subject:     claims.Uid,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		ExpiresAt:   claims.Exp,
+		SessionId:   claims.Sid,
+		AppId:       claims.AppId,
+		Scopes:      scopes,
+	}, nil
+}
@@ -0,0 +1,144 @@
+package authservice
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestIntrospect(t *testing.T) {
+	tests := []struct {
+		name string
+		// token returns the token to introspect.
+		token      func(t *testing.T, e *testEnv) string
+		wantActive bool
+		wantScopes []string
+	}{
+		{
+			name: "access token",
+			token: func(t *testing.T, e *testEnv) string {
+				result, err := e.auth.Login(context.Background(), testEmail, testPassword, models.AppCredentials{}, models.Device{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return result.AccessToken
+			},
+			wantActive: true,
+		},
+		{
+			name: "access token of a logged out session",
+			token: func(t *testing.T, e *testEnv) string {
+				result, err := e.auth.Login(context.Background(), testEmail, testPassword, models.AppCredentials{}, models.Device{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := e.auth.LogoutAll(context.Background(), e.user.Id); err != nil {
+					t.Fatal(err)
+				}
+				return result.AccessToken
+			},
+		},
+		{
+			name: "access token of an app",
+			token: func(t *testing.T, e *testEnv) string {
+				secret, app, err := e.auth.CreateApp(context.Background(), "app", nil, []string{models.ScopeStatsRead})
+				if err != nil {
+					t.Fatal(err)
+				}
+				result, err := e.auth.Login(context.Background(), testEmail, testPassword, models.AppCredentials{Id: app.Id, Secret: secret}, models.Device{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return result.AccessToken
+			},
+			wantActive: true,
+			wantScopes: []string{models.ScopeStatsRead},
+		},
+		{
+			name: "access token of a revoked app",
+			token: func(t *testing.T, e *testEnv) string {
+				secret, app, err := e.auth.CreateApp(context.Background(), "app", nil, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				result, err := e.auth.Login(context.Background(), testEmail, testPassword, models.AppCredentials{Id: app.Id, Secret: secret}, models.Device{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := e.auth.RevokeApp(context.Background(), app.Id); err != nil {
+					t.Fatal(err)
+				}
+				return result.AccessToken
+			},
+		},
+		{
+			name: "refresh token",
+			token: func(t *testing.T, e *testEnv) string {
+				return e.login(t)
+			},
+		},
+		{
+			name: "personal access token",
+			token: func(t *testing.T, e *testEnv) string {
+				token, _, err := e.auth.CreatePersonalAccessToken(context.Background(), e.user.Id, "ci", []string{models.ScopeStatsRead}, time.Time{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			wantActive: true,
+			wantScopes: []string{models.ScopeStatsRead},
+		},
+		{
+			name: "revoked personal access token",
+			token: func(t *testing.T, e *testEnv) string {
+				token, pat, err := e.auth.CreatePersonalAccessToken(context.Background(), e.user.Id, "ci", nil, time.Time{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := e.auth.RevokePersonalAccessToken(context.Background(), e.user.Id, pat.Id); err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+		},
+		{
+			name:  "not a token",
+			token: func(t *testing.T, e *testEnv) string { return "not-a-token" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+
+			introspection, err := e.auth.Introspect(context.Background(), tt.token(t, e))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if introspection.Active != tt.wantActive {
+				t.Fatalf("active %t, want %t", introspection.Active, tt.wantActive)
+			}
+			if !tt.wantActive {
+				if introspection.Subject != uuid.Nil {
+					t.Errorf("inactive token names subject %v", introspection.Subject)
+				}
+				return
+			}
+
+			if introspection.Subject != e.user.Id {
+				t.Errorf("subject %v, want %v", introspection.Subject, e.user.Id)
+			}
+			if (introspection.SessionId == uuid.Nil) == (introspection.TokenId == uuid.Nil) {
+				t.Errorf("session %v and token %v, want exactly one", introspection.SessionId, introspection.TokenId)
+			}
+			if len(introspection.Scopes) != len(tt.wantScopes) {
+				t.Errorf("scopes %v, want %v", introspection.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
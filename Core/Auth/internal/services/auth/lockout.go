@@ -0,0 +1,124 @@
+package authservice
+
+import (
+	"auth/pkg/lib/logger/sl"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+var ErrTooManyAttempts = errors.New("too many failed login attempts")
+
+// LockoutError is returned while an account or client address is locked
+// out. It matches ErrTooManyAttempts with errors.Is.
+type LockoutError struct {
+	Until time.Time
+}
+
+func (e *LockoutError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *LockoutError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+// RetryAfter is how long the caller has to wait before trying again.
+func (e *LockoutError) RetryAfter() time.Duration {
+	return max(time.Until(e.Until), 0)
+}
+
+type attemptKey struct {
+	key       string
+	threshold int
+}
+
+// attemptKeys lists the counters a login attempt is charged to: the account,
+// addressed by email so unknown emails are throttled the same way, and the
+// client address when it is known.
+func (a AuthService) attemptKeys(email string, ip string) []attemptKey {
+	keys := []attemptKey{{
+		key:       accountAttemptKey(email),
+		threshold: a.lockout.AccountThreshold,
+	}}
+	if ip != "" {
+		keys = append(keys, attemptKey{
+			key:       "ip:" + ip,
+			threshold: a.lockout.IpThreshold,
+		})
+	}
+	return keys
+}
+
+func accountAttemptKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkLockout returns a *LockoutError if any of keys is locked.
+func (a AuthService) checkLockout(ctx context.Context, keys []attemptKey) error {
+	var until time.Time
+	for _, k := range keys {
+		attempts, err := a.attemptsstorage.Get(ctx, k.key)
+		if err != nil {
+			return err
+		}
+		if attempts.LockedUntil.After(until) {
+			until = attempts.LockedUntil
+		}
+	}
+
+	if until.After(time.Now()) {
+		return &LockoutError{Until: until}
+	}
+	return nil
+}
+
+// registerFailure counts a failed attempt against keys and locks those that
+// went over their threshold. Storage errors are only logged: they must not
+// turn a wrong password into a different answer.
+func (a AuthService) registerFailure(ctx context.Context, keys []attemptKey) {
+	const op = "service.auth.registerFailure"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	for _, k := range keys {
+		attempts, err := a.attemptsstorage.RegisterFailure(ctx, k.key, a.lockout.Window)
+		if err != nil {
+			log.Error("failed to register failed attempt", sl.Err(err))
+			continue
+		}
+
+		over := attempts.Failures - k.threshold
+		if over < 0 {
+			continue
+		}
+
+		delay := a.lockout.BaseDelay
+		for i := 0; i < over && delay < a.lockout.MaxDelay; i++ {
+			delay *= 2
+		}
+		delay = min(delay, a.lockout.MaxDelay)
+
+		if err := a.attemptsstorage.Lock(ctx, k.key, time.Now().Add(delay)); err != nil {
+			log.Error("failed to lock", sl.Err(err))
+			continue
+		}
+		log.Warn("locked out after failed attempts",
+			slog.String("key", k.key),
+			slog.Int("failures", attempts.Failures),
+			slog.Duration("delay", delay),
+		)
+	}
+}
+
+// resetAttempts clears the account counter after a successful login. The
+// address counter is left alone so one valid account cannot be used to
+// keep guessing others from the same address.
+func (a AuthService) resetAttempts(ctx context.Context, email string) {
+	if err := a.attemptsstorage.Reset(ctx, accountAttemptKey(email)); err != nil {
+		a.log.Error("failed to reset login attempts", sl.Err(err))
+	}
+}
@@ -0,0 +1,127 @@
+package authservice
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLockoutDelay(t *testing.T) {
+	// The test config locks an account after 3 failures for 1m, doubling up
+	// to 4m.
+	tests := []struct {
+		failures  int
+		wantDelay time.Duration
+	}{
+		{failures: 2, wantDelay: 0},
+		{failures: 3, wantDelay: time.Minute},
+		{failures: 4, wantDelay: 2 * time.Minute},
+		{failures: 5, wantDelay: 4 * time.Minute},
+		{failures: 8, wantDelay: 4 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d failures", tt.failures), func(t *testing.T) {
+			e := newTestEnv(t)
+			keys := e.auth.attemptKeys(testEmail, "")
+
+			for range tt.failures {
+				e.auth.registerFailure(context.Background(), keys)
+			}
+
+			err := e.auth.checkLockout(context.Background(), keys)
+			if tt.wantDelay == 0 {
+				if err != nil {
+					t.Fatalf("locked out: %v", err)
+				}
+				return
+			}
+
+			var lockout *LockoutError
+			if !errors.As(err, &lockout) {
+				t.Fatalf("error %v, want a *LockoutError", err)
+			}
+			if got := lockout.RetryAfter(); got > tt.wantDelay || got < tt.wantDelay-time.Second {
+				t.Errorf("retry after %v, want %v", got, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	type attempt struct {
+		email    string
+		password string
+		ip       string
+	}
+	wrong := func(email, ip string) attempt { return attempt{email: email, password: "wrong", ip: ip} }
+	right := func(ip string) attempt { return attempt{email: testEmail, password: testPassword, ip: ip} }
+
+	tests := []struct {
+		name     string
+		attempts []attempt
+		last     attempt
+		wantErr  error
+	}{
+		{
+			name:     "below the account threshold",
+			attempts: []attempt{wrong(testEmail, "192.0.2.1"), wrong(testEmail, "192.0.2.1")},
+			last:     right("192.0.2.1"),
+		},
+		{
+			name:     "account locked",
+			attempts: []attempt{wrong(testEmail, "192.0.2.1"), wrong(testEmail, "192.0.2.2"), wrong(testEmail, "192.0.2.3")},
+			last:     right("192.0.2.4"),
+			wantErr:  ErrTooManyAttempts,
+		},
+		{
+			name:     "email case does not matter",
+			attempts: []attempt{wrong("USER@example.com", "192.0.2.1"), wrong(" user@EXAMPLE.com", "192.0.2.1"), wrong(testEmail, "192.0.2.1")},
+			last:     right("192.0.2.2"),
+			wantErr:  ErrTooManyAttempts,
+		},
+		{
+			name:     "success resets the account counter",
+			attempts: []attempt{wrong(testEmail, "192.0.2.1"), wrong(testEmail, "192.0.2.1"), right("192.0.2.1"), wrong(testEmail, "192.0.2.1"), wrong(testEmail, "192.0.2.1")},
+			last:     right("192.0.2.1"),
+		},
+		{
+			name: "address locked",
+			attempts: []attempt{
+				wrong("a@example.com", "192.0.2.1"), wrong("b@example.com", "192.0.2.1"), wrong("c@example.com", "192.0.2.1"),
+				wrong("d@example.com", "192.0.2.1"), wrong("e@example.com", "192.0.2.1"),
+			},
+			last:    right("192.0.2.1"),
+			wantErr: ErrTooManyAttempts,
+		},
+		{
+			name: "other address",
+			attempts: []attempt{
+				wrong("a@example.com", "192.0.2.1"), wrong("b@example.com", "192.0.2.1"), wrong("c@example.com", "192.0.2.1"),
+				wrong("d@example.com", "192.0.2.1"), wrong("e@example.com", "192.0.2.1"),
+			},
+			last: right("192.0.2.2"),
+		},
+	}
+
+	login := func(e *testEnv, a attempt) error {
+		_, err := e.auth.Login(context.Background(), a.email, a.password, models.AppCredentials{}, models.Device{Ip: a.ip})
+		return err
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			for _, a := range tt.attempts {
+				_ = login(e, a)
+			}
+
+			if err := login(e, tt.last); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Login: %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package authservice
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/lib/randtoken"
+	"auth/internal/storage"
+	"auth/pkg/lib/logger/sl"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// lastUsedPrecision limits how often verifying a token writes its last use
+// time, scripts may call the API many times a minute.
+const lastUsedPrecision = time.Minute
+
+var (
+	ErrInvalidScope                = errors.New("unknown scope")
+	ErrInvalidExpiry               = errors.New("invalid expiry")
+	ErrTooManyPersonalAccessTokens = errors.New("too many personal access tokens")
+	ErrPersonalAccessTokenNotFound = errors.New("personal access token not found")
+)
+
+// CreatePersonalAccessToken implements interfaces.Auth. The token is
+// returned in plain text only here.
+func (a AuthService) CreatePersonalAccessToken(ctx context.Context, uid uuid.UUID, name string, scopes []string, expiresAt time.Time) (string, models.PersonalAccessToken, error) {
+	const op = "service.auth.createPersonalAccessToken"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("uid", uid.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(models.Scopes, scope) {
+			log.Warn("unknown scope", slog.String("scope", scope))
+			return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w: %s", op, ErrInvalidScope, scope)
+		}
+	}
+	scopes = slices.Compact(slices.Sorted(slices.Values(scopes)))
+
+	now := time.Now()
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(a.pat.DefaultTTL)
+	}
+	if !expiresAt.After(now) || expiresAt.After(now.Add(a.pat.MaxTTL)) {
+		log.Warn("expiry out of range", slog.Time("expires_at", expiresAt))
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, ErrInvalidExpiry)
+	}
+
+	if _, err := a.usersstorage.GetUserById(ctx, uid); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Error("failed to get user by id", sl.Err(err))
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	existing, err := a.patstorage.GetByUserId(ctx, uid)
+	if err != nil {
+		log.Error("failed to get personal access tokens", sl.Err(err))
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(existing) >= a.pat.MaxPerUser {
+		log.Warn("personal access token limit reached")
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, ErrTooManyPersonalAccessTokens)
+	}
+
+	secret, _, err := randtoken.New()
+	if err != nil {
+		log.Error("failed to generate token", sl.Err(err))
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+	token := models.PersonalAccessTokenPrefix + secret
+
+	pat := models.PersonalAccessToken{
+		Id:        uuid.New(),
+		UserId:    uid,
+		Name:      name,
+		Hash:      randtoken.Hash(token),
+		Scopes:    scopes,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	if err := a.patstorage.Insert(ctx, pat); err != nil {
+		log.Error("failed to save personal access token", sl.Err(err))
+		return "", models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("personal access token created", slog.String("id", pat.Id.String()))
+	return token, pat, nil
+}
+
+// ListPersonalAccessTokens implements interfaces.Auth.
+func (a AuthService) ListPersonalAccessTokens(ctx context.Context, uid uuid.UUID) ([]models.PersonalAccessToken, error) {
+	const op = "service.auth.listPersonalAccessTokens"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("uid", uid.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	tokens, err := a.patstorage.GetByUserId(ctx, uid)
+	if err != nil {
+		log.Error("failed to get personal access tokens", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// RevokePersonalAccessToken implements interfaces.Auth.
+func (a AuthService) RevokePersonalAccessToken(ctx context.Context, uid uuid.UUID, id uuid.UUID) error {
+	const op = "service.auth.revokePersonalAccessToken"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("uid", uid.String()),
+		slog.String("id", id.String()),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if err := a.patstorage.Delete(ctx, uid, id); err != nil {
+		if errors.Is(err, storage.ErrPersonalAccessTokenNotFound) {
+			log.Warn("personal access token not found")
+			return fmt.Errorf("%s: %w", op, ErrPersonalAccessTokenNotFound)
+		}
+
+		log.Error("failed to delete personal access token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("personal access token revoked")
+	return nil
+}
+
+// VerifyPersonalAccessToken implements interfaces.Auth. It returns the token
+// together with its owner, whose role still applies to what the token can
+// reach.
+func (a AuthService) VerifyPersonalAccessToken(ctx context.Context, token string) (models.PersonalAccessToken, models.User, error) {
+	const op = "service.auth.verifyPersonalAccessToken"
+	log := a.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.PersonalAccessToken{}, models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if !strings.HasPrefix(token, models.PersonalAccessTokenPrefix) {
+		return models.PersonalAccessToken{}, models.User{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	pat, err := a.patstorage.GetByHash(ctx, randtoken.Hash(token))
+	if err != nil {
+		if errors.Is(err, storage.ErrPersonalAccessTokenNotFound) {
+			log.Warn("personal access token not found")
+			return models.PersonalAccessToken{}, models.User{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		log.Error("failed to get personal access token", sl.Err(err))
+		return models.PersonalAccessToken{}, models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.String("id", pat.Id.String()), slog.String("uid", pat.UserId.String()))
+
+	now := time.Now()
+	if now.After(pat.ExpiresAt) {
+		log.Warn("personal access token expired")
+		return models.PersonalAccessToken{}, models.User{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	user, err := a.usersstorage.GetUserById(ctx, pat.UserId)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("token owner not found")
+			return models.PersonalAccessToken{}, models.User{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+
+		log.Error("failed to get user by id", sl.Err(err))
+		return models.PersonalAccessToken{}, models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if now.Sub(pat.LastUsedAt) > lastUsedPrecision {
+		if err := a.patstorage.Touch(ctx, pat.Id, now); err != nil {
+			log.Error("failed to record token use", sl.Err(err))
+		}
+		pat.LastUsedAt = now
+	}
+
+	return pat, user, nil
+}
@@ -0,0 +1,165 @@
+package authservice
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/lib/randtoken"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCreatePersonalAccessToken(t *testing.T) {
+	// The test config allows 2 tokens per user, for 1h by default and 24h at
+	// most.
+	tests := []struct {
+		name       string
+		existing   int
+		scopes     []string
+		expiresIn  time.Duration
+		wantErr    error
+		wantTTL    time.Duration
+		wantScopes []string
+	}{
+		{
+			name:       "default expiry",
+			scopes:     []string{models.ScopeStatsRead, models.ScopeArticlesWrite, models.ScopeStatsRead},
+			wantTTL:    time.Hour,
+			wantScopes: []string{models.ScopeArticlesWrite, models.ScopeStatsRead},
+		},
+		{name: "chosen expiry", expiresIn: 12 * time.Hour, wantTTL: 12 * time.Hour},
+		{name: "expiry in the past", expiresIn: -time.Minute, wantErr: ErrInvalidExpiry},
+		{name: "expiry beyond the limit", expiresIn: 25 * time.Hour, wantErr: ErrInvalidExpiry},
+		{name: "unknown scope", scopes: []string{"admin"}, wantErr: ErrInvalidScope},
+		{name: "too many tokens", existing: 2, wantErr: ErrTooManyPersonalAccessTokens},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			ctx := context.Background()
+
+			for range tt.existing {
+				if _, _, err := e.auth.CreatePersonalAccessToken(ctx, e.user.Id, "existing", nil, time.Time{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var expiresAt time.Time
+			if tt.expiresIn != 0 {
+				expiresAt = time.Now().Add(tt.expiresIn)
+			}
+
+			token, pat, err := e.auth.CreatePersonalAccessToken(ctx, e.user.Id, "script", tt.scopes, expiresAt)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if pat.Hash == token || pat.Hash != randtoken.Hash(token) {
+				t.Error("token is not stored as its hash")
+			}
+			if ttl := time.Until(pat.ExpiresAt); ttl > tt.wantTTL || ttl < tt.wantTTL-time.Second {
+				t.Errorf("expires in %v, want %v", ttl, tt.wantTTL)
+			}
+			if !slices.Equal(pat.Scopes, tt.wantScopes) {
+				t.Errorf("scopes %v, want %v", pat.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestVerifyPersonalAccessToken(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare returns the token to verify, given a valid one.
+		prepare func(t *testing.T, e *testEnv, token string, pat models.PersonalAccessToken) string
+		wantErr error
+	}{
+		{
+			name:    "valid token",
+			prepare: func(t *testing.T, e *testEnv, token string, pat models.PersonalAccessToken) string { return token },
+		},
+		{
+			name: "without the prefix",
+			prepare: func(t *testing.T, e *testEnv, token string, pat models.PersonalAccessToken) string {
+				return token[len(models.PersonalAccessTokenPrefix):]
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "unknown token",
+			prepare: func(t *testing.T, e *testEnv, token string, pat models.PersonalAccessToken) string {
+				return models.PersonalAccessTokenPrefix + "unknown"
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "revoked token",
+			prepare: func(t *testing.T, e *testEnv, token string, pat models.PersonalAccessToken) string {
+				if err := e.auth.RevokePersonalAccessToken(context.Background(), e.user.Id, pat.Id); err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "expired token",
+			prepare: func(t *testing.T, e *testEnv, token string, pat models.PersonalAccessToken) string {
+				expired := models.PersonalAccessTokenPrefix + "expired"
+				if err := e.auth.patstorage.Insert(context.Background(), models.PersonalAccessToken{
+					Id:        uuid.New(),
+					UserId:    e.user.Id,
+					Hash:      randtoken.Hash(expired),
+					CreatedAt: time.Now().Add(-2 * time.Hour),
+					ExpiresAt: time.Now().Add(-time.Hour),
+				}); err != nil {
+					t.Fatal(err)
+				}
+				return expired
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "owner deleted",
+			prepare: func(t *testing.T, e *testEnv, token string, pat models.PersonalAccessToken) string {
+				if _, err := e.auth.usersstorage.Delete(context.Background(), e.user.Id); err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			token, pat, err := e.auth.CreatePersonalAccessToken(context.Background(), e.user.Id, "script", []string{models.ScopeStatsRead}, time.Time{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verified, owner, err := e.auth.VerifyPersonalAccessToken(context.Background(), tt.prepare(t, e, token, pat))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if verified.Id != pat.Id || owner.Id != e.user.Id {
+				t.Errorf("verified token %v of %v, want %v of %v", verified.Id, owner.Id, pat.Id, e.user.Id)
+			}
+			if verified.LastUsedAt.IsZero() {
+				t.Error("last use was not recorded")
+			}
+		})
+	}
+}
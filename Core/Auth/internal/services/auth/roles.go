@@ -0,0 +1,22 @@
+package authservice
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/lib/rbac"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// ListRoles implements interfaces.Auth.
+func (a AuthService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	const op = "service.auth.listRoles"
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	return slices.Clone(rbac.Roles), nil
+}
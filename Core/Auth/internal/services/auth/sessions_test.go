@@ -0,0 +1,92 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestLogout(t *testing.T) {
+	tests := []struct {
+		name string
+		// logout ends sessions of the test user, given the ids of its three
+		// sessions.
+		logout     func(t *testing.T, e *testEnv, sids []uuid.UUID) error
+		wantErr    error
+		wantActive int
+	}{
+		{
+			name: "own session",
+			logout: func(t *testing.T, e *testEnv, sids []uuid.UUID) error {
+				return e.auth.Logout(context.Background(), e.user.Id, sids[0])
+			},
+			wantActive: 2,
+		},
+		{
+			name: "session of another user",
+			logout: func(t *testing.T, e *testEnv, sids []uuid.UUID) error {
+				return e.auth.Logout(context.Background(), uuid.New(), sids[0])
+			},
+			wantErr:    ErrSessionNotFound,
+			wantActive: 3,
+		},
+		{
+			name: "unknown session",
+			logout: func(t *testing.T, e *testEnv, sids []uuid.UUID) error {
+				return e.auth.Logout(context.Background(), e.user.Id, uuid.New())
+			},
+			wantErr:    ErrSessionNotFound,
+			wantActive: 3,
+		},
+		{
+			name: "all sessions",
+			logout: func(t *testing.T, e *testEnv, sids []uuid.UUID) error {
+				revoked, err := e.auth.LogoutAll(context.Background(), e.user.Id)
+				if err == nil && revoked != 3 {
+					t.Errorf("LogoutAll revoked %d sessions, want 3", revoked)
+				}
+				return err
+			},
+		},
+		{
+			name: "all sessions of another user",
+			logout: func(t *testing.T, e *testEnv, sids []uuid.UUID) error {
+				revoked, err := e.auth.LogoutAll(context.Background(), uuid.New())
+				if err == nil && revoked != 0 {
+					t.Errorf("LogoutAll revoked %d sessions, want 0", revoked)
+				}
+				return err
+			},
+			wantActive: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+
+			var sids []uuid.UUID
+			for range 3 {
+				claims, err := e.auth.tokens.ParseRefreshToken(e.login(t))
+				if err != nil {
+					t.Fatal(err)
+				}
+				sids = append(sids, claims.Sid)
+			}
+
+			if err := tt.logout(t, e, sids); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+
+			sessions, err := e.auth.GetSessions(context.Background(), e.user.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sessions) != tt.wantActive {
+				t.Errorf("%d active sessions, want %d", len(sessions), tt.wantActive)
+			}
+		})
+	}
+}
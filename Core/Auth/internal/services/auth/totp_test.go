@@ -0,0 +1,142 @@
+package authservice
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// totpCode returns the code an authenticator app shows for secret at t.
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1_000_000)
+}
+
+// enrollTotp turns TOTP on for the test user with a code of the current
+// period and returns the secret and the recovery codes.
+func (e *testEnv) enrollTotp(t *testing.T) (string, []string) {
+	t.Helper()
+
+	secret, _, err := e.auth.BeginTotpEnrollment(context.Background(), e.user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveryCodes, err := e.auth.ConfirmTotpEnrollment(context.Background(), e.user.Id, totpCode(t, secret, time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secret, recoveryCodes
+}
+
+func TestVerifyTotpLogin(t *testing.T) {
+	tests := []struct {
+		name string
+		// answer returns the challenge token and the code to present, given
+		// the challenge of a login and the enrolled secret and recovery codes.
+		answer  func(t *testing.T, e *testEnv, challenge string, secret string, recoveryCodes []string) (string, string)
+		wantErr error
+	}{
+		{
+			name: "code of the next period",
+			answer: func(t *testing.T, e *testEnv, challenge string, secret string, recoveryCodes []string) (string, string) {
+				return challenge, totpCode(t, secret, time.Now().Add(30*time.Second))
+			},
+		},
+		{
+			name: "code used for enrollment",
+			answer: func(t *testing.T, e *testEnv, challenge string, secret string, recoveryCodes []string) (string, string) {
+				return challenge, totpCode(t, secret, time.Now())
+			},
+			wantErr: ErrInvalidTotpCode,
+		},
+		{
+			name: "wrong code",
+			answer: func(t *testing.T, e *testEnv, challenge string, secret string, recoveryCodes []string) (string, string) {
+				return challenge, "000000"
+			},
+			wantErr: ErrInvalidTotpCode,
+		},
+		{
+			name: "recovery code",
+			answer: func(t *testing.T, e *testEnv, challenge string, secret string, recoveryCodes []string) (string, string) {
+				return challenge, recoveryCodes[0]
+			},
+		},
+		{
+			name: "recovery code used twice",
+			answer: func(t *testing.T, e *testEnv, challenge string, secret string, recoveryCodes []string) (string, string) {
+				if _, err := e.auth.VerifyTotpLogin(context.Background(), challenge, recoveryCodes[0], models.Device{}); err != nil {
+					t.Fatal(err)
+				}
+				return challenge, recoveryCodes[0]
+			},
+			wantErr: ErrInvalidTotpCode,
+		},
+		{
+			name: "refresh token as the challenge",
+			answer: func(t *testing.T, e *testEnv, challenge string, secret string, recoveryCodes []string) (string, string) {
+				result, err := e.auth.VerifyTotpLogin(context.Background(), challenge, recoveryCodes[0], models.Device{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return result.RefreshToken, recoveryCodes[1]
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "totp turned off after the challenge",
+			answer: func(t *testing.T, e *testEnv, challenge string, secret string, recoveryCodes []string) (string, string) {
+				if err := e.auth.DisableTotp(context.Background(), e.user.Id, recoveryCodes[0]); err != nil {
+					t.Fatal(err)
+				}
+				return challenge, recoveryCodes[1]
+			},
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			secret, recoveryCodes := e.enrollTotp(t)
+
+			login, err := e.auth.Login(context.Background(), testEmail, testPassword, models.AppCredentials{}, models.Device{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if login.ChallengeType != models.ChallengeTotp || login.RefreshToken != "" {
+				t.Fatalf("login with totp issued %+v, want a totp challenge only", login)
+			}
+
+			challenge, code := tt.answer(t, e, login.ChallengeToken, secret, recoveryCodes)
+			result, err := e.auth.VerifyTotpLogin(context.Background(), challenge, code, models.Device{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyTotpLogin: %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (result.AccessToken == "" || result.RefreshToken == "") {
+				t.Error("VerifyTotpLogin did not issue tokens")
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package mockapps
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MockStorage struct {
+	mu   sync.Mutex
+	apps map[uuid.UUID]models.App
+}
+
+func New() *MockStorage {
+	return &MockStorage{
+		apps: make(map[uuid.UUID]models.App),
+	}
+}
+
+// Insert implements interfaces.AppsStorage.
+func (m *MockStorage) Insert(ctx context.Context, app models.App) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.apps[app.Id] = app
+	return nil
+}
+
+// GetById implements interfaces.AppsStorage.
+func (m *MockStorage) GetById(ctx context.Context, id uuid.UUID) (models.App, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	app, exists := m.apps[id]
+	if !exists {
+		return models.App{}, storage.ErrAppNotFound
+	}
+	return app, nil
+}
+
+// GetAll implements interfaces.AppsStorage.
+func (m *MockStorage) GetAll(ctx context.Context) ([]models.App, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	apps := make([]models.App, 0, len(m.apps))
+	for _, app := range m.apps {
+		apps = append(apps, app)
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].CreatedAt.Before(apps[j].CreatedAt)
+	})
+	return apps, nil
+}
+
+// UpdateSecret implements interfaces.AppsStorage.
+func (m *MockStorage) UpdateSecret(ctx context.Context, id uuid.UUID, secretHash string, rotatedAt time.Time) (models.App, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	app, exists := m.apps[id]
+	if !exists {
+		return models.App{}, storage.ErrAppNotFound
+	}
+	app.SecretHash = secretHash
+	app.SecretRotatedAt = rotatedAt
+	m.apps[id] = app
+	return app, nil
+}
+
+// Disable implements interfaces.AppsStorage.
+func (m *MockStorage) Disable(ctx context.Context, id uuid.UUID) (models.App, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	app, exists := m.apps[id]
+	if !exists {
+		return models.App{}, storage.ErrAppNotFound
+	}
+	app.Disabled = true
+	m.apps[id] = app
+	return app, nil
+}
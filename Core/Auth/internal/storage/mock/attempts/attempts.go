@@ -0,0 +1,69 @@
+package mockattempts
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"sync"
+	"time"
+)
+
+type MockStorage struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempts
+}
+
+func New() *MockStorage {
+	return &MockStorage{
+		attempts: make(map[string]models.LoginAttempts),
+	}
+}
+
+// Get implements interfaces.LoginAttemptsStorage.
+func (m *MockStorage) Get(ctx context.Context, key string) (models.LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempts, exists := m.attempts[key]
+	if !exists {
+		return models.LoginAttempts{Key: key}, nil
+	}
+	return attempts, nil
+}
+
+// RegisterFailure implements interfaces.LoginAttemptsStorage.
+func (m *MockStorage) RegisterFailure(ctx context.Context, key string, window time.Duration) (models.LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	attempts, exists := m.attempts[key]
+	if !exists || attempts.LastFailureAt.Before(now.Add(-window)) {
+		attempts.Key = key
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = now
+	m.attempts[key] = attempts
+	return attempts, nil
+}
+
+// Lock implements interfaces.LoginAttemptsStorage.
+func (m *MockStorage) Lock(ctx context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempts := m.attempts[key]
+	attempts.Key = key
+	attempts.LockedUntil = until
+	m.attempts[key] = attempts
+	return nil
+}
+
+// Reset implements interfaces.LoginAttemptsStorage.
+func (m *MockStorage) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.attempts, key)
+	return nil
+}
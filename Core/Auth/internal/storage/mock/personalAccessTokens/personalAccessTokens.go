@@ -0,0 +1,83 @@
+package mockpersonalaccesstokens
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MockStorage struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]models.PersonalAccessToken
+}
+
+func New() *MockStorage {
+	return &MockStorage{
+		tokens: make(map[uuid.UUID]models.PersonalAccessToken),
+	}
+}
+
+// Insert implements interfaces.PersonalAccessTokensStorage.
+func (m *MockStorage) Insert(ctx context.Context, token models.PersonalAccessToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tokens[token.Id] = token
+	return nil
+}
+
+// GetByHash implements interfaces.PersonalAccessTokensStorage.
+func (m *MockStorage) GetByHash(ctx context.Context, hash string) (models.PersonalAccessToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, token := range m.tokens {
+		if token.Hash == hash {
+			return token, nil
+		}
+	}
+	return models.PersonalAccessToken{}, storage.ErrPersonalAccessTokenNotFound
+}
+
+// GetByUserId implements interfaces.PersonalAccessTokensStorage.
+func (m *MockStorage) GetByUserId(ctx context.Context, uid uuid.UUID) ([]models.PersonalAccessToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tokens := make([]models.PersonalAccessToken, 0)
+	for _, token := range m.tokens {
+		if token.UserId == uid {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+// Delete implements interfaces.PersonalAccessTokensStorage.
+func (m *MockStorage) Delete(ctx context.Context, uid uuid.UUID, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, exists := m.tokens[id]
+	if !exists || token.UserId != uid {
+		return storage.ErrPersonalAccessTokenNotFound
+	}
+	delete(m.tokens, id)
+	return nil
+}
+
+// Touch implements interfaces.PersonalAccessTokensStorage.
+func (m *MockStorage) Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if token, exists := m.tokens[id]; exists {
+		token.LastUsedAt = usedAt
+		m.tokens[id] = token
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package mocksessions
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MockStorage struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]models.Session
+}
+
+func New() *MockStorage {
+	return &MockStorage{
+		sessions: make(map[uuid.UUID]models.Session),
+	}
+}
+
+// Insert implements interfaces.SessionsStorage.
+func (m *MockStorage) Insert(ctx context.Context, session models.Session) (models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sessions[session.Id] = session
+	return session, nil
+}
+
+// GetSessionById implements interfaces.SessionsStorage.
+func (m *MockStorage) GetSessionById(ctx context.Context, sid uuid.UUID) (models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sid]
+	if !exists {
+		return models.Session{}, storage.ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// GetActiveSessionsByUserId implements interfaces.SessionsStorage.
+func (m *MockStorage) GetActiveSessionsByUserId(ctx context.Context, uid uuid.UUID) ([]models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	sessions := make([]models.Session, 0)
+	for _, session := range m.sessions {
+		if session.UserId == uid && !session.Revoked && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+// Rotate implements interfaces.SessionsStorage.
+func (m *MockStorage) Rotate(ctx context.Context, sid uuid.UUID, oldTokenId uuid.UUID, newTokenId uuid.UUID, expiresAt time.Time) (models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sid]
+	if !exists {
+		return models.Session{}, storage.ErrSessionNotFound
+	}
+	if session.Revoked {
+		return models.Session{}, storage.ErrSessionRevoked
+	}
+	if session.RefreshTokenId != oldTokenId {
+		return models.Session{}, storage.ErrTokenMismatch
+	}
+
+	session.RefreshTokenId = newTokenId
+	session.ExpiresAt = expiresAt
+	session.LastUsedAt = time.Now()
+	m.sessions[sid] = session
+	return session, nil
+}
+
+// Revoke implements interfaces.SessionsStorage.
+func (m *MockStorage) Revoke(ctx context.Context, sid uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sid]
+	if !exists {
+		return storage.ErrSessionNotFound
+	}
+	session.Revoked = true
+	m.sessions[sid] = session
+	return nil
+}
+
+// RevokeAllByUserId implements interfaces.SessionsStorage.
+func (m *MockStorage) RevokeAllByUserId(ctx context.Context, uid uuid.UUID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var revoked int64
+	for sid, session := range m.sessions {
+		if session.UserId == uid && !session.Revoked {
+			session.Revoked = true
+			m.sessions[sid] = session
+			revoked++
+		}
+	}
+	return revoked, nil
+}
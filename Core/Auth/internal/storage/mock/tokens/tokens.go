@@ -0,0 +1,71 @@
+package mocktokens
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MockStorage struct {
+	mu     sync.Mutex
+	tokens map[string]models.OneTimeToken
+}
+
+func New() *MockStorage {
+	return &MockStorage{
+		tokens: make(map[string]models.OneTimeToken),
+	}
+}
+
+// Insert implements interfaces.OneTimeTokensStorage.
+func (m *MockStorage) Insert(ctx context.Context, token models.OneTimeToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tokens[token.Hash] = token
+	return nil
+}
+
+// Consume implements interfaces.OneTimeTokensStorage.
+func (m *MockStorage) Consume(ctx context.Context, hash string, purpose string) (models.OneTimeToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, exists := m.tokens[hash]
+	if !exists || token.Purpose != purpose {
+		return models.OneTimeToken{}, storage.ErrTokenNotFound
+	}
+	delete(m.tokens, hash)
+	return token, nil
+}
+
+// DeleteByUserId implements interfaces.OneTimeTokensStorage.
+func (m *MockStorage) DeleteByUserId(ctx context.Context, uid uuid.UUID, purpose string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for hash, token := range m.tokens {
+		if token.UserId == uid && token.Purpose == purpose {
+			delete(m.tokens, hash)
+		}
+	}
+	return nil
+}
+
+// CountByUserIdSince implements interfaces.OneTimeTokensStorage.
+func (m *MockStorage) CountByUserIdSince(ctx context.Context, uid uuid.UUID, purpose string, since time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, token := range m.tokens {
+		if token.UserId == uid && token.Purpose == purpose && token.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,108 @@
+package mocktotp
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MockStorage struct {
+	mu            sync.Mutex
+	secrets       map[uuid.UUID]models.Totp
+	recoveryCodes map[uuid.UUID]map[string]struct{}
+}
+
+func New() *MockStorage {
+	return &MockStorage{
+		secrets:       make(map[uuid.UUID]models.Totp),
+		recoveryCodes: make(map[uuid.UUID]map[string]struct{}),
+	}
+}
+
+// GetByUserId implements interfaces.TotpStorage.
+func (m *MockStorage) GetByUserId(ctx context.Context, uid uuid.UUID) (models.Totp, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	totp, exists := m.secrets[uid]
+	if !exists {
+		return models.Totp{}, storage.ErrTotpNotFound
+	}
+	return totp, nil
+}
+
+// SavePending implements interfaces.TotpStorage.
+func (m *MockStorage) SavePending(ctx context.Context, totp models.Totp) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, exists := m.secrets[totp.UserId]; exists && current.Enabled {
+		return storage.ErrTotpEnabled
+	}
+	totp.Enabled = false
+	totp.LastUsedStep = 0
+	m.secrets[totp.UserId] = totp
+	return nil
+}
+
+// Enable implements interfaces.TotpStorage.
+func (m *MockStorage) Enable(ctx context.Context, uid uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	totp, exists := m.secrets[uid]
+	if !exists || totp.Enabled {
+		return storage.ErrTotpNotFound
+	}
+	totp.Enabled = true
+	totp.ConfirmedAt = time.Now()
+	totp.LastUsedStep = step
+	m.secrets[uid] = totp
+
+	codes := make(map[string]struct{}, len(recoveryCodeHashes))
+	for _, hash := range recoveryCodeHashes {
+		codes[hash] = struct{}{}
+	}
+	m.recoveryCodes[uid] = codes
+	return nil
+}
+
+// UseStep implements interfaces.TotpStorage.
+func (m *MockStorage) UseStep(ctx context.Context, uid uuid.UUID, step int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	totp, exists := m.secrets[uid]
+	if !exists || totp.LastUsedStep >= step {
+		return storage.ErrTotpStepUsed
+	}
+	totp.LastUsedStep = step
+	m.secrets[uid] = totp
+	return nil
+}
+
+// ConsumeRecoveryCode implements interfaces.TotpStorage.
+func (m *MockStorage) ConsumeRecoveryCode(ctx context.Context, uid uuid.UUID, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.recoveryCodes[uid][hash]; !exists {
+		return storage.ErrRecoveryCodeNotFound
+	}
+	delete(m.recoveryCodes[uid], hash)
+	return nil
+}
+
+// Delete implements interfaces.TotpStorage.
+func (m *MockStorage) Delete(ctx context.Context, uid uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.secrets, uid)
+	delete(m.recoveryCodes, uid)
+	return nil
+}
@@ -2,11 +2,13 @@ package mockusers
 
 import (
 	"auth/internal/domain/models"
+	"auth/internal/lib/hasher"
 	"auth/internal/storage"
 	"context"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type MockStorage struct {
@@ -18,13 +20,14 @@ func New() *MockStorage {
 	return &MockStorage{
 		users: map[uuid.UUID]models.User{
 			generated_id: {
-				Id:          generated_id,
-				Email:       "testuser@example.com",
-				Password:    "securepassword",
-				Role:        "admin",
-				Nick:        "test_nick",
-				Description: "Hello, my name is test_nick",
-				Birthday:    time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+				Id:            generated_id,
+				Email:         "testuser@example.com",
+				Password:      "securepassword",
+				Roles:         []string{"admin"},
+				Nick:          "test_nick",
+				Description:   "Hello, my name is test_nick",
+				Birthday:      time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+				EmailVerified: true,
 			},
 		},
 	}
@@ -59,20 +62,52 @@ func (m *MockStorage) GetUserByEmail(ctx context.Context, email string) (models.
 	return models.User{}, storage.ErrUserNotFound
 }
 
-// Insert implements storage.Storage.
+// VerifyCredentials implements storage.Storage.
+func (m *MockStorage) VerifyCredentials(ctx context.Context, email string, password string) (models.User, error) {
+	for _, v := range m.users {
+		if v.Email != email {
+			continue
+		}
+		if ok, _ := hasher.Verify(v.Password, password, bcrypt.DefaultCost); !ok {
+			break
+		}
+		v.Password = ""
+		return v, nil
+	}
+
+	return models.User{}, storage.ErrInvalidCredentials
+}
+
+// Insert implements storage.Storage. Like UsersManageService, it hashes
+// the plain text password.
 func (m *MockStorage) Insert(ctx context.Context, user models.User) (models.User, error) {
 	if _, exists := m.users[user.Id]; exists {
 		return models.User{}, storage.ErrUserExists
 	}
+	hash, err := hasher.Hash(user.Password, bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+	user.Password = hash
 	m.users[user.Id] = user
 	return user, nil
 }
 
 // Update implements storage.Storage.
 func (m *MockStorage) Update(ctx context.Context, id uuid.UUID, user models.User) (models.User, error) {
-	if _, exists := m.users[id]; !exists {
+	stored, exists := m.users[id]
+	if !exists {
 		return models.User{}, storage.ErrUserNotFound
 	}
+	if user.Password == "" {
+		user.Password = stored.Password
+	} else {
+		hash, err := hasher.Hash(user.Password, bcrypt.DefaultCost)
+		if err != nil {
+			return models.User{}, err
+		}
+		user.Password = hash
+	}
 	m.users[id] = user
 	return user, nil
 }
@@ -86,3 +121,14 @@ func (m *MockStorage) Delete(ctx context.Context, id uuid.UUID) (models.User, er
 	delete(m.users, id)
 	return user, nil
 }
+
+// SetEmailVerified implements storage.Storage.
+func (m *MockStorage) SetEmailVerified(ctx context.Context, id uuid.UUID, verified bool) (models.User, error) {
+	user, exists := m.users[id]
+	if !exists {
+		return models.User{}, storage.ErrUserNotFound
+	}
+	user.EmailVerified = verified
+	m.users[id] = user
+	return user, nil
+}
@@ -0,0 +1,195 @@
+package psqlstorage
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"auth/pkg/lib/logger/sl"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const AppsTableName = "Apps"
+
+const appColumns = `id, name, secret_hash, redirect_uris, scopes, disabled, created_at, secret_rotated_at`
+
+// AppsStorage keeps registered client applications in the same database as
+// sessions.
+type AppsStorage struct {
+	log *slog.Logger
+	DB  *sql.DB
+}
+
+func NewAppsStorage(log *slog.Logger, db *sql.DB) *AppsStorage {
+	return &AppsStorage{
+		log: log,
+		DB:  db,
+	}
+}
+
+// Insert implements interfaces.AppsStorage.
+func (as *AppsStorage) Insert(ctx context.Context, app models.App) error {
+	const op = "storage.psql.apps.insert"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := as.DB.ExecContext(ctx, `
+		INSERT INTO `+AppsTableName+` (`+appColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`,
+		app.Id, app.Name, app.SecretHash, pq.Array(app.RedirectUris), pq.Array(app.Scopes),
+		app.Disabled, app.CreatedAt, app.SecretRotatedAt)
+	if err != nil {
+		log.Error("Error inserting app", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetById implements interfaces.AppsStorage.
+func (as *AppsStorage) GetById(ctx context.Context, id uuid.UUID) (models.App, error) {
+	const op = "storage.psql.apps.getById"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.App{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	app, err := scanApp(as.DB.QueryRowContext(ctx, `
+		SELECT `+appColumns+` FROM `+AppsTableName+` WHERE id = $1;`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.App{}, fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+		}
+
+		log.Error("Error fetching app", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return app, nil
+}
+
+// GetAll implements interfaces.AppsStorage.
+func (as *AppsStorage) GetAll(ctx context.Context) ([]models.App, error) {
+	const op = "storage.psql.apps.getAll"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	rows, err := as.DB.QueryContext(ctx, `
+		SELECT `+appColumns+` FROM `+AppsTableName+` ORDER BY created_at;`)
+	if err != nil {
+		log.Error("Error fetching apps", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	apps := make([]models.App, 0)
+	for rows.Next() {
+		app, err := scanApp(rows)
+		if err != nil {
+			log.Error("Error scanning app", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		apps = append(apps, app)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("Error iterating apps", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return apps, nil
+}
+
+// UpdateSecret implements interfaces.AppsStorage.
+func (as *AppsStorage) UpdateSecret(ctx context.Context, id uuid.UUID, secretHash string, rotatedAt time.Time) (models.App, error) {
+	const op = "storage.psql.apps.updateSecret"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.App{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	app, err := scanApp(as.DB.QueryRowContext(ctx, `
+		UPDATE `+AppsTableName+` SET secret_hash = $2, secret_rotated_at = $3
+		WHERE id = $1
+		RETURNING `+appColumns+`;`, id, secretHash, rotatedAt))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.App{}, fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+		}
+
+		log.Error("Error updating app secret", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return app, nil
+}
+
+// Disable implements interfaces.AppsStorage.
+func (as *AppsStorage) Disable(ctx context.Context, id uuid.UUID) (models.App, error) {
+	const op = "storage.psql.apps.disable"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.App{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	app, err := scanApp(as.DB.QueryRowContext(ctx, `
+		UPDATE `+AppsTableName+` SET disabled = TRUE
+		WHERE id = $1
+		RETURNING `+appColumns+`;`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.App{}, fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+		}
+
+		log.Error("Error disabling app", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return app, nil
+}
+
+func scanApp(row rowScanner) (models.App, error) {
+	var app models.App
+	err := row.Scan(&app.Id, &app.Name, &app.SecretHash, pq.Array(&app.RedirectUris), pq.Array(&app.Scopes),
+		&app.Disabled, &app.CreatedAt, &app.SecretRotatedAt)
+	if err != nil {
+		return models.App{}, err
+	}
+
+	return app, nil
+}
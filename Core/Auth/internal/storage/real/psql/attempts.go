@@ -0,0 +1,143 @@
+package psqlstorage
+
+import (
+	"auth/internal/domain/models"
+	"auth/pkg/lib/logger/sl"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const LoginAttemptsTableName = "LoginAttempts"
+
+// AttemptsStorage keeps failed login counters in the same database as
+// sessions.
+type AttemptsStorage struct {
+	log *slog.Logger
+	DB  *sql.DB
+}
+
+func NewAttemptsStorage(log *slog.Logger, db *sql.DB) *AttemptsStorage {
+	return &AttemptsStorage{
+		log: log,
+		DB:  db,
+	}
+}
+
+// Get implements interfaces.LoginAttemptsStorage. A key without failures
+// yields a zero record rather than an error.
+func (as *AttemptsStorage) Get(ctx context.Context, key string) (models.LoginAttempts, error) {
+	const op = "storage.psql.attempts.get"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.LoginAttempts{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	attempts := models.LoginAttempts{Key: key}
+	var lockedUntil sql.NullTime
+	err := as.DB.QueryRowContext(ctx, `
+		SELECT failures, last_failure_at, locked_until
+		FROM `+LoginAttemptsTableName+` WHERE attempt_key = $1;`, key).
+		Scan(&attempts.Failures, &attempts.LastFailureAt, &lockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return attempts, nil
+		}
+
+		log.Error("Error fetching login attempts", sl.Err(err))
+		return models.LoginAttempts{}, fmt.Errorf("%s: %w", op, err)
+	}
+	attempts.LockedUntil = lockedUntil.Time
+
+	return attempts, nil
+}
+
+// RegisterFailure implements interfaces.LoginAttemptsStorage. The counter
+// starts over when the previous failure is older than window.
+func (as *AttemptsStorage) RegisterFailure(ctx context.Context, key string, window time.Duration) (models.LoginAttempts, error) {
+	const op = "storage.psql.attempts.registerFailure"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.LoginAttempts{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	attempts := models.LoginAttempts{Key: key}
+	var lockedUntil sql.NullTime
+	err := as.DB.QueryRowContext(ctx, `
+		INSERT INTO `+LoginAttemptsTableName+` (attempt_key, failures, last_failure_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (attempt_key) DO UPDATE
+		SET failures = CASE
+				WHEN `+LoginAttemptsTableName+`.last_failure_at < NOW() - $2 * INTERVAL '1 second' THEN 1
+				ELSE `+LoginAttemptsTableName+`.failures + 1
+			END,
+			last_failure_at = NOW()
+		RETURNING failures, last_failure_at, locked_until;`, key, window.Seconds()).
+		Scan(&attempts.Failures, &attempts.LastFailureAt, &lockedUntil)
+	if err != nil {
+		log.Error("Error registering failed attempt", sl.Err(err))
+		return models.LoginAttempts{}, fmt.Errorf("%s: %w", op, err)
+	}
+	attempts.LockedUntil = lockedUntil.Time
+
+	return attempts, nil
+}
+
+// Lock implements interfaces.LoginAttemptsStorage.
+func (as *AttemptsStorage) Lock(ctx context.Context, key string, until time.Time) error {
+	const op = "storage.psql.attempts.lock"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := as.DB.ExecContext(ctx, `
+		UPDATE `+LoginAttemptsTableName+` SET locked_until = $2 WHERE attempt_key = $1;`, key, until)
+	if err != nil {
+		log.Error("Error locking", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Reset implements interfaces.LoginAttemptsStorage.
+func (as *AttemptsStorage) Reset(ctx context.Context, key string) error {
+	const op = "storage.psql.attempts.reset"
+	log := as.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := as.DB.ExecContext(ctx, `
+		DELETE FROM `+LoginAttemptsTableName+` WHERE attempt_key = $1;`, key)
+	if err != nil {
+		log.Error("Error resetting login attempts", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
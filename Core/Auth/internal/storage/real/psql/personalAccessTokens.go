@@ -0,0 +1,190 @@
+package psqlstorage
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"auth/pkg/lib/logger/sl"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const PersonalAccessTokensTableName = "PersonalAccessTokens"
+
+// PersonalAccessTokensStorage keeps hashed personal access tokens in the
+// same database as sessions.
+type PersonalAccessTokensStorage struct {
+	log *slog.Logger
+	DB  *sql.DB
+}
+
+func NewPersonalAccessTokensStorage(log *slog.Logger, db *sql.DB) *PersonalAccessTokensStorage {
+	return &PersonalAccessTokensStorage{
+		log: log,
+		DB:  db,
+	}
+}
+
+// Insert implements interfaces.PersonalAccessTokensStorage.
+func (ps *PersonalAccessTokensStorage) Insert(ctx context.Context, token models.PersonalAccessToken) error {
+	const op = "storage.psql.personalAccessTokens.insert"
+	log := ps.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := ps.DB.ExecContext(ctx, `
+		INSERT INTO `+PersonalAccessTokensTableName+` (id, user_id, name, token_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);`,
+		token.Id, token.UserId, token.Name, token.Hash, pq.Array(token.Scopes), token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		log.Error("Error inserting personal access token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetByHash implements interfaces.PersonalAccessTokensStorage.
+func (ps *PersonalAccessTokensStorage) GetByHash(ctx context.Context, hash string) (models.PersonalAccessToken, error) {
+	const op = "storage.psql.personalAccessTokens.getByHash"
+	log := ps.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	token, err := scanPersonalAccessToken(ps.DB.QueryRowContext(ctx, `
+		SELECT id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at
+		FROM `+PersonalAccessTokensTableName+` WHERE token_hash = $1;`, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, storage.ErrPersonalAccessTokenNotFound)
+		}
+
+		log.Error("Error fetching personal access token", sl.Err(err))
+		return models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, nil
+}
+
+// GetByUserId implements interfaces.PersonalAccessTokensStorage.
+func (ps *PersonalAccessTokensStorage) GetByUserId(ctx context.Context, uid uuid.UUID) ([]models.PersonalAccessToken, error) {
+	const op = "storage.psql.personalAccessTokens.getByUserId"
+	log := ps.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	rows, err := ps.DB.QueryContext(ctx, `
+		SELECT id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at
+		FROM `+PersonalAccessTokensTableName+` WHERE user_id = $1
+		ORDER BY created_at DESC;`, uid)
+	if err != nil {
+		log.Error("Error fetching personal access tokens", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	tokens := make([]models.PersonalAccessToken, 0)
+	for rows.Next() {
+		token, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			log.Error("Error scanning personal access token", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("Error iterating personal access tokens", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// Delete implements interfaces.PersonalAccessTokensStorage. A token of
+// another user is reported as not found.
+func (ps *PersonalAccessTokensStorage) Delete(ctx context.Context, uid uuid.UUID, id uuid.UUID) error {
+	const op = "storage.psql.personalAccessTokens.delete"
+	log := ps.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	res, err := ps.DB.ExecContext(ctx, `
+		DELETE FROM `+PersonalAccessTokensTableName+` WHERE id = $1 AND user_id = $2;`, id, uid)
+	if err != nil {
+		log.Error("Error deleting personal access token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrPersonalAccessTokenNotFound)
+	}
+
+	return nil
+}
+
+// Touch implements interfaces.PersonalAccessTokensStorage.
+func (ps *PersonalAccessTokensStorage) Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	const op = "storage.psql.personalAccessTokens.touch"
+	log := ps.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := ps.DB.ExecContext(ctx, `
+		UPDATE `+PersonalAccessTokensTableName+` SET last_used_at = $2 WHERE id = $1;`, id, usedAt)
+	if err != nil {
+		log.Error("Error updating last use of personal access token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func scanPersonalAccessToken(row rowScanner) (models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	var lastUsedAt sql.NullTime
+	err := row.Scan(&token.Id, &token.UserId, &token.Name, &token.Hash, pq.Array(&token.Scopes),
+		&token.CreatedAt, &token.ExpiresAt, &lastUsedAt)
+	if err != nil {
+		return models.PersonalAccessToken{}, err
+	}
+	token.LastUsedAt = lastUsedAt.Time
+
+	return token, nil
+}
@@ -0,0 +1,270 @@
+package psqlstorage
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"auth/pkg/lib/logger/sl"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/pressly/goose/v3"
+)
+
+const SessionsTableName = "Sessions"
+
+const sessionColumns = `id, user_id, refresh_token_id, user_agent, ip, created_at, last_used_at, expires_at, revoked, app_id`
+
+type PsqlStorage struct {
+	log *slog.Logger
+	DB  *sql.DB
+}
+
+func New(log *slog.Logger, connStr string) *PsqlStorage {
+	const op = "psql.New"
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		log.With(slog.String("op", op)).Error("Error connecting to DB", sl.Err(err))
+		panic(err)
+	}
+
+	wd, _ := os.Getwd()
+	migrationPath := filepath.Join(wd, "app", "migrations")
+	if err := applyMigrations(db, migrationPath); err != nil {
+		panic(err)
+	}
+
+	return &PsqlStorage{
+		log: log,
+		DB:  db,
+	}
+}
+
+func applyMigrations(db *sql.DB, migrationsPath string) error {
+	return goose.Up(db, migrationsPath)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row rowScanner) (models.Session, error) {
+	var session models.Session
+	var appId uuid.NullUUID
+	err := row.Scan(
+		&session.Id,
+		&session.UserId,
+		&session.RefreshTokenId,
+		&session.UserAgent,
+		&session.Ip,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&session.Revoked,
+		&appId,
+	)
+	session.AppId = appId.UUID
+	return session, err
+}
+
+// Insert implements interfaces.SessionsStorage.
+func (ps *PsqlStorage) Insert(ctx context.Context, session models.Session) (models.Session, error) {
+	const op = "storage.psql.insert"
+	log := ps.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.Session{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := ps.DB.ExecContext(ctx, `
+		INSERT INTO `+SessionsTableName+` (`+sessionColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`,
+		session.Id, session.UserId, session.RefreshTokenId, session.UserAgent, session.Ip,
+		session.CreatedAt, session.LastUsedAt, session.ExpiresAt, session.Revoked,
+		uuid.NullUUID{UUID: session.AppId, Valid: session.AppId != uuid.Nil})
+	if err != nil {
+		log.Error("Error inserting session", sl.Err(err))
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
+// GetSessionById implements interfaces.SessionsStorage.
+func (ps *PsqlStorage) GetSessionById(ctx context.Context, sid uuid.UUID) (models.Session, error) {
+	const op = "storage.psql.getSessionById"
+	log := ps.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.Session{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	session, err := scanSession(ps.DB.QueryRowContext(ctx, `
+		SELECT `+sessionColumns+` FROM `+SessionsTableName+` WHERE id = $1;`, sid))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn("Session with current id not found", sl.Err(err))
+			return models.Session{}, fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+		}
+
+		log.Error("Error scanning row", sl.Err(err))
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
+// GetActiveSessionsByUserId implements interfaces.SessionsStorage.
+func (ps *PsqlStorage) GetActiveSessionsByUserId(ctx context.Context, uid uuid.UUID) ([]models.Session, error) {
+	const op = "storage.psql.getActiveSessionsByUserId"
+	log := ps.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	rows, err := ps.DB.QueryContext(ctx, `
+		SELECT `+sessionColumns+` FROM `+SessionsTableName+`
+		WHERE user_id = $1 AND revoked = FALSE AND expires_at > NOW()
+		ORDER BY last_used_at DESC;`, uid)
+	if err != nil {
+		log.Error("Error retrieving sessions", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			log.Error("Error scanning row", sl.Err(err))
+			continue
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// Rotate implements interfaces.SessionsStorage.
+func (ps *PsqlStorage) Rotate(ctx context.Context, sid uuid.UUID, oldTokenId uuid.UUID, newTokenId uuid.UUID, expiresAt time.Time) (models.Session, error) {
+	const op = "storage.psql.rotate"
+	log := ps.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.Session{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	// The refresh token id acts as a compare-and-swap guard, so two concurrent
+	// refreshes with the same token cannot both succeed.
+	session, err := scanSession(ps.DB.QueryRowContext(ctx, `
+		UPDATE `+SessionsTableName+`
+		SET refresh_token_id = $1, expires_at = $2, last_used_at = NOW()
+		WHERE id = $3 AND refresh_token_id = $4 AND revoked = FALSE
+		RETURNING `+sessionColumns+`;`,
+		newTokenId, expiresAt, sid, oldTokenId))
+	if err == nil {
+		return session, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Error("Error rotating refresh token", sl.Err(err))
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	current, err := ps.GetSessionById(ctx, sid)
+	if err != nil {
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if current.Revoked {
+		return models.Session{}, fmt.Errorf("%s: %w", op, storage.ErrSessionRevoked)
+	}
+
+	return models.Session{}, fmt.Errorf("%s: %w", op, storage.ErrTokenMismatch)
+}
+
+// Revoke implements interfaces.SessionsStorage.
+func (ps *PsqlStorage) Revoke(ctx context.Context, sid uuid.UUID) error {
+	const op = "storage.psql.revoke"
+	log := ps.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	result, err := ps.DB.ExecContext(ctx, `
+		UPDATE `+SessionsTableName+` SET revoked = TRUE WHERE id = $1;`, sid)
+	if err != nil {
+		log.Error("Error revoking session", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Error("Error get rows affected", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		log.Warn("Zero rows affected")
+		return fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+	}
+
+	return nil
+}
+
+// RevokeAllByUserId implements interfaces.SessionsStorage.
+func (ps *PsqlStorage) RevokeAllByUserId(ctx context.Context, uid uuid.UUID) (int64, error) {
+	const op = "storage.psql.revokeAllByUserId"
+	log := ps.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	result, err := ps.DB.ExecContext(ctx, `
+		UPDATE `+SessionsTableName+` SET revoked = TRUE WHERE user_id = $1 AND revoked = FALSE;`, uid)
+	if err != nil {
+		log.Error("Error revoking sessions", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Error("Error get rows affected", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rowsAffected, nil
+}
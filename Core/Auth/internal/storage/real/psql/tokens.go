@@ -0,0 +1,137 @@
+package psqlstorage
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"auth/pkg/lib/logger/sl"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const OneTimeTokensTableName = "OneTimeTokens"
+
+// TokensStorage keeps one-time tokens in the same database as sessions.
+type TokensStorage struct {
+	log *slog.Logger
+	DB  *sql.DB
+}
+
+func NewTokensStorage(log *slog.Logger, db *sql.DB) *TokensStorage {
+	return &TokensStorage{
+		log: log,
+		DB:  db,
+	}
+}
+
+// Insert implements interfaces.OneTimeTokensStorage.
+func (ts *TokensStorage) Insert(ctx context.Context, token models.OneTimeToken) error {
+	const op = "storage.psql.tokens.insert"
+	log := ts.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := ts.DB.ExecContext(ctx, `
+		INSERT INTO `+OneTimeTokensTableName+` (token_hash, user_id, purpose, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5);`,
+		token.Hash, token.UserId, token.Purpose, token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		log.Error("Error inserting token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Consume implements interfaces.OneTimeTokensStorage. The token is deleted
+// in the same statement it is read by, so it can be used only once.
+func (ts *TokensStorage) Consume(ctx context.Context, hash string, purpose string) (models.OneTimeToken, error) {
+	const op = "storage.psql.tokens.consume"
+	log := ts.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.OneTimeToken{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	token := models.OneTimeToken{Hash: hash, Purpose: purpose}
+	err := ts.DB.QueryRowContext(ctx, `
+		DELETE FROM `+OneTimeTokensTableName+`
+		WHERE token_hash = $1 AND purpose = $2
+		RETURNING user_id, created_at, expires_at;`, hash, purpose).
+		Scan(&token.UserId, &token.CreatedAt, &token.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn("Token not found")
+			return models.OneTimeToken{}, fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+		}
+
+		log.Error("Error consuming token", sl.Err(err))
+		return models.OneTimeToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, nil
+}
+
+// DeleteByUserId implements interfaces.OneTimeTokensStorage.
+func (ts *TokensStorage) DeleteByUserId(ctx context.Context, uid uuid.UUID, purpose string) error {
+	const op = "storage.psql.tokens.deleteByUserId"
+	log := ts.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := ts.DB.ExecContext(ctx, `
+		DELETE FROM `+OneTimeTokensTableName+` WHERE user_id = $1 AND purpose = $2;`, uid, purpose)
+	if err != nil {
+		log.Error("Error deleting tokens", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CountByUserIdSince implements interfaces.OneTimeTokensStorage.
+func (ts *TokensStorage) CountByUserIdSince(ctx context.Context, uid uuid.UUID, purpose string, since time.Time) (int, error) {
+	const op = "storage.psql.tokens.countByUserIdSince"
+	log := ts.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	var count int
+	err := ts.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM `+OneTimeTokensTableName+`
+		WHERE user_id = $1 AND purpose = $2 AND created_at > $3;`, uid, purpose, since).
+		Scan(&count)
+	if err != nil {
+		log.Error("Error counting tokens", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,250 @@
+package psqlstorage
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"auth/pkg/lib/logger/sl"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TotpSecretsTableName   = "TotpSecrets"
+	RecoveryCodesTableName = "RecoveryCodes"
+)
+
+// TotpStorage keeps TOTP secrets and hashed recovery codes in the same
+// database as sessions.
+type TotpStorage struct {
+	log *slog.Logger
+	DB  *sql.DB
+}
+
+func NewTotpStorage(log *slog.Logger, db *sql.DB) *TotpStorage {
+	return &TotpStorage{
+		log: log,
+		DB:  db,
+	}
+}
+
+// GetByUserId implements interfaces.TotpStorage.
+func (ts *TotpStorage) GetByUserId(ctx context.Context, uid uuid.UUID) (models.Totp, error) {
+	const op = "storage.psql.totp.getByUserId"
+	log := ts.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.Totp{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	totp := models.Totp{UserId: uid}
+	var confirmedAt sql.NullTime
+	err := ts.DB.QueryRowContext(ctx, `
+		SELECT secret, enabled, last_used_step, created_at, confirmed_at
+		FROM `+TotpSecretsTableName+` WHERE user_id = $1;`, uid).
+		Scan(&totp.Secret, &totp.Enabled, &totp.LastUsedStep, &totp.CreatedAt, &confirmedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Totp{}, fmt.Errorf("%s: %w", op, storage.ErrTotpNotFound)
+		}
+
+		log.Error("Error fetching totp", sl.Err(err))
+		return models.Totp{}, fmt.Errorf("%s: %w", op, err)
+	}
+	totp.ConfirmedAt = confirmedAt.Time
+
+	return totp, nil
+}
+
+// SavePending implements interfaces.TotpStorage. A pending secret replaces
+// the previous pending one, an enabled secret is never overwritten.
+func (ts *TotpStorage) SavePending(ctx context.Context, totp models.Totp) error {
+	const op = "storage.psql.totp.savePending"
+	log := ts.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	res, err := ts.DB.ExecContext(ctx, `
+		INSERT INTO `+TotpSecretsTableName+` (user_id, secret, enabled, last_used_step, created_at)
+		VALUES ($1, $2, FALSE, 0, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = EXCLUDED.created_at
+		WHERE `+TotpSecretsTableName+`.enabled = FALSE;`,
+		totp.UserId, totp.Secret, totp.CreatedAt)
+	if err != nil {
+		log.Error("Error saving totp", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTotpEnabled)
+	}
+
+	return nil
+}
+
+// Enable implements interfaces.TotpStorage. The secret is switched on and
+// the recovery codes of the user are replaced in one transaction.
+func (ts *TotpStorage) Enable(ctx context.Context, uid uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	const op = "storage.psql.totp.enable"
+	log := ts.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	tx, err := ts.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Error starting transaction", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE `+TotpSecretsTableName+`
+		SET enabled = TRUE, confirmed_at = NOW(), last_used_step = $2
+		WHERE user_id = $1 AND enabled = FALSE;`, uid, step)
+	if err != nil {
+		log.Error("Error enabling totp", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTotpNotFound)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM `+RecoveryCodesTableName+` WHERE user_id = $1;`, uid); err != nil {
+		log.Error("Error deleting recovery codes", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO `+RecoveryCodesTableName+` (code_hash, user_id, created_at)
+			VALUES ($1, $2, NOW());`, hash, uid); err != nil {
+			log.Error("Error inserting recovery code", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Error committing transaction", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UseStep implements interfaces.TotpStorage. Steps only move forward, so a
+// code cannot be replayed within its validity window.
+func (ts *TotpStorage) UseStep(ctx context.Context, uid uuid.UUID, step int64) error {
+	const op = "storage.psql.totp.useStep"
+	log := ts.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	res, err := ts.DB.ExecContext(ctx, `
+		UPDATE `+TotpSecretsTableName+` SET last_used_step = $2
+		WHERE user_id = $1 AND last_used_step < $2;`, uid, step)
+	if err != nil {
+		log.Error("Error saving totp step", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTotpStepUsed)
+	}
+
+	return nil
+}
+
+// ConsumeRecoveryCode implements interfaces.TotpStorage.
+func (ts *TotpStorage) ConsumeRecoveryCode(ctx context.Context, uid uuid.UUID, hash string) error {
+	const op = "storage.psql.totp.consumeRecoveryCode"
+	log := ts.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	res, err := ts.DB.ExecContext(ctx, `
+		DELETE FROM `+RecoveryCodesTableName+` WHERE user_id = $1 AND code_hash = $2;`, uid, hash)
+	if err != nil {
+		log.Error("Error consuming recovery code", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrRecoveryCodeNotFound)
+	}
+
+	return nil
+}
+
+// Delete implements interfaces.TotpStorage.
+func (ts *TotpStorage) Delete(ctx context.Context, uid uuid.UUID) error {
+	const op = "storage.psql.totp.delete"
+	log := ts.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	tx, err := ts.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("Error starting transaction", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM `+RecoveryCodesTableName+` WHERE user_id = $1;`, uid); err != nil {
+		log.Error("Error deleting recovery codes", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM `+TotpSecretsTableName+` WHERE user_id = $1;`, uid); err != nil {
+		log.Error("Error deleting totp", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Error committing transaction", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -3,31 +3,40 @@ package usersmanageservice
 import (
 	"auth/internal/domain/models"
 	umprofiles "auth/internal/domain/profiles/um_profiles"
+	"auth/internal/storage"
 	"auth/pkg/lib/logger/sl"
 	"context"
 	"fmt"
 	"log/slog"
 
 	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"github.com/chas3air/shared/grpcconn"
 	"github.com/google/uuid"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type UsersManageService struct {
-	log         *slog.Logger
-	ServiceHost string
-	ServicePort int
+	log  *slog.Logger
+	conn *grpcconn.Conn
 }
 
-func New(log *slog.Logger, serviceHost string, servicePort int) *UsersManageService {
+// New builds the storage on conn, which is shared by all its calls.
+func New(log *slog.Logger, conn *grpcconn.Conn) *UsersManageService {
 	return &UsersManageService{
-		log:         log,
-		ServiceHost: serviceHost,
-		ServicePort: servicePort,
+		log:  log,
+		conn: conn,
 	}
 }
 
+// ReadMethods are the calls of the storage that only read and so may be
+// retried.
+var ReadMethods = []string{
+	umv1.UsersManager_GetUsers_FullMethodName,
+	umv1.UsersManager_GetUserById_FullMethodName,
+	umv1.UsersManager_GetUserByEmail_FullMethodName,
+}
+
 // GetUsers implements interfaces.UsersStorage.
 func (u *UsersManageService) GetUsers(ctx context.Context) ([]models.User, error) {
 	const op = "usersmanageservice.getUsers"
@@ -39,15 +48,11 @@ func (u *UsersManageService) GetUsers(ctx context.Context) ([]models.User, error
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", u.ServiceHost, u.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := u.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := umv1.NewUsersManagerClient(conn)
 	res, err := c.GetUsers(ctx, nil)
@@ -80,19 +85,20 @@ func (u *UsersManageService) GetUserById(ctx context.Context, uid uuid.UUID) (mo
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", u.ServiceHost, u.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := u.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := umv1.NewUsersManagerClient(conn)
 	res, err := c.GetUserById(ctx, &umv1.GetUserByIdRequest{Id: uid.String()})
 	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			log.Warn("user not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+
 		log.Warn("failed to get user by ID", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
@@ -117,19 +123,20 @@ func (u *UsersManageService) GetUserByEmail(ctx context.Context, email string) (
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", u.ServiceHost, u.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := u.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := umv1.NewUsersManagerClient(conn)
 	res, err := c.GetUserByEmail(ctx, &umv1.GetUserByEmailRequest{Email: email})
 	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			log.Warn("user not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+
 		log.Warn("failed to get user by email", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
@@ -143,6 +150,52 @@ func (u *UsersManageService) GetUserByEmail(ctx context.Context, email string) (
 	return resUser, nil
 }
 
+// VerifyCredentials implements interfaces.UsersStorage.
+func (u *UsersManageService) VerifyCredentials(ctx context.Context, email string, password string) (models.User, error) {
+	const op = "usersmanageservice.verifyCredentials"
+	log := u.log.With(slog.String("op", op))
+
+	select {
+	case <-ctx.Done():
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := u.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := umv1.NewUsersManagerClient(conn)
+	res, err := c.VerifyCredentials(ctx, &umv1.VerifyCredentialsRequest{
+		Email:    email,
+		Password: password,
+	})
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			log.Warn("invalid credentials", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrInvalidCredentials)
+		}
+
+		log.Warn("failed to verify credentials", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	uid, err := uuid.Parse(res.GetId())
+	if err != nil {
+		log.Warn("invalid user id in response", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.User{
+		Id:            uid,
+		Email:         email,
+		Roles:         res.GetRoles(),
+		EmailVerified: res.GetEmailVerified(),
+	}, nil
+}
+
 // Insert implements interfaces.UsersStorage.
 func (u *UsersManageService) Insert(ctx context.Context, user models.User) (models.User, error) {
 	const op = "usersmanageservice.insert"
@@ -154,15 +207,11 @@ func (u *UsersManageService) Insert(ctx context.Context, user models.User) (mode
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", u.ServiceHost, u.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := u.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := umv1.NewUsersManagerClient(conn)
 	userForInsert, err := umprofiles.UsrToProtoUsr(user)
@@ -193,15 +242,11 @@ func (u *UsersManageService) Update(ctx context.Context, uid uuid.UUID, user mod
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", u.ServiceHost, u.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := u.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := umv1.NewUsersManagerClient(conn)
 	userForUpdate, err := umprofiles.UsrToProtoUsr(user)
@@ -233,15 +278,11 @@ func (u *UsersManageService) Delete(ctx context.Context, uid uuid.UUID) (models.
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", u.ServiceHost, u.ServicePort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := u.conn.Get()
 	if err != nil {
 		log.Error("failed to connect to gRPC server", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer conn.Close()
 
 	c := umv1.NewUsersManagerClient(conn)
 	res, err := c.Delete(ctx, &umv1.DeleteRequest{
@@ -260,3 +301,44 @@ func (u *UsersManageService) Delete(ctx context.Context, uid uuid.UUID) (models.
 
 	return resUser, nil
 }
+
+// SetEmailVerified implements interfaces.UsersStorage.
+func (u *UsersManageService) SetEmailVerified(ctx context.Context, uid uuid.UUID, verified bool) (models.User, error) {
+	const op = "usersmanageservice.setEmailVerified"
+	log := u.log.With(slog.String("op", op))
+
+	select {
+	case <-ctx.Done():
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	conn, err := u.conn.Get()
+	if err != nil {
+		log.Error("failed to connect to gRPC server", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := umv1.NewUsersManagerClient(conn)
+	res, err := c.SetEmailVerified(ctx, &umv1.SetEmailVerifiedRequest{
+		Id:            uid.String(),
+		EmailVerified: verified,
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			log.Warn("user not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+
+		log.Warn("failed to set email verification", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resUser, err := umprofiles.ProtoUsrToUsr(res.GetUser())
+	if err != nil {
+		log.Warn("failed to convert proto user to model user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resUser, nil
+}
@@ -3,7 +3,17 @@ package storage
 import "errors"
 
 var (
-	ErrUserExists   = errors.New("user already exists")
-	ErrUserNotFound = errors.New("user not found")
-	ErrAppNotFound  = errors.New("app not found")
+	ErrUserExists                  = errors.New("user already exists")
+	ErrUserNotFound                = errors.New("user not found")
+	ErrInvalidCredentials          = errors.New("invalid credentials")
+	ErrAppNotFound                 = errors.New("app not found")
+	ErrSessionNotFound             = errors.New("session not found")
+	ErrSessionRevoked              = errors.New("session revoked")
+	ErrTokenMismatch               = errors.New("refresh token is not the current one")
+	ErrTokenNotFound               = errors.New("token not found")
+	ErrTotpNotFound                = errors.New("totp is not set up")
+	ErrTotpEnabled                 = errors.New("totp is already enabled")
+	ErrTotpStepUsed                = errors.New("totp code already used")
+	ErrRecoveryCodeNotFound        = errors.New("recovery code not found")
+	ErrPersonalAccessTokenNotFound = errors.New("personal access token not found")
 )
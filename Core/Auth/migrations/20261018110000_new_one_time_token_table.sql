@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE OneTimeTokens (
+    token_hash VARCHAR(64) NOT NULL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    purpose VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX onetimetokens_user_id_purpose_idx ON OneTimeTokens (user_id, purpose);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS OneTimeTokens;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE TotpSecrets (
+    user_id UUID NOT NULL PRIMARY KEY,
+    secret VARCHAR(64) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE RecoveryCodes (
+    code_hash VARCHAR(64) NOT NULL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX recoverycodes_user_id_idx ON RecoveryCodes (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS RecoveryCodes;
+DROP TABLE IF EXISTS TotpSecrets;
+-- +goose StatementEnd
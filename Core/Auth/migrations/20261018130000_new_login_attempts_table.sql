@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE LoginAttempts (
+    attempt_key VARCHAR(320) NOT NULL PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS LoginAttempts;
+-- +goose StatementEnd
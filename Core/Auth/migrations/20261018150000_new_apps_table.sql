@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE Apps (
+    id UUID NOT NULL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    secret_hash VARCHAR(64) NOT NULL,
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    secret_rotated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+ALTER TABLE Sessions ADD COLUMN app_id UUID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE Sessions DROP COLUMN IF EXISTS app_id;
+
+DROP TABLE IF EXISTS Apps;
+-- +goose StatementEnd
@@ -5,21 +5,112 @@ import (
 	"os"
 	"time"
 
+	"github.com/chas3air/shared/mtls"
+	"github.com/chas3air/shared/resilience"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env              string        `yaml:"env" env-default:"local"`
-	AccessTokenTTL   time.Duration `yaml:"access_token_ttl" env-default:"15m"`
-	RefreshTokenTTL  time.Duration `yaml:"refresh_token_ttl" env-default:"5h"`
-	UsersStorageHost string        `yaml:"usersStorageHost" env-default:"usersManageService"`
-	UsersStoragePort int           `yaml:"usersStoragePort" env-default:"50051"`
-	Grpc             GrpcConfig    `yaml:"grpc"`
+	Env                  string                     `yaml:"env" env-default:"local"`
+	AccessTokenTTL       time.Duration              `yaml:"access_token_ttl" env-default:"15m"`
+	RefreshTokenTTL      time.Duration              `yaml:"refresh_token_ttl" env-default:"5h"`
+	PublicURL            string                     `yaml:"public_url" env-default:"http://localhost"`
+	EmailVerificationTTL time.Duration              `yaml:"email_verification_ttl" env-default:"24h"`
+	PasswordReset        PasswordResetConfig        `yaml:"password_reset"`
+	Totp                 TotpConfig                 `yaml:"totp"`
+	Lockout              LockoutConfig              `yaml:"lockout"`
+	PersonalAccessTokens PersonalAccessTokensConfig `yaml:"personal_access_tokens"`
+	Apps                 AppsConfig                 `yaml:"apps"`
+	UsersStorageHost     string                     `yaml:"usersStorageHost" env-default:"usersManageService"`
+	UsersStoragePort     int                        `yaml:"usersStoragePort" env-default:"50051"`
+	GrpcClient           GrpcClientConfig           `yaml:"grpc_client"`
+	UsersResilience      ResiliencePolicy           `yaml:"users_resilience"`
+	Grpc                 GrpcConfig                 `yaml:"grpc"`
+	TLS                  TLSConfig                  `yaml:"tls"`
+	Jwt                  JwtConfig                  `yaml:"jwt"`
+	Mailer               MailerConfig               `yaml:"mailer"`
 }
 
+// PasswordResetConfig limits reset emails to Limit per Window for each
+// account.
+type PasswordResetConfig struct {
+	TTL    time.Duration `yaml:"ttl" env-default:"1h"`
+	Limit  int           `yaml:"limit" env-default:"3"`
+	Window time.Duration `yaml:"window" env-default:"1h"`
+}
+
+// TotpConfig controls two-factor authentication. With RequireForPrivileged
+// set, users whose role is in PrivilegedRoles cannot finish a login, or
+// turn TOTP off, without an authenticator app.
+type TotpConfig struct {
+	Issuer               string        `yaml:"issuer" env-default:"Redhub"`
+	ChallengeTTL         time.Duration `yaml:"challenge_ttl" env-default:"5m"`
+	RecoveryCodes        int           `yaml:"recovery_codes" env-default:"10"`
+	RequireForPrivileged bool          `yaml:"require_for_privileged" env-default:"false"`
+	PrivilegedRoles      []string      `yaml:"privileged_roles" env-default:"admin,user_admin,article_admin,moderator"`
+}
+
+// LockoutConfig throttles failed logins. Once an account or a client address
+// reaches its threshold of failures within Window, every further failure
+// locks it for BaseDelay, doubled per failure and capped at MaxDelay.
+type LockoutConfig struct {
+	AccountThreshold int           `yaml:"account_threshold" env-default:"5"`
+	IpThreshold      int           `yaml:"ip_threshold" env-default:"20"`
+	BaseDelay        time.Duration `yaml:"base_delay" env-default:"30s"`
+	MaxDelay         time.Duration `yaml:"max_delay" env-default:"15m"`
+	Window           time.Duration `yaml:"window" env-default:"1h"`
+}
+
+// PersonalAccessTokensConfig bounds the tokens users create for scripts.
+// Tokens requested without an expiry get DefaultTTL.
+type PersonalAccessTokensConfig struct {
+	DefaultTTL time.Duration `yaml:"default_ttl" env-default:"720h"`
+	MaxTTL     time.Duration `yaml:"max_ttl" env-default:"8760h"`
+	MaxPerUser int           `yaml:"max_per_user" env-default:"20"`
+}
+
+// AppsConfig controls registered client applications. With Required set,
+// logins that do not name an app are rejected.
+type AppsConfig struct {
+	Required bool `yaml:"required" env-default:"false"`
+}
+
+// MailerConfig selects how emails are delivered: "smtp", or "file" to append
+// them to FilePath for local runs. The SMTP password is read from the
+// SMTP_PASSWORD environment variable so it never ends up in logs.
+type MailerConfig struct {
+	Kind         string `yaml:"kind" env-default:"file"`
+	From         string `yaml:"from" env-default:"noreply@redhub.local"`
+	FilePath     string `yaml:"file_path"`
+	SmtpHost     string `yaml:"smtp_host"`
+	SmtpPort     int    `yaml:"smtp_port" env-default:"587"`
+	SmtpUsername string `yaml:"smtp_username"`
+}
+
+type JwtConfig struct {
+	Issuer            string        `yaml:"issuer" env-default:"auth"`
+	Audience          string        `yaml:"audience" env-default:"redhub"`
+	KeysPath          string        `yaml:"keys_path" env-default:"./keys"`
+	KeyRotationPeriod time.Duration `yaml:"key_rotation_period" env-default:"24h"`
+}
+
+// TLSConfig secures the gRPC connections of the service, see mtls.Config.
+type TLSConfig = mtls.Config
+
+// GrpcClientConfig tunes the connections kept open to the gRPC services.
+// KeepaliveTime must not be below the 10s the services accept.
+type GrpcClientConfig struct {
+	KeepaliveTime    time.Duration `yaml:"keepalive_time" env-default:"30s"`
+	KeepaliveTimeout time.Duration `yaml:"keepalive_timeout" env-default:"10s"`
+}
+
+// ResiliencePolicy is the retry and circuit breaker policy of one
+// downstream service, described at resilience.Policy.
+type ResiliencePolicy = resilience.Policy
+
 type GrpcConfig struct {
 	Port    int           `yaml:"port" env-default:"50051"`
-	Timeout time.Duration `yaml:"timeout" env-default:"10h"`
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
 }
 
 func MustLoad() *Config {
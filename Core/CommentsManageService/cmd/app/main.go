@@ -5,10 +5,17 @@ import (
 	psqlstorage "commentsManageService/internal/storage/real/psql"
 	"commentsManageService/pkg/config"
 	"commentsManageService/pkg/lib/logger"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+
+	"github.com/chas3air/shared/authclient"
+	"github.com/chas3air/shared/grpcconn"
+	"github.com/chas3air/shared/mtls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 )
 
 func main() {
@@ -21,7 +28,34 @@ func main() {
 	// storage := mock.New(log)
 	storage := psqlstorage.New(log, os.Getenv("DATABASE_URL"))
 
-	application := app.New(log, storage, cfg.Grpc.Port)
+	serverCreds, err := mtls.ServerCredentials(cfg.TLS)
+	if err != nil {
+		panic(err)
+	}
+
+	var authClient *authclient.Client
+	if cfg.Auth.Host != "" {
+		clientCreds, err := mtls.ClientCredentials(cfg.TLS)
+		if err != nil {
+			panic(err)
+		}
+		authConn := grpcconn.New(log,
+			fmt.Sprintf("%s:%d", cfg.Auth.Host, cfg.Auth.Port),
+			keepalive.ClientParameters{
+				Time:                cfg.Auth.KeepaliveTime,
+				Timeout:             cfg.Auth.KeepaliveTimeout,
+				PermitWithoutStream: true,
+			},
+			grpc.WithTransportCredentials(clientCreds),
+		)
+		authClient = authclient.New(log, authConn)
+	} else if !cfg.TLS.VerifiesClients() {
+		// Without tokens the caller is only known from the metadata of a
+		// gateway verified over mutual TLS.
+		panic("either auth host or tls with ca and trusted_clients must be set")
+	}
+
+	application := app.New(log, storage, cfg.Grpc.Port, cfg.Grpc.Timeout, authClient, serverCreds, cfg.TLS.TrustedClients)
 
 	go func() {
 		application.GRPCServer.MustRun()
@@ -33,7 +67,7 @@ func main() {
 	<-stop
 
 	log.Info("Stoping application")
-	application.GRPCServer.Stop()
+	application.Stop()
 
 	log.Info("Closing conn to DB")
 	storage.DB.Close()
@@ -4,19 +4,39 @@ import (
 	grpcapp "commentsManageService/internal/app/grpc"
 	"commentsManageService/internal/domain/interfaces/storage"
 	commentservice "commentsManageService/internal/service/commentService"
+	"commentsManageService/pkg/lib/logger/sl"
 	"log/slog"
+	"time"
+
+	"github.com/chas3air/shared/authclient"
+	"google.golang.org/grpc/credentials"
 )
 
 type App struct {
+	log        *slog.Logger
 	GRPCServer *grpcapp.App
+	authClient *authclient.Client
 }
 
-func New(log *slog.Logger, storage storage.CommentStorage, port int) *App {
+func New(log *slog.Logger, storage storage.CommentStorage, port int, timeout time.Duration, authClient *authclient.Client, creds credentials.TransportCredentials, trustedClients []string) *App {
 	// storage := psqlstorage.New(log, os.Getenv("DATABASE_URL"))
 	commentsservice := commentservice.New(log, storage)
 
-	grpcapp := grpcapp.New(log, commentsservice, port)
+	grpcapp := grpcapp.New(log, commentsservice, port, timeout, authClient, creds, trustedClients)
 	return &App{
+		log:        log,
 		GRPCServer: grpcapp,
+		authClient: authClient,
+	}
+}
+
+// Stop stops the gRPC server, then closes the connection to Auth it used.
+func (a *App) Stop() {
+	a.GRPCServer.Stop()
+
+	if a.authClient != nil {
+		if err := a.authClient.Close(); err != nil {
+			a.log.Error("Failed to close connection to Auth", sl.Err(err))
+		}
 	}
 }
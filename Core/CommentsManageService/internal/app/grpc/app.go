@@ -6,18 +6,48 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"time"
 
+	"github.com/chas3air/shared/authclient"
+	"github.com/chas3air/shared/deadline"
+	"github.com/chas3air/shared/mtls"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 )
 
+// keepaliveMinTime is the shortest keepalive ping interval accepted from
+// clients; more frequent pings close the connection.
+const keepaliveMinTime = 10 * time.Second
+
 type App struct {
 	log        *slog.Logger
 	gRPCServer *grpc.Server
 	port       int
 }
 
-func New(log *slog.Logger, commentsManService service.CommentService, port int) *App {
-	gRPCServer := grpc.NewServer()
+// New builds the gRPC server with creds, giving every call at most
+// timeout. Calls from trustedClients, verified over mutual TLS, are marked
+// as such. With authClient set, the bearer tokens of incoming calls are
+// checked with Auth.
+func New(log *slog.Logger, commentsManService service.CommentService, port int, timeout time.Duration, authClient *authclient.Client, creds credentials.TransportCredentials, trustedClients []string) *App {
+	opts := []grpc.ServerOption{
+		grpc.Creds(creds),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			// Clients keep their connections open and ping them when idle.
+			MinTime:             keepaliveMinTime,
+			PermitWithoutStream: true,
+		}),
+	}
+	interceptors := []grpc.UnaryServerInterceptor{
+		deadline.UnaryServerInterceptor(timeout),
+		mtls.UnaryServerInterceptor(trustedClients),
+	}
+	if authClient != nil {
+		interceptors = append(interceptors, authClient.UnaryServerInterceptor)
+	}
+	opts = append(opts, grpc.ChainUnaryInterceptor(interceptors...))
+	gRPCServer := grpc.NewServer(opts...)
 
 	grpccomments.Register(gRPCServer, commentsManService, log)
 
@@ -10,6 +10,7 @@ import (
 type CommentService interface {
 	GetCommentById(context.Context, uuid.UUID) (models.Comment, error)
 	GetCommentsByArticleId(context.Context, uuid.UUID) ([]models.Comment, error)
+	GetCommentsByOwnerId(context.Context, uuid.UUID) ([]models.Comment, error)
 	Insert(context.Context, models.Comment) (models.Comment, error)
 	Delete(context.Context, uuid.UUID) (models.Comment, error)
 }
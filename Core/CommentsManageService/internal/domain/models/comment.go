@@ -8,8 +8,8 @@ import (
 
 type Comment struct {
 	Id        uuid.UUID `json:"id,omitempty"`
-	ArticleId uuid.UUID `json:"article_id,omitempty"`
+	ArticleId uuid.UUID `json:"article_id,omitempty" validate:"required"`
 	OwnerId   uuid.UUID `json:"owner_id,omitempty"`
 	CreatedAt time.Time `json:"created_at,omitempty"`
-	Content   string    `json:"content,omitempty"`
+	Content   string    `json:"content,omitempty" validate:"required"`
 }
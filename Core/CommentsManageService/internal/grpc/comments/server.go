@@ -11,6 +11,7 @@ import (
 	service_error "commentsManageService/internal/service"
 
 	cmv1 "github.com/chas3air/protos/gen/go/commentsManager"
+	"github.com/chas3air/shared/validation"
 	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -117,6 +118,51 @@ func (s *serverAPI) GetCommentsByArticleId(ctx context.Context, req *cmv1.GetCom
 	}, nil
 }
 
+func (s *serverAPI) GetCommentsByOwnerId(ctx context.Context, req *cmv1.GetCommentsByOwnerIdRequest) (*cmv1.GetCommentsByOwnerIdResponse, error) {
+	const op = "grpc.commentsManager.getCommentsByOwnerId"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	id_s := req.GetOwnerId()
+	if id_s == "" {
+		log.Error("Failed to get owner_id", sl.Err(errors.New("required parametr owner_id")))
+		return nil, status.Error(codes.InvalidArgument, "required parametr owner_id")
+	}
+	parsedUUID, err := uuid.Parse(id_s)
+	if err != nil {
+		log.Error("Invalid owner_id, must be uuid")
+		return nil, status.Error(codes.InvalidArgument, "invalid owner_id, must be uuid")
+	}
+
+	commentsFromDB, err := s.commentService.GetCommentsByOwnerId(ctx, parsedUUID)
+	if err != nil {
+		log.Error("Error retrieving comments", sl.Err(err))
+		return nil, status.Error(codes.Internal, "error retrieving comments")
+	}
+
+	resp_comments := make([]*cmv1.Comment, 0, 10)
+	for _, comment := range commentsFromDB {
+		profiled_comment, err := cmprofiles.ComToProtoCom(comment)
+		if err != nil {
+			log.Warn("Wrong structure", sl.Err(err))
+			continue
+		}
+
+		resp_comments = append(resp_comments, profiled_comment)
+	}
+
+	return &cmv1.GetCommentsByOwnerIdResponse{
+		Comments: resp_comments,
+	}, nil
+}
+
 func (s *serverAPI) Insert(ctx context.Context, req *cmv1.InsertRequest) (*cmv1.InsertResponse, error) {
 	const op = "grpc.commentsManager.insert"
 	log := s.log.With(
@@ -141,19 +187,39 @@ func (s *serverAPI) Insert(ctx context.Context, req *cmv1.InsertRequest) (*cmv1.
 		return nil, status.Error(codes.InvalidArgument, "wrong structure")
 	}
 
-	_, err = s.commentService.Insert(ctx, commentForInsert)
+	if err := validation.Struct(commentForInsert, "invalid comment"); err != nil {
+		log.Warn("Invalid comment", sl.Err(err))
+		return nil, err
+	}
+
+	inserted_comment, err := s.commentService.Insert(ctx, commentForInsert)
 	if err != nil {
 		if errors.Is(err, service_error.ErrAlreadyExists) {
 			log.Warn("Comment already exists", sl.Err(err))
 			return nil, status.Error(codes.AlreadyExists, "comment already exists")
 		}
+		if errors.Is(err, service_error.ErrUntrustedCaller) {
+			return nil, status.Error(codes.PermissionDenied, "caller is named by an untrusted client")
+		}
+		if errors.Is(err, service_error.ErrUnauthenticated) {
+			return nil, status.Error(codes.Unauthenticated, "caller is not authenticated")
+		}
+		if errors.Is(err, service_error.ErrPermissionDenied) {
+			return nil, status.Error(codes.PermissionDenied, "owner_id does not match the caller")
+		}
 
 		log.Error("Failed to insert comment", sl.Err(err))
 		return nil, status.Error(codes.Internal, "failed to insert comment")
 	}
 
+	resp_comment, err := cmprofiles.ComToProtoCom(inserted_comment)
+	if err != nil {
+		log.Error("Wrong structure", sl.Err(err))
+		return nil, status.Error(codes.Internal, "wrong structure")
+	}
+
 	return &cmv1.InsertResponse{
-		Comment: commentFromReq,
+		Comment: resp_comment,
 	}, nil
 }
 
@@ -186,6 +252,15 @@ func (s *serverAPI) Delete(ctx context.Context, req *cmv1.DeleteRequest) (*cmv1.
 			log.Warn("Comment not found", sl.Err(err))
 			return nil, status.Error(codes.NotFound, "comment not found")
 		}
+		if errors.Is(err, service_error.ErrUntrustedCaller) {
+			return nil, status.Error(codes.PermissionDenied, "caller is named by an untrusted client")
+		}
+		if errors.Is(err, service_error.ErrUnauthenticated) {
+			return nil, status.Error(codes.Unauthenticated, "caller is not authenticated")
+		}
+		if errors.Is(err, service_error.ErrPermissionDenied) {
+			return nil, status.Error(codes.PermissionDenied, "only the owner or a moderator may delete the comment")
+		}
 
 		log.Error("Failed to delete comment", sl.Err(err))
 		return nil, status.Error(codes.Internal, "failed to delete comment")
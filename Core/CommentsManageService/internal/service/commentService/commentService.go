@@ -12,9 +12,13 @@ import (
 	service_error "commentsManageService/internal/service"
 	storage_error "commentsManageService/internal/storage"
 
+	"github.com/chas3air/shared/caller"
 	"github.com/google/uuid"
 )
 
+// moderatePermission lets a caller delete comments of other users.
+const moderatePermission = "comments.moderate"
+
 type CommentService struct {
 	log     *slog.Logger
 	storage storage.CommentStorage
@@ -76,8 +80,30 @@ func (c *CommentService) GetCommentsByArticleId(ctx context.Context, aid uuid.UU
 	return comments, nil
 }
 
+func (c *CommentService) GetCommentsByOwnerId(ctx context.Context, uid uuid.UUID) ([]models.Comment, error) {
+	const op = "service.commentService.getCommentsByOwnerId"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	comments, err := c.storage.GetCommentsByOwnerId(ctx, uid)
+	if err != nil {
+		log.Error("Failed to retrieve comment by owner_id", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("Successfully retrieve comments by owner_id")
+	return comments, nil
+}
+
 func (c *CommentService) Insert(ctx context.Context, comment models.Comment) (models.Comment, error) {
-	const op = "service.commentService."
+	const op = "service.commentService.insert"
 	log := c.log.With(
 		slog.String("op", op),
 	)
@@ -88,7 +114,21 @@ func (c *CommentService) Insert(ctx context.Context, comment models.Comment) (mo
 	default:
 	}
 
-	comment, err := c.storage.Insert(ctx, comment)
+	who, err := caller.FromContext(ctx)
+	if err != nil {
+		log.Warn("Caller is not known", sl.Err(err))
+		return models.Comment{}, fmt.Errorf("%s: %w", op, callerError(err))
+	}
+
+	// The author is whoever is authenticated; an owner given by the client
+	// must agree with that.
+	if comment.OwnerId != uuid.Nil && comment.OwnerId != who.Id {
+		log.Warn("Owner does not match caller", slog.String("caller", who.Id.String()))
+		return models.Comment{}, fmt.Errorf("%s: %w", op, service_error.ErrPermissionDenied)
+	}
+	comment.OwnerId = who.Id
+
+	comment, err = c.storage.Insert(ctx, comment)
 	if err != nil {
 		if errors.Is(err, storage_error.ErrAlreadyExists) {
 			log.Warn("Comment already exists", sl.Err(err))
@@ -104,7 +144,7 @@ func (c *CommentService) Insert(ctx context.Context, comment models.Comment) (mo
 }
 
 func (c *CommentService) Delete(ctx context.Context, cid uuid.UUID) (models.Comment, error) {
-	const op = "service.commentService."
+	const op = "service.commentService.delete"
 	log := c.log.With(
 		slog.String("op", op),
 	)
@@ -115,6 +155,28 @@ func (c *CommentService) Delete(ctx context.Context, cid uuid.UUID) (models.Comm
 	default:
 	}
 
+	who, err := caller.FromContext(ctx)
+	if err != nil {
+		log.Warn("Caller is not known", sl.Err(err))
+		return models.Comment{}, fmt.Errorf("%s: %w", op, callerError(err))
+	}
+
+	existing, err := c.storage.GetCommentById(ctx, cid)
+	if err != nil {
+		if errors.Is(err, storage_error.ErrNotFound) {
+			log.Warn("Comment not found", sl.Err(err))
+			return models.Comment{}, fmt.Errorf("%s: %w", op, service_error.ErrNotFound)
+		}
+
+		log.Error("Failed to get comment by id", sl.Err(err))
+		return models.Comment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if existing.OwnerId != who.Id && !who.Can(moderatePermission) {
+		log.Warn("Caller may not delete comment", slog.String("caller", who.Id.String()))
+		return models.Comment{}, fmt.Errorf("%s: %w", op, service_error.ErrPermissionDenied)
+	}
+
 	comment, err := c.storage.Delete(ctx, cid)
 	if err != nil {
 		if errors.Is(err, storage_error.ErrNotFound) {
@@ -129,3 +191,12 @@ func (c *CommentService) Delete(ctx context.Context, cid uuid.UUID) (models.Comm
 	log.Info("Comment deleted successfully")
 	return comment, nil
 }
+
+// callerError tells a caller named by an untrusted client from one that is
+// not known at all.
+func callerError(err error) error {
+	if errors.Is(err, caller.ErrUntrusted) {
+		return service_error.ErrUntrustedCaller
+	}
+	return service_error.ErrUnauthenticated
+}
@@ -0,0 +1,148 @@
+package commentservice
+
+import (
+	"commentsManageService/internal/domain/models"
+	service_error "commentsManageService/internal/service"
+	"commentsManageService/internal/storage/real/mock"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/chas3air/shared/caller"
+	"github.com/chas3air/shared/mtls"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// as returns a context carrying the metadata the gateway sends for a caller.
+func as(id uuid.UUID, permissions ...string) context.Context {
+	md := metadata.Pairs(caller.IdKey, id.String())
+	for _, permission := range permissions {
+		md.Append(caller.PermissionsKey, permission)
+	}
+	return fromGateway(metadata.NewIncomingContext(context.Background(), md))
+}
+
+// fromGateway marks ctx as a call the gateway made over mutual TLS.
+func fromGateway(ctx context.Context) context.Context {
+	ctx = peer.NewContext(ctx, &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "api-gateway"}}}},
+		}},
+	})
+
+	interceptor := mtls.UnaryServerInterceptor([]string{"api-gateway"})
+	_, _ = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(marked context.Context, req any) (any, error) {
+		ctx = marked
+		return nil, nil
+	})
+	return ctx
+}
+
+func newTestService(t *testing.T) (*CommentService, *mock.MemoryStorage) {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	storage := mock.New(log)
+	return New(log, storage), storage
+}
+
+func TestDelete(t *testing.T) {
+	owner := uuid.New()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		missing bool
+		wantErr error
+	}{
+		{name: "owner", ctx: as(owner)},
+		{name: "moderator", ctx: as(uuid.New(), moderatePermission)},
+		{name: "other user", ctx: as(uuid.New(), "comments.create"), wantErr: service_error.ErrPermissionDenied},
+		{name: "no caller", ctx: context.Background(), wantErr: service_error.ErrUnauthenticated},
+		{name: "malformed caller", ctx: fromGateway(metadata.NewIncomingContext(context.Background(), metadata.Pairs(caller.IdKey, "nobody"))), wantErr: service_error.ErrUnauthenticated},
+		{name: "caller named without tls", ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(caller.IdKey, uuid.NewString())), wantErr: service_error.ErrUntrustedCaller},
+		{name: "missing comment", ctx: as(owner), missing: true, wantErr: service_error.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, storage := newTestService(t)
+
+			comment := models.Comment{Id: uuid.New(), ArticleId: uuid.New(), OwnerId: owner, Content: "content"}
+			if _, err := storage.Insert(context.Background(), comment); err != nil {
+				t.Fatal(err)
+			}
+
+			cid := comment.Id
+			if tt.missing {
+				cid = uuid.New()
+			}
+
+			deleted, err := c.Delete(tt.ctx, cid)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+
+			_, err = storage.GetCommentById(context.Background(), comment.Id)
+			kept := err == nil
+			switch {
+			case tt.wantErr == nil && (kept || deleted.Id != comment.Id):
+				t.Error("comment was not deleted")
+			case tt.wantErr != nil && !kept:
+				t.Error("comment deleted although the request was refused")
+			}
+		})
+	}
+}
+
+func TestInsert(t *testing.T) {
+	author := uuid.New()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		ownerId   uuid.UUID
+		wantOwner uuid.UUID
+		wantErr   error
+	}{
+		{name: "owner taken from caller", ctx: as(author), wantOwner: author},
+		{name: "owner matches caller", ctx: as(author), ownerId: author, wantOwner: author},
+		{name: "owner of another user", ctx: as(author), ownerId: uuid.New(), wantErr: service_error.ErrPermissionDenied},
+		{name: "no caller", ctx: context.Background(), wantErr: service_error.ErrUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, storage := newTestService(t)
+
+			input := models.Comment{Id: uuid.New(), ArticleId: uuid.New(), Content: "content", OwnerId: tt.ownerId}
+			comment, err := c.Insert(tt.ctx, input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if stored, _ := storage.GetCommentsByArticleId(context.Background(), input.ArticleId); len(stored) != 0 {
+					t.Error("comment stored although the request was refused")
+				}
+				return
+			}
+
+			stored, err := storage.GetCommentById(context.Background(), comment.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if comment.OwnerId != tt.wantOwner || stored.OwnerId != tt.wantOwner {
+				t.Errorf("owner %s, want %s", comment.OwnerId, tt.wantOwner)
+			}
+		})
+	}
+}
@@ -69,6 +69,28 @@ func (m *MemoryStorage) GetCommentsByArticleId(ctx context.Context, aid uuid.UUI
 	return comments, nil
 }
 
+func (m *MemoryStorage) GetCommentsByOwnerId(ctx context.Context, uid uuid.UUID) ([]models.Comment, error) {
+	const op = "storage.memory.getCommentsByOwnerId"
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var comments []models.Comment
+	for _, comment := range m.comments {
+		if comment.OwnerId == uid {
+			comments = append(comments, comment)
+		}
+	}
+
+	return comments, nil
+}
+
 func (m *MemoryStorage) Insert(ctx context.Context, comment models.Comment) (models.Comment, error) {
 	const op = "storage.memory.insert"
 	log := m.log.With(slog.String("op", op))
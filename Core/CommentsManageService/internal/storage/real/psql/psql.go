@@ -116,6 +116,42 @@ func (p *PsqlStorage) GetCommentsByArticleId(ctx context.Context, aid uuid.UUID)
 	return comments, nil
 }
 
+func (p *PsqlStorage) GetCommentsByOwnerId(ctx context.Context, uid uuid.UUID) ([]models.Comment, error) {
+	const op = "storage.psql.getCommentsByOwnerId"
+	log := p.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	rows, err := p.DB.QueryContext(ctx, `
+		SELECT * FROM `+CommentTableName+`
+		WHERE owner_id=$1
+	`, uid)
+	if err != nil {
+		log.Error("Error retrieving all comments", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var comments = make([]models.Comment, 0, 10)
+	for rows.Next() {
+		var comment models.Comment
+		if err := rows.Scan(&comment.Id, &comment.ArticleId, &comment.OwnerId, &comment.CreatedAt, &comment.Content); err != nil {
+			log.Error("Error scanning row", sl.Err(err))
+			continue
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, nil
+}
+
 func (p *PsqlStorage) Insert(ctx context.Context, comment models.Comment) (models.Comment, error) {
 	const op = "storage.psql.insert"
 	log := p.log.With(
@@ -18,7 +18,7 @@ func main() {
 
 	log.Info("starting application", slog.Any("config:", cfg))
 
-	application := app.New(log, cfg.Grpc.Port)
+	application := app.New(log, cfg)
 
 	go func() {
 		application.GRPCServer.MustRun()
@@ -29,6 +29,6 @@ func main() {
 
 	<-stop
 
-	application.GRPCServer.Stop()
+	application.Stop()
 	log.Info("application stopped")
 }
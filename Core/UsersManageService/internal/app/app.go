@@ -1,24 +1,76 @@
 package app
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 	grpcapp "usersManageService/internal/app/grpc"
 	usermanager "usersManageService/internal/services/usersManager"
 	psqlstorage "usersManageService/internal/storage/real/psql"
+	"usersManageService/pkg/config"
+	"usersManageService/pkg/lib/logger/sl"
+
+	"github.com/chas3air/shared/authclient"
+	"github.com/chas3air/shared/grpcconn"
+	"github.com/chas3air/shared/mtls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 )
 
 type App struct {
+	log        *slog.Logger
 	GRPCServer *grpcapp.App
+	authClient *authclient.Client
 }
 
-func New(log *slog.Logger, port int) *App {
+func New(log *slog.Logger, cfg *config.Config) *App {
 	storage := psqlstorage.New(log, os.Getenv("DATABASE_URL"))
 	//storage := mock.New()
-	usermanager := usermanager.New(log, storage)
+	usermanager := usermanager.New(log, storage, cfg.PasswordCost)
+
+	serverCreds, err := mtls.ServerCredentials(cfg.TLS)
+	if err != nil {
+		panic(err)
+	}
+	// Auth changes users without a token of theirs, so it must be verified
+	// as a trusted client over mutual TLS.
+	if !cfg.TLS.VerifiesClients() {
+		panic("tls must be enabled with ca and trusted_clients")
+	}
+
+	var authClient *authclient.Client
+	if cfg.Auth.Host != "" {
+		clientCreds, err := mtls.ClientCredentials(cfg.TLS)
+		if err != nil {
+			panic(err)
+		}
+		authConn := grpcconn.New(log,
+			fmt.Sprintf("%s:%d", cfg.Auth.Host, cfg.Auth.Port),
+			keepalive.ClientParameters{
+				Time:                cfg.Auth.KeepaliveTime,
+				Timeout:             cfg.Auth.KeepaliveTimeout,
+				PermitWithoutStream: true,
+			},
+			grpc.WithTransportCredentials(clientCreds),
+		)
+		authClient = authclient.New(log, authConn)
+	}
 
-	grpcapp := grpcapp.New(log, usermanager, port)
+	grpcapp := grpcapp.New(log, usermanager, cfg.Grpc.Port, cfg.Grpc.Timeout, authClient, serverCreds, cfg.TLS.TrustedClients)
 	return &App{
+		log:        log,
 		GRPCServer: grpcapp,
+		authClient: authClient,
+	}
+}
+
+// Stop stops the gRPC server, then closes the connection to Auth it used.
+func (a *App) Stop() {
+	a.GRPCServer.Stop()
+
+	if a.authClient != nil {
+		if err := a.authClient.Close(); err != nil {
+			a.log.Error("Failed to close connection to Auth", sl.Err(err))
+		}
 	}
 }
@@ -14,4 +14,5 @@ type Storage interface {
 	Insert(ctx context.Context, user models.User) (models.User, error)
 	Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error)
 	Delete(ctx context.Context, uid uuid.UUID) (models.User, error)
+	SetEmailVerified(ctx context.Context, uid uuid.UUID, verified bool) (models.User, error)
 }
@@ -11,7 +11,9 @@ type UsersManager interface {
 	GetUsers(ctx context.Context) ([]models.User, error)
 	GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (models.User, error)
+	VerifyCredentials(ctx context.Context, email string, password string) (models.User, error)
 	Insert(ctx context.Context, user models.User) (models.User, error)
 	Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error)
 	Delete(ctx context.Context, uid uuid.UUID) (models.User, error)
+	SetEmailVerified(ctx context.Context, uid uuid.UUID, verified bool) (models.User, error)
 }
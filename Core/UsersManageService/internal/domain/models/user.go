@@ -7,11 +7,12 @@ import (
 )
 
 type User struct {
-	Id          uuid.UUID `json:"id",omitempty`
-	Email       string    `json:"email" gorm:"unique"`
-	Password    string    `json:"password"`
-	Role        string    `json:"role"`
-	Nick        string    `json:"nick"`
-	Description string    `json:"description"`
-	Birthday    time.Time `json:"birthday"`
+	Id            uuid.UUID `json:"id,omitempty"`
+	Email         string    `json:"email" gorm:"unique" validate:"required,email,max=255"`
+	Password      string    `json:"password" validate:"omitempty,max=72"`
+	Roles         []string  `json:"roles"`
+	Nick          string    `json:"nick" validate:"required,max=50"`
+	Description   string    `json:"description"`
+	Birthday      time.Time `json:"birthday" validate:"notfuture"`
+	EmailVerified bool      `json:"email_verified"`
 }
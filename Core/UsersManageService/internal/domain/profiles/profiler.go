@@ -9,6 +9,7 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// UsrToProtoUsr never copies the password: it does not leave the service.
 func UsrToProtoUsr(user models.User) (*umv1.User, error) {
 	var birthday *timestamppb.Timestamp
 	if !user.Birthday.IsZero() {
@@ -16,13 +17,13 @@ func UsrToProtoUsr(user models.User) (*umv1.User, error) {
 	}
 
 	return &umv1.User{
-		Id:          user.Id.String(),
-		Email:       user.Email,
-		Password:    user.Password,
-		Role:        user.Role,
-		Nick:        user.Nick,
-		Description: user.Description,
-		Birthday:    birthday,
+		Id:            user.Id.String(),
+		Email:         user.Email,
+		Roles:         user.Roles,
+		Nick:          user.Nick,
+		Description:   user.Description,
+		Birthday:      birthday,
+		EmailVerified: user.EmailVerified,
 	}, nil
 }
 
@@ -38,12 +39,13 @@ func ProtoUsrToUsr(proto_usr *umv1.User) (models.User, error) {
 	}
 
 	return models.User{
-		Id:          parsedUUID,
-		Email:       proto_usr.GetEmail(),
-		Password:    proto_usr.GetPassword(),
-		Role:        proto_usr.GetRole(),
-		Nick:        proto_usr.GetNick(),
-		Description: proto_usr.GetDescription(),
-		Birthday:    birthday,
+		Id:            parsedUUID,
+		Email:         proto_usr.GetEmail(),
+		Password:      proto_usr.GetPassword(),
+		Roles:         proto_usr.GetRoles(),
+		Nick:          proto_usr.GetNick(),
+		Description:   proto_usr.GetDescription(),
+		Birthday:      birthday,
+		EmailVerified: proto_usr.GetEmailVerified(),
 	}, nil
 }
@@ -10,6 +10,7 @@ import (
 	"usersManageService/pkg/lib/logger/sl"
 
 	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"github.com/chas3air/shared/validation"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -129,7 +130,7 @@ func (s *serverAPI) GetUserByEmail(ctx context.Context, req *umv1.GetUserByEmail
 	if err != nil {
 		if errors.Is(err, services.ErrNotFound) {
 			log.Warn("User with current email not found", sl.Err(err))
-			return nil, status.Error(codes.InvalidArgument, "user with current email not found")
+			return nil, status.Error(codes.NotFound, "user with current email not found")
 		}
 
 		log.Error("Failed to retrieve user by email", sl.Err(err))
@@ -147,6 +148,41 @@ func (s *serverAPI) GetUserByEmail(ctx context.Context, req *umv1.GetUserByEmail
 	}, nil
 }
 
+func (s *serverAPI) VerifyCredentials(ctx context.Context, req *umv1.VerifyCredentialsRequest) (*umv1.VerifyCredentialsResponse, error) {
+	const op = "grpc.users.verifyCredentials"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	if req.GetEmail() == "" || req.GetPassword() == "" {
+		log.Error("Email and password are required", sl.Err(errors.New("email and password are required")))
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	user, err := s.userManager.VerifyCredentials(ctx, req.GetEmail(), req.GetPassword())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			log.Warn("Invalid credentials", sl.Err(err))
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+
+		log.Error("Failed to verify credentials", sl.Err(err))
+		return nil, status.Error(codes.Internal, "failed to verify credentials")
+	}
+
+	return &umv1.VerifyCredentialsResponse{
+		Id:            user.Id.String(),
+		Roles:         user.Roles,
+		EmailVerified: user.EmailVerified,
+	}, nil
+}
+
 func (s *serverAPI) Insert(ctx context.Context, req *umv1.InsertRequest) (*umv1.InsertResponse, error) {
 	const op = "grpc.users.insertArticle"
 	log := s.log.With(
@@ -170,8 +206,21 @@ func (s *serverAPI) Insert(ctx context.Context, req *umv1.InsertRequest) (*umv1.
 		return nil, status.Error(codes.InvalidArgument, "wrong structure")
 	}
 
-	_, err = s.userManager.Insert(ctx, parsedUser)
+	if err := validation.Struct(parsedUser, "invalid user"); err != nil {
+		log.Warn("Invalid user", sl.Err(err))
+		return nil, err
+	}
+
+	inserted_user, err := s.userManager.Insert(ctx, parsedUser)
 	if err != nil {
+		if errors.Is(err, services.ErrUnauthenticated) {
+			log.Warn("Caller is not authenticated", sl.Err(err))
+			return nil, status.Error(codes.Unauthenticated, "caller is not authenticated")
+		}
+		if errors.Is(err, services.ErrPermissionDenied) {
+			log.Warn("Caller may not change user", sl.Err(err))
+			return nil, status.Error(codes.PermissionDenied, "only a user manager may create users")
+		}
 		if errors.Is(err, services.ErrAlreadyExists) {
 			log.Warn("User already exists", sl.Err(err))
 			return nil, status.Error(codes.AlreadyExists, "user already exists")
@@ -181,8 +230,14 @@ func (s *serverAPI) Insert(ctx context.Context, req *umv1.InsertRequest) (*umv1.
 		return nil, status.Error(codes.InvalidArgument, "failed to insert user")
 	}
 
+	profiled_user, err := profiles.UsrToProtoUsr(inserted_user)
+	if err != nil {
+		log.Error("Wrong structure, failed to customize", sl.Err(err))
+		return nil, status.Error(codes.Internal, "wrong structure")
+	}
+
 	return &umv1.InsertResponse{
-		User: req.GetUser(),
+		User: profiled_user,
 	}, nil
 }
 
@@ -209,6 +264,11 @@ func (s *serverAPI) Update(ctx context.Context, req *umv1.UpdateRequest) (*umv1.
 		return nil, status.Error(codes.InvalidArgument, "wrong structure")
 	}
 
+	if err := validation.Struct(parsedUser, "invalid user"); err != nil {
+		log.Warn("Invalid user", sl.Err(err))
+		return nil, err
+	}
+
 	if req.GetId() == "" {
 		log.Error("Id is required", sl.Err(errors.New("id is required")))
 		return nil, status.Error(codes.InvalidArgument, "id is required")
@@ -220,8 +280,16 @@ func (s *serverAPI) Update(ctx context.Context, req *umv1.UpdateRequest) (*umv1.
 		return nil, status.Error(codes.InvalidArgument, "Invalid id, must be uuid")
 	}
 
-	_, err = s.userManager.Update(ctx, parsedUUID, parsedUser)
+	updated_user, err := s.userManager.Update(ctx, parsedUUID, parsedUser)
 	if err != nil {
+		if errors.Is(err, services.ErrUnauthenticated) {
+			log.Warn("Caller is not authenticated", sl.Err(err))
+			return nil, status.Error(codes.Unauthenticated, "caller is not authenticated")
+		}
+		if errors.Is(err, services.ErrPermissionDenied) {
+			log.Warn("Caller may not change user", sl.Err(err))
+			return nil, status.Error(codes.PermissionDenied, "only the user or a user manager may update the user")
+		}
 		if errors.Is(err, services.ErrNotFound) {
 			log.Warn("User with current id not found", sl.Err(err))
 			return nil, status.Error(codes.NotFound, "user with current id not found")
@@ -231,8 +299,14 @@ func (s *serverAPI) Update(ctx context.Context, req *umv1.UpdateRequest) (*umv1.
 		return nil, status.Error(codes.Internal, "failed to update user")
 	}
 
+	profiled_user, err := profiles.UsrToProtoUsr(updated_user)
+	if err != nil {
+		log.Error("Wrong structure, failed to customize", sl.Err(err))
+		return nil, status.Error(codes.Internal, "wrong structure")
+	}
+
 	return &umv1.UpdateResponse{
-		User: req.GetUser(),
+		User: profiled_user,
 	}, nil
 }
 
@@ -260,6 +334,14 @@ func (s *serverAPI) Delete(ctx context.Context, req *umv1.DeleteRequest) (*umv1.
 
 	user, err := s.userManager.Delete(ctx, parsedUUID)
 	if err != nil {
+		if errors.Is(err, services.ErrUnauthenticated) {
+			log.Warn("Caller is not authenticated", sl.Err(err))
+			return nil, status.Error(codes.Unauthenticated, "caller is not authenticated")
+		}
+		if errors.Is(err, services.ErrPermissionDenied) {
+			log.Warn("Caller may not change user", sl.Err(err))
+			return nil, status.Error(codes.PermissionDenied, "only the user or a user manager may delete the user")
+		}
 		if errors.Is(err, services.ErrNotFound) {
 			log.Warn("User with current id not found", sl.Err(err))
 			return nil, status.Error(codes.NotFound, "user with current id not found")
@@ -279,3 +361,51 @@ func (s *serverAPI) Delete(ctx context.Context, req *umv1.DeleteRequest) (*umv1.
 		User: profiled_user,
 	}, nil
 }
+
+func (s *serverAPI) SetEmailVerified(ctx context.Context, req *umv1.SetEmailVerifiedRequest) (*umv1.SetEmailVerifiedResponse, error) {
+	const op = "grpc.users.setEmailVerified"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	parsedUUID, err := uuid.Parse(req.GetId())
+	if err != nil {
+		log.Error("Invalid id, must be uuid", sl.Err(err))
+		return nil, status.Error(codes.InvalidArgument, "invalid id, must be uuid")
+	}
+
+	user, err := s.userManager.SetEmailVerified(ctx, parsedUUID, req.GetEmailVerified())
+	if err != nil {
+		if errors.Is(err, services.ErrUnauthenticated) {
+			log.Warn("Caller is not authenticated", sl.Err(err))
+			return nil, status.Error(codes.Unauthenticated, "caller is not authenticated")
+		}
+		if errors.Is(err, services.ErrPermissionDenied) {
+			log.Warn("Caller may not change user", sl.Err(err))
+			return nil, status.Error(codes.PermissionDenied, "only Auth or a user manager may verify the email")
+		}
+		if errors.Is(err, services.ErrNotFound) {
+			log.Warn("User with current id not found", sl.Err(err))
+			return nil, status.Error(codes.NotFound, "user with current id not found")
+		}
+
+		log.Error("Failed to set email verification", sl.Err(err))
+		return nil, status.Error(codes.Internal, "failed to set email verification")
+	}
+
+	profiled_user, err := profiles.UsrToProtoUsr(user)
+	if err != nil {
+		log.Error("Wrong structure, failed to customize", sl.Err(err))
+		return nil, status.Error(codes.Internal, "wrong structure")
+	}
+
+	return &umv1.SetEmailVerifiedResponse{
+		User: profiled_user,
+	}, nil
+}
@@ -0,0 +1,44 @@
+package hasher
+
+import (
+	"crypto/subtle"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash is compared against when the account does not exist, so a missing
+// email takes as long to reject as a wrong password.
+const dummyHash = "$2a$10$M5cBhYPRXKbumpD10JVJ4uUb7F453pNdc1E1z88ADJl2btZq73IGe"
+
+// Hash returns a salted bcrypt hash of password with the given cost.
+func Hash(password string, cost int) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Verify checks password against the stored value. Values saved before
+// hashing was introduced are plain text: they are compared in constant time
+// and always reported as needing a rehash, as are hashes with a cost other
+// than the configured one.
+func Verify(stored string, password string, cost int) (ok bool, needsRehash bool) {
+	storedCost, err := bcrypt.Cost([]byte(stored))
+	if err != nil {
+		ok = subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+		return ok, ok
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)); err != nil {
+		return false, false
+	}
+
+	return true, storedCost != cost
+}
+
+// Mismatch burns the same amount of time as Verify against a real hash.
+func Mismatch(password string) {
+	_ = bcrypt.CompareHashAndPassword([]byte(dummyHash), []byte(password))
+}
@@ -5,4 +5,9 @@ import "errors"
 var (
 	ErrNotFound      = errors.New("resource not found")
 	ErrAlreadyExists = errors.New("resource already exists")
+
+	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	ErrUnauthenticated  = errors.New("caller is not authenticated")
+	ErrPermissionDenied = errors.New("permission denied")
 )
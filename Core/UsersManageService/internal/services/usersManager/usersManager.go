@@ -5,27 +5,51 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"usersManageService/internal/domain/interfaces/storage"
 	"usersManageService/internal/domain/models"
+	"usersManageService/internal/lib/hasher"
 	"usersManageService/internal/services"
 	storage_errors "usersManageService/internal/storage"
 	"usersManageService/pkg/lib/logger/sl"
 
+	"github.com/chas3air/shared/authclient"
+	"github.com/chas3air/shared/mtls"
 	"github.com/google/uuid"
 )
 
+// managePermission allows changing any user.
+const managePermission = "users.manage"
+
 type UserManager struct {
-	log     *slog.Logger
-	storage storage.Storage
+	log          *slog.Logger
+	storage      storage.Storage
+	passwordCost int
 }
 
-var ErrInvalidCredentials = errors.New("invalid credentials")
-
-func New(log *slog.Logger, storage storage.Storage) *UserManager {
+func New(log *slog.Logger, storage storage.Storage, passwordCost int) *UserManager {
 	return &UserManager{
-		log:     log,
-		storage: storage,
+		log:          log,
+		storage:      storage,
+		passwordCost: passwordCost,
+	}
+}
+
+// hashPassword replaces the plain text password with its bcrypt hash. Input
+// is always treated as plain text, even when it looks like a hash, so that
+// callers cannot plant a hash of their choosing.
+func (um *UserManager) hashPassword(user models.User) (models.User, error) {
+	if user.Password == "" {
+		return user, nil
+	}
+
+	hash, err := hasher.Hash(user.Password, um.passwordCost)
+	if err != nil {
+		return models.User{}, err
 	}
+	user.Password = hash
+
+	return user, nil
 }
 
 func (um *UserManager) GetUsers(ctx context.Context) ([]models.User, error) {
@@ -98,6 +122,124 @@ func (um *UserManager) GetUserByEmail(ctx context.Context, email string) (models
 	return user, nil
 }
 
+// VerifyCredentials checks password next to the stored hash and returns the
+// user without it. An unknown email and a wrong password are both reported
+// as services.ErrInvalidCredentials. Plain text and outdated hashes are upgraded on
+// success.
+func (um *UserManager) VerifyCredentials(ctx context.Context, email string, password string) (models.User, error) {
+	const op = "services.userManager.VerifyCredentials"
+	log := um.log.With(slog.String("operation", op))
+
+	select {
+	case <-ctx.Done():
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, err := um.storage.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage_errors.ErrNotFound) {
+			log.Warn("User not found")
+			hasher.Mismatch(password)
+			return models.User{}, fmt.Errorf("%s: %w", op, services.ErrInvalidCredentials)
+		}
+
+		log.Error("Failed to retrieve user by email", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ok, needsRehash := hasher.Verify(user.Password, password, um.passwordCost)
+	if !ok {
+		log.Warn("Invalid password", slog.String("uid", user.Id.String()))
+		return models.User{}, fmt.Errorf("%s: %w", op, services.ErrInvalidCredentials)
+	}
+
+	if needsRehash {
+		um.rehashPassword(ctx, user, password)
+	}
+
+	user.Password = ""
+	return user, nil
+}
+
+// rehashPassword stores a fresh hash of password. Failures are only logged:
+// the credentials have already been accepted.
+func (um *UserManager) rehashPassword(ctx context.Context, user models.User, password string) {
+	const op = "services.userManager.rehashPassword"
+	log := um.log.With(
+		slog.String("operation", op),
+		slog.String("uid", user.Id.String()),
+	)
+
+	hash, err := hasher.Hash(password, um.passwordCost)
+	if err != nil {
+		log.Error("Failed to hash password", sl.Err(err))
+		return
+	}
+	user.Password = hash
+
+	if _, err := um.storage.Update(ctx, user.Id, user); err != nil {
+		log.Error("Failed to save rehashed password", sl.Err(err))
+		return
+	}
+
+	log.Info("Password rehashed")
+}
+
+// authorize allows changing the user uid to callers who may manage users,
+// to the user themselves, reported by self, and to trusted clients acting
+// without a user's token: Auth and the gateway. A zero uid allows managers
+// and trusted clients only.
+func (um *UserManager) authorize(ctx context.Context, uid uuid.UUID, log *slog.Logger) (self bool, err error) {
+	principal, ok := authclient.FromContext(ctx)
+	if !ok {
+		if client, ok := mtls.TrustedClient(ctx); ok {
+			log.Debug("Call made by trusted client", slog.String("client", client))
+			return false, nil
+		}
+
+		log.Warn("Anonymous call from untrusted client")
+		return false, services.ErrUnauthenticated
+	}
+
+	if slices.Contains(principal.Permissions, managePermission) {
+		return false, nil
+	}
+	if uid != uuid.Nil && principal.Id == uid {
+		return true, nil
+	}
+
+	log.Warn("Caller may not change user", slog.String("caller", principal.Id.String()))
+	return false, services.ErrPermissionDenied
+}
+
+// authorizeOwnUpdate keeps users from changing their own roles, email and
+// password directly; those go through managers and Auth.
+func (um *UserManager) authorizeOwnUpdate(ctx context.Context, uid uuid.UUID, user models.User, log *slog.Logger) error {
+	if user.Password != "" {
+		log.Warn("User may not set own password directly")
+		return services.ErrPermissionDenied
+	}
+
+	current, err := um.storage.GetUserById(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage_errors.ErrNotFound) {
+			log.Warn("User not found for update", sl.Err(err))
+			return services.ErrNotFound
+		}
+
+		log.Error("Failed to retrieve user by id", sl.Err(err))
+		return err
+	}
+
+	if user.Email != current.Email || (user.Roles != nil && !slices.Equal(user.Roles, current.Roles)) {
+		log.Warn("User may not change own email or roles")
+		return services.ErrPermissionDenied
+	}
+
+	return nil
+}
+
 func (um *UserManager) Insert(ctx context.Context, user models.User) (models.User, error) {
 	const op = "services.userManager.Insert"
 	log := um.log.With(slog.String("operation", op))
@@ -108,7 +250,17 @@ func (um *UserManager) Insert(ctx context.Context, user models.User) (models.Use
 	default:
 	}
 
-	user, err := um.storage.Insert(ctx, user)
+	if _, err := um.authorize(ctx, uuid.Nil, log); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := um.hashPassword(user)
+	if err != nil {
+		log.Error("Failed to hash password", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err = um.storage.Insert(ctx, user)
 	if err != nil {
 		if errors.Is(err, storage_errors.ErrAlreadyExists) {
 			log.Warn("User already exists", sl.Err(err))
@@ -133,7 +285,23 @@ func (um *UserManager) Update(ctx context.Context, uid uuid.UUID, user models.Us
 	default:
 	}
 
-	user, err := um.storage.Update(ctx, uid, user)
+	self, err := um.authorize(ctx, uid, log)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if self {
+		if err := um.authorizeOwnUpdate(ctx, uid, user, log); err != nil {
+			return models.User{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	user, err = um.hashPassword(user)
+	if err != nil {
+		log.Error("Failed to hash password", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err = um.storage.Update(ctx, uid, user)
 	if err != nil {
 		if errors.Is(err, storage_errors.ErrNotFound) {
 			log.Warn("User not found for update", sl.Err(err))
@@ -158,6 +326,10 @@ func (um *UserManager) Delete(ctx context.Context, uid uuid.UUID) (models.User,
 	default:
 	}
 
+	if _, err := um.authorize(ctx, uid, log); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	user, err := um.storage.Delete(ctx, uid)
 	if err != nil {
 		if errors.Is(err, storage_errors.ErrNotFound) {
@@ -172,3 +344,38 @@ func (um *UserManager) Delete(ctx context.Context, uid uuid.UUID) (models.User,
 	log.Info("User deleted successfully")
 	return user, nil
 }
+
+func (um *UserManager) SetEmailVerified(ctx context.Context, uid uuid.UUID, verified bool) (models.User, error) {
+	const op = "services.userManager.SetEmailVerified"
+	log := um.log.With(slog.String("operation", op))
+
+	select {
+	case <-ctx.Done():
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	// Verifying an email is up to Auth, which checked the link sent to it.
+	self, err := um.authorize(ctx, uid, log)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if self {
+		log.Warn("User may not verify own email directly")
+		return models.User{}, fmt.Errorf("%s: %w", op, services.ErrPermissionDenied)
+	}
+
+	user, err := um.storage.SetEmailVerified(ctx, uid, verified)
+	if err != nil {
+		if errors.Is(err, storage_errors.ErrNotFound) {
+			log.Warn("User not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, services.ErrNotFound)
+		}
+
+		log.Error("Failed to set email verification", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("Email verification updated", slog.Bool("email_verified", verified))
+	return user, nil
+}
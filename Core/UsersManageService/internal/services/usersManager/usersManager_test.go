@@ -0,0 +1,301 @@
+package usermanager
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"usersManageService/internal/domain/models"
+	"usersManageService/internal/services"
+	storage_errors "usersManageService/internal/storage"
+
+	"github.com/chas3air/shared/authclient"
+	"github.com/chas3air/shared/mtls"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// memStorage keeps users in memory.
+type memStorage struct {
+	users map[uuid.UUID]models.User
+}
+
+func (m *memStorage) GetUsers(ctx context.Context) ([]models.User, error) {
+	return nil, nil
+}
+
+func (m *memStorage) GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error) {
+	user, ok := m.users[uid]
+	if !ok {
+		return models.User{}, storage_errors.ErrNotFound
+	}
+	return user, nil
+}
+
+func (m *memStorage) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	return models.User{}, storage_errors.ErrNotFound
+}
+
+func (m *memStorage) Insert(ctx context.Context, user models.User) (models.User, error) {
+	m.users[user.Id] = user
+	return user, nil
+}
+
+func (m *memStorage) Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error) {
+	if _, ok := m.users[uid]; !ok {
+		return models.User{}, storage_errors.ErrNotFound
+	}
+	user.Id = uid
+	m.users[uid] = user
+	return user, nil
+}
+
+func (m *memStorage) Delete(ctx context.Context, uid uuid.UUID) (models.User, error) {
+	user, ok := m.users[uid]
+	if !ok {
+		return models.User{}, storage_errors.ErrNotFound
+	}
+	delete(m.users, uid)
+	return user, nil
+}
+
+func (m *memStorage) SetEmailVerified(ctx context.Context, uid uuid.UUID, verified bool) (models.User, error) {
+	user, ok := m.users[uid]
+	if !ok {
+		return models.User{}, storage_errors.ErrNotFound
+	}
+	user.EmailVerified = verified
+	m.users[uid] = user
+	return user, nil
+}
+
+var (
+	owner = uuid.New()
+	other = uuid.New()
+)
+
+func newManager() *UserManager {
+	storage := &memStorage{users: map[uuid.UUID]models.User{
+		owner: {Id: owner, Email: "owner@example.com", Roles: []string{"user"}, Nick: "owner"},
+	}}
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), storage, bcrypt.MinCost)
+}
+
+// from returns a context of a call made over mutual TLS by client, which
+// the server trusts when it is "auth".
+func from(client string) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: client}}}},
+		}},
+	})
+
+	interceptor := mtls.UnaryServerInterceptor([]string{"auth"})
+	_, _ = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(marked context.Context, req any) (any, error) {
+		ctx = marked
+		return nil, nil
+	})
+	return ctx
+}
+
+func as(id uuid.UUID, permissions ...string) context.Context {
+	return authclient.NewContext(context.Background(), authclient.Principal{Id: id, Permissions: permissions})
+}
+
+func TestAuthorization(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		call    func(ctx context.Context, um *UserManager) error
+		wantErr error
+	}{
+		{
+			name:    "anonymous insert",
+			ctx:     context.Background(),
+			call:    insert,
+			wantErr: services.ErrUnauthenticated,
+		},
+		{
+			name:    "insert from untrusted client",
+			ctx:     from("article_service"),
+			call:    insert,
+			wantErr: services.ErrUnauthenticated,
+		},
+		{
+			name: "insert from auth",
+			ctx:  from("auth"),
+			call: insert,
+		},
+		{
+			name:    "insert by user",
+			ctx:     as(owner),
+			call:    insert,
+			wantErr: services.ErrPermissionDenied,
+		},
+		{
+			name: "insert by manager",
+			ctx:  as(other, managePermission),
+			call: insert,
+		},
+		{
+			name:    "anonymous update",
+			ctx:     context.Background(),
+			call:    updateNick,
+			wantErr: services.ErrUnauthenticated,
+		},
+		{
+			name: "own profile",
+			ctx:  as(owner),
+			call: updateNick,
+		},
+		{
+			name:    "other user's profile",
+			ctx:     as(other),
+			call:    updateNick,
+			wantErr: services.ErrPermissionDenied,
+		},
+		{
+			name: "other user's profile by manager",
+			ctx:  as(other, managePermission),
+			call: updateNick,
+		},
+		{
+			name:    "own roles",
+			ctx:     as(owner),
+			call:    update(models.User{Email: "owner@example.com", Roles: []string{"admin"}, Nick: "owner"}),
+			wantErr: services.ErrPermissionDenied,
+		},
+		{
+			name:    "own email",
+			ctx:     as(owner),
+			call:    update(models.User{Email: "new@example.com", Nick: "owner"}),
+			wantErr: services.ErrPermissionDenied,
+		},
+		{
+			name:    "own password",
+			ctx:     as(owner),
+			call:    update(models.User{Email: "owner@example.com", Password: "secret", Nick: "owner"}),
+			wantErr: services.ErrPermissionDenied,
+		},
+		{
+			name: "password from auth",
+			ctx:  from("auth"),
+			call: update(models.User{Email: "owner@example.com", Password: "secret", Nick: "owner"}),
+		},
+		{
+			name: "own account",
+			ctx:  as(owner),
+			call: remove,
+		},
+		{
+			name:    "other user's account",
+			ctx:     as(other),
+			call:    remove,
+			wantErr: services.ErrPermissionDenied,
+		},
+		{
+			name:    "anonymous delete",
+			ctx:     context.Background(),
+			call:    remove,
+			wantErr: services.ErrUnauthenticated,
+		},
+		{
+			name:    "own email verification",
+			ctx:     as(owner),
+			call:    verify,
+			wantErr: services.ErrPermissionDenied,
+		},
+		{
+			name: "email verification from auth",
+			ctx:  from("auth"),
+			call: verify,
+		},
+		{
+			name:    "anonymous email verification",
+			ctx:     context.Background(),
+			call:    verify,
+			wantErr: services.ErrUnauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(tt.ctx, newManager())
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHashesPasswords(t *testing.T) {
+	planted, err := bcrypt.GenerateFromPassword([]byte("chosen"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{name: "plain text", password: "secret"},
+		{name: "looks like a hash", password: string(planted)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memStorage{users: map[uuid.UUID]models.User{}}
+			um := New(slog.New(slog.NewTextHandler(io.Discard, nil)), storage, bcrypt.MinCost)
+
+			user, err := um.Insert(from("auth"), models.User{Id: uuid.New(), Email: "user@example.com", Password: tt.password})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := um.Update(from("auth"), user.Id, models.User{Email: "user@example.com", Password: tt.password}); err != nil {
+				t.Fatal(err)
+			}
+
+			stored := storage.users[user.Id].Password
+			if stored == tt.password {
+				t.Fatal("password stored as given")
+			}
+			if err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(tt.password)); err != nil {
+				t.Errorf("stored hash does not match the given password: %v", err)
+			}
+		})
+	}
+}
+
+func insert(ctx context.Context, um *UserManager) error {
+	_, err := um.Insert(ctx, models.User{Id: uuid.New(), Email: "new@example.com", Password: "secret", Nick: "new"})
+	return err
+}
+
+func update(user models.User) func(ctx context.Context, um *UserManager) error {
+	return func(ctx context.Context, um *UserManager) error {
+		_, err := um.Update(ctx, owner, user)
+		return err
+	}
+}
+
+var updateNick = update(models.User{Email: "owner@example.com", Roles: []string{"user"}, Nick: "renamed"})
+
+func remove(ctx context.Context, um *UserManager) error {
+	_, err := um.Delete(ctx, owner)
+	return err
+}
+
+func verify(ctx context.Context, um *UserManager) error {
+	_, err := um.SetEmailVerified(ctx, owner, true)
+	return err
+}
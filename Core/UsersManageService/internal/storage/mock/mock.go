@@ -18,13 +18,14 @@ func New() *MockStorage {
 	return &MockStorage{
 		users: map[uuid.UUID]models.User{
 			generated_id: {
-				Id:          generated_id,
-				Email:       "testuser@example.com",
-				Password:    "securepassword",
-				Role:        "admin",
-				Nick:        "test_nick",
-				Description: "Hello, my name is test_nick",
-				Birthday:    time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+				Id:            generated_id,
+				Email:         "testuser@example.com",
+				Password:      "securepassword",
+				Roles:         []string{"admin"},
+				Nick:          "test_nick",
+				Description:   "Hello, my name is test_nick",
+				Birthday:      time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+				EmailVerified: true,
 			},
 		},
 	}
@@ -86,3 +87,14 @@ func (m *MockStorage) Delete(ctx context.Context, id uuid.UUID) (models.User, er
 	delete(m.users, id)
 	return user, nil
 }
+
+// SetEmailVerified implements storage.Storage.
+func (m *MockStorage) SetEmailVerified(ctx context.Context, id uuid.UUID, verified bool) (models.User, error) {
+	user, exists := m.users[id]
+	if !exists {
+		return models.User{}, storage.ErrNotFound
+	}
+	user.EmailVerified = verified
+	m.users[id] = user
+	return user, nil
+}
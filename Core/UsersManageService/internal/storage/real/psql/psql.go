@@ -61,7 +61,7 @@ func (ps *PsqlStorage) GetUsers(ctx context.Context) ([]models.User, error) {
 	default:
 	}
 
-	rows, err := ps.DB.QueryContext(ctx, `SELECT id, email, password, role, nick, description, birthday FROM `+UsersTableName+`;`)
+	rows, err := ps.DB.QueryContext(ctx, `SELECT id, email, password, roles, nick, description, birthday, email_verified FROM `+UsersTableName+`;`)
 	if err != nil {
 		log.Error("Error retrieving all users", sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -71,7 +71,7 @@ func (ps *PsqlStorage) GetUsers(ctx context.Context) ([]models.User, error) {
 	var users []models.User
 	for rows.Next() {
 		var user models.User
-		if err := rows.Scan(&user.Id, &user.Email, &user.Password, &user.Role, &user.Nick, &user.Description, &user.Birthday); err != nil {
+		if err := rows.Scan(&user.Id, &user.Email, &user.Password, pq.Array(&user.Roles), &user.Nick, &user.Description, &user.Birthday, &user.EmailVerified); err != nil {
 			log.Error("-Error scanning row", sl.Err(err))
 			continue
 		}
@@ -95,8 +95,8 @@ func (ps *PsqlStorage) GetUserById(ctx context.Context, uid uuid.UUID) (models.U
 	}
 
 	var user models.User
-	err := ps.DB.QueryRowContext(ctx, `SELECT id, email, password, role, nick, description, birthday FROM `+UsersTableName+` WHERE id = $1;`, uid).
-		Scan(&user.Id, &user.Email, &user.Password, &user.Role, &user.Nick, &user.Description, &user.Birthday)
+	err := ps.DB.QueryRowContext(ctx, `SELECT id, email, password, roles, nick, description, birthday, email_verified FROM `+UsersTableName+` WHERE id = $1;`, uid).
+		Scan(&user.Id, &user.Email, &user.Password, pq.Array(&user.Roles), &user.Nick, &user.Description, &user.Birthday, &user.EmailVerified)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			log.Error("User with current id not found", sl.Err(err))
@@ -123,8 +123,8 @@ func (ps *PsqlStorage) GetUserByEmail(ctx context.Context, email string) (models
 	}
 
 	var user models.User
-	err := ps.DB.QueryRowContext(ctx, `SELECT id, email, password, role, nick, description, birthday FROM `+UsersTableName+` WHERE email = $1;`, email).
-		Scan(&user.Id, &user.Email, &user.Password, &user.Role, &user.Nick, &user.Description, &user.Birthday)
+	err := ps.DB.QueryRowContext(ctx, `SELECT id, email, password, roles, nick, description, birthday, email_verified FROM `+UsersTableName+` WHERE email = $1;`, email).
+		Scan(&user.Id, &user.Email, &user.Password, pq.Array(&user.Roles), &user.Nick, &user.Description, &user.Birthday, &user.EmailVerified)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			log.Warn("User with current email not found", sl.Err(err))
@@ -151,8 +151,8 @@ func (ps *PsqlStorage) Insert(ctx context.Context, user models.User) (models.Use
 	}
 
 	_, err := ps.DB.ExecContext(ctx, `
-		INSERT INTO `+UsersTableName+` (id, email, password, role, nick, description, birthday) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7);`, user.Id, user.Email, user.Password, user.Role, user.Nick, user.Description, user.Birthday)
+		INSERT INTO `+UsersTableName+` (id, email, password, roles, nick, description, birthday, email_verified) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`, user.Id, user.Email, user.Password, pq.Array(rolesOrEmpty(user.Roles)), user.Nick, user.Description, user.Birthday, user.EmailVerified)
 
 	if err != nil {
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
@@ -167,6 +167,15 @@ func (ps *PsqlStorage) Insert(ctx context.Context, user models.User) (models.Use
 	return user, nil
 }
 
+// rolesOrEmpty keeps a nil list of roles, which pq binds as NULL, away from
+// the NOT NULL roles column.
+func rolesOrEmpty(roles []string) []string {
+	if roles == nil {
+		return []string{}
+	}
+	return roles
+}
+
 func (ps *PsqlStorage) Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error) {
 	const op = "storage.psql.update"
 	log := ps.log.With(
@@ -179,25 +188,57 @@ func (ps *PsqlStorage) Update(ctx context.Context, uid uuid.UUID, user models.Us
 	default:
 	}
 
-	result, err := ps.DB.ExecContext(ctx, `
+	// The verification flag is managed by SetEmailVerified; an update only
+	// resets it when the email itself changes. An empty password keeps the
+	// stored hash and nil roles keep the stored roles.
+	err := ps.DB.QueryRowContext(ctx, `
 		UPDATE `+UsersTableName+` 
-		SET email = $1, password = $2, role = $3, nick = $4, description = $5, birthday = $6 
-		WHERE id = $7;`,
-		user.Email, user.Password, user.Role, user.Nick, user.Description, user.Birthday, uid)
+		SET email = $1, password = COALESCE(NULLIF($2, ''), password), roles = COALESCE($3, roles), nick = $4, description = $5, birthday = $6,
+			email_verified = CASE WHEN email = $1 THEN email_verified ELSE FALSE END
+		WHERE id = $7
+		RETURNING roles, email_verified;`,
+		user.Email, user.Password, pq.Array(user.Roles), user.Nick, user.Description, user.Birthday, uid).
+		Scan(pq.Array(&user.Roles), &user.EmailVerified)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Error("Zero rows affected")
+			return models.User{}, fmt.Errorf("%s: %w", op, storage_error.ErrNotFound)
+		}
+
 		log.Error("Error updating user", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Error("Error get rows affected", sl.Err(err))
-		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	return user, nil
+}
+
+func (ps *PsqlStorage) SetEmailVerified(ctx context.Context, uid uuid.UUID, verified bool) (models.User, error) {
+	const op = "storage.psql.setEmailVerified"
+	log := ps.log.With(
+		slog.String("op", op),
+	)
+
+	select {
+	case <-ctx.Done():
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
 	}
 
-	if rowsAffected == 0 {
-		log.Error("Zero rows affected")
-		return models.User{}, fmt.Errorf("%s: %w", op, storage_error.ErrNotFound)
+	var user models.User
+	err := ps.DB.QueryRowContext(ctx, `
+		UPDATE `+UsersTableName+` 
+		SET email_verified = $1 
+		WHERE id = $2
+		RETURNING id, email, password, roles, nick, description, birthday, email_verified;`, verified, uid).
+		Scan(&user.Id, &user.Email, &user.Password, pq.Array(&user.Roles), &user.Nick, &user.Description, &user.Birthday, &user.EmailVerified)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn("User with current id not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storage_error.ErrNotFound)
+		}
+
+		log.Error("Error updating user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return user, nil
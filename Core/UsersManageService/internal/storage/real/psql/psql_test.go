@@ -0,0 +1,165 @@
+package psqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"usersManageService/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+// recorder is a database/sql connector that remembers every statement and
+// its arguments, and answers queries with the single row in returning.
+type recorder struct {
+	mu        sync.Mutex
+	queries   []string
+	args      [][]driver.Value
+	returning []driver.Value
+}
+
+func (r *recorder) Connect(context.Context) (driver.Conn, error) { return &recorderConn{r}, nil }
+func (r *recorder) Driver() driver.Driver                        { return nil }
+
+func (r *recorder) last() (string, []driver.Value) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.queries[len(r.queries)-1], r.args[len(r.args)-1]
+}
+
+type recorderConn struct{ r *recorder }
+
+func (c *recorderConn) Prepare(query string) (driver.Stmt, error) {
+	return &recorderStmt{r: c.r, query: query}, nil
+}
+func (c *recorderConn) Close() error              { return nil }
+func (c *recorderConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+type recorderStmt struct {
+	r     *recorder
+	query string
+}
+
+func (s *recorderStmt) Close() error  { return nil }
+func (s *recorderStmt) NumInput() int { return -1 }
+
+func (s *recorderStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.record(args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *recorderStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.record(args)
+	return &recorderRows{values: s.r.returning}, nil
+}
+
+func (s *recorderStmt) record(args []driver.Value) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+	s.r.queries = append(s.r.queries, s.query)
+	s.r.args = append(s.r.args, slices.Clone(args))
+}
+
+type recorderRows struct {
+	values []driver.Value
+	done   bool
+}
+
+func (r *recorderRows) Columns() []string {
+	return make([]string, len(r.values))
+}
+
+func (r *recorderRows) Close() error { return nil }
+
+func (r *recorderRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func newRecordingStorage(t *testing.T, returning ...driver.Value) (*PsqlStorage, *recorder) {
+	t.Helper()
+
+	rec := &recorder{returning: returning}
+	db := sql.OpenDB(rec)
+	t.Cleanup(func() { db.Close() })
+
+	return &PsqlStorage{
+		log: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		DB:  db,
+	}, rec
+}
+
+func TestInsertBindsRoles(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []string
+		want  driver.Value
+	}{
+		{name: "nil", roles: nil, want: "{}"},
+		{name: "empty", roles: []string{}, want: "{}"},
+		{name: "set", roles: []string{"admin", "moderator"}, want: `{"admin","moderator"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, rec := newRecordingStorage(t)
+
+			_, err := ps.Insert(context.Background(), models.User{Id: uuid.New(), Email: "a@b.c", Roles: tt.roles})
+			if err != nil {
+				t.Fatalf("Insert: %v", err)
+			}
+
+			_, args := rec.last()
+			if got := args[3]; got != tt.want {
+				t.Errorf("roles bound as %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateBindsRoles(t *testing.T) {
+	tests := []struct {
+		name      string
+		roles     []string
+		want      driver.Value
+		stored    string
+		wantRoles []string
+	}{
+		// nil binds NULL so that COALESCE keeps the stored roles.
+		{name: "nil keeps stored", roles: nil, want: nil, stored: "{admin}", wantRoles: []string{"admin"}},
+		{name: "empty clears", roles: []string{}, want: "{}", stored: "{}", wantRoles: []string{}},
+		{name: "set", roles: []string{"moderator"}, want: `{"moderator"}`, stored: "{moderator}", wantRoles: []string{"moderator"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, rec := newRecordingStorage(t, []byte(tt.stored), false)
+
+			user, err := ps.Update(context.Background(), uuid.New(), models.User{Email: "a@b.c", Roles: tt.roles})
+			if err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+
+			query, args := rec.last()
+			if got := args[2]; got != tt.want {
+				t.Errorf("roles bound as %#v, want %#v", got, tt.want)
+			}
+			if !strings.Contains(query, "roles = COALESCE($3, roles)") {
+				t.Errorf("update does not keep the stored roles on NULL:\n%s", query)
+			}
+			if !slices.Equal(user.Roles, tt.wantRoles) {
+				t.Errorf("returned roles %v, want %v", user.Roles, tt.wantRoles)
+			}
+		})
+	}
+}
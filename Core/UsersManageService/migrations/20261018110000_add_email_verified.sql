@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE Users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+-- Accounts created before verification was introduced are trusted as is.
+UPDATE Users SET email_verified = TRUE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE Users DROP COLUMN IF EXISTS email_verified;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE Users ADD COLUMN roles TEXT[] NOT NULL DEFAULT '{}';
+UPDATE Users SET roles = ARRAY[role] WHERE role <> '';
+ALTER TABLE Users DROP COLUMN role;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE Users ADD COLUMN role VARCHAR(50) NOT NULL DEFAULT '';
+-- Only one role survives the rollback; the first one is kept.
+UPDATE Users SET role = COALESCE(roles[1], '');
+ALTER TABLE Users DROP COLUMN roles;
+-- +goose StatementEnd
@@ -0,0 +1,147 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	authv1 "github.com/chas3air/protos/gen/go/auth"
+	"github.com/chas3air/shared/grpcconn"
+	"github.com/chas3air/shared/internal/sl"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AuthorizationKey is the metadata key callers pass their bearer token in.
+const AuthorizationKey = "authorization"
+
+// callerKeys are the metadata keys the gateway used to name the caller
+// before services could check tokens themselves. They are dropped from
+// incoming requests, so they cannot be forged once introspection is on.
+var callerKeys = []string{"x-caller-id", "x-caller-permissions"}
+
+var ErrInactive = errors.New("token is not active")
+
+type principalKey struct{}
+
+// Principal is the user a token acts for, as reported by Auth.
+type Principal struct {
+	Id          uuid.UUID
+	Roles       []string
+	Permissions []string
+	Scopes      []string
+	ExpiresAt   time.Time
+	SessionId   string
+	TokenId     string
+}
+
+// NewContext returns a copy of ctx carrying principal.
+func NewContext(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal the interceptor authenticated, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// Client asks Auth who a token belongs to, over one connection kept open
+// for all calls.
+type Client struct {
+	log  *slog.Logger
+	conn *grpcconn.Conn
+}
+
+func New(log *slog.Logger, conn *grpcconn.Conn) *Client {
+	return &Client{
+		log:  log,
+		conn: conn,
+	}
+}
+
+// Close closes the connection to Auth.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Introspect returns the principal of token, or ErrInactive if Auth does
+// not accept it.
+func (c *Client) Introspect(ctx context.Context, token string) (Principal, error) {
+	const op = "authclient.introspect"
+	log := c.log.With(
+		slog.String("op", op),
+	)
+
+	conn, err := c.conn.Get()
+	if err != nil {
+		log.Error("failed to get connection to Auth", sl.Err(err))
+		return Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := authv1.NewAuthClient(conn).Introspect(ctx, &authv1.IntrospectRequest{
+		Token: token,
+	})
+	if err != nil {
+		log.Error("failed to introspect token", sl.Err(err))
+		return Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !res.GetActive() {
+		return Principal{}, fmt.Errorf("%s: %w", op, ErrInactive)
+	}
+
+	id, err := uuid.Parse(res.GetSubject())
+	if err != nil {
+		log.Error("invalid subject", sl.Err(err))
+		return Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return Principal{
+		Id:          id,
+		Roles:       res.GetRoles(),
+		Permissions: res.GetPermissions(),
+		Scopes:      res.GetScopes(),
+		ExpiresAt:   res.GetExpiresAt().AsTime(),
+		SessionId:   res.GetSessionId(),
+		TokenId:     res.GetTokenId(),
+	}, nil
+}
+
+// UnaryServerInterceptor authenticates the bearer token of an incoming call
+// with Auth and puts its principal in the context. Calls without a token go
+// through anonymously; calls with an inactive token are rejected.
+func (c *Client) UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return handler(ctx, req)
+	}
+
+	md = md.Copy()
+	for _, key := range callerKeys {
+		delete(md, key)
+	}
+	ctx = metadata.NewIncomingContext(ctx, md)
+
+	values := md.Get(AuthorizationKey)
+	if len(values) == 0 {
+		return handler(ctx, req)
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(values[0], "Bearer"))
+	principal, err := c.Introspect(ctx, token)
+	if err != nil {
+		if errors.Is(err, ErrInactive) {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return nil, status.Error(codes.Unavailable, "failed to authenticate")
+	}
+
+	return handler(NewContext(ctx, principal), req)
+}
@@ -0,0 +1,154 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	authv1 "github.com/chas3air/protos/gen/go/auth"
+	"github.com/chas3air/shared/grpcconn"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var subject = uuid.New()
+
+// fakeAuth accepts the token "good" only.
+type fakeAuth struct {
+	authv1.UnimplementedAuthServer
+}
+
+func (fakeAuth) Introspect(ctx context.Context, in *authv1.IntrospectRequest) (*authv1.IntrospectResponse, error) {
+	if in.GetToken() != "good" {
+		return &authv1.IntrospectResponse{Active: false}, nil
+	}
+	return &authv1.IntrospectResponse{
+		Active:      true,
+		Subject:     subject.String(),
+		Permissions: []string{"articles.manage"},
+	}, nil
+}
+
+// countingListener counts the connections it accepts.
+type countingListener struct {
+	net.Listener
+	accepted atomic.Int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.accepted.Add(1)
+	}
+	return conn, err
+}
+
+func startAuth(t *testing.T) (*Client, *countingListener) {
+	t.Helper()
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := &countingListener{Listener: tcp}
+
+	server := grpc.NewServer()
+	authv1.RegisterAuthServer(server, fakeAuth{})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	conn := grpcconn.New(log, lis.Addr().String(), keepalive.ClientParameters{},
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	client := New(log, conn)
+	t.Cleanup(func() { client.Close() })
+
+	return client, lis
+}
+
+func TestIntrospectReusesConnection(t *testing.T) {
+	client, lis := startAuth(t)
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Introspect(context.Background(), "good"); err != nil {
+				t.Errorf("Introspect: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := lis.accepted.Load(); got != 1 {
+		t.Errorf("Auth accepted %d connections, want 1", got)
+	}
+}
+
+func TestIntrospectAfterClose(t *testing.T) {
+	client, _ := startAuth(t)
+	client.Close()
+
+	if _, err := client.Introspect(context.Background(), "good"); !errors.Is(err, grpcconn.ErrClosed) {
+		t.Errorf("Introspect after Close: %v, want grpcconn.ErrClosed", err)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	client, _ := startAuth(t)
+
+	tests := []struct {
+		name          string
+		md            metadata.MD
+		wantCode      codes.Code
+		wantPrincipal bool
+	}{
+		{name: "no token", md: metadata.Pairs(), wantCode: codes.OK},
+		{name: "active token", md: metadata.Pairs(AuthorizationKey, "Bearer good"), wantCode: codes.OK, wantPrincipal: true},
+		{name: "inactive token", md: metadata.Pairs(AuthorizationKey, "Bearer bad"), wantCode: codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.md.Append("x-caller-id", uuid.NewString())
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+
+			var principal Principal
+			var gotPrincipal bool
+			var callerKeys []string
+			_, err := client.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+				principal, gotPrincipal = FromContext(ctx)
+				md, _ := metadata.FromIncomingContext(ctx)
+				callerKeys = md.Get("x-caller-id")
+				return nil, nil
+			})
+
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("code %v, want %v", got, tt.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			if gotPrincipal != tt.wantPrincipal {
+				t.Errorf("principal present = %v, want %v", gotPrincipal, tt.wantPrincipal)
+			}
+			if gotPrincipal && principal.Id != subject {
+				t.Errorf("principal %v, want %v", principal.Id, subject)
+			}
+			if len(callerKeys) != 0 {
+				t.Errorf("x-caller-id reached the handler: %v", callerKeys)
+			}
+		})
+	}
+}
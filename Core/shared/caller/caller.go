@@ -0,0 +1,75 @@
+package caller
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/chas3air/shared/authclient"
+	"github.com/chas3air/shared/mtls"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys the gateway uses to pass on who a request is made by.
+const (
+	IdKey          = "x-caller-id"
+	PermissionsKey = "x-caller-permissions"
+)
+
+var (
+	ErrNoCaller  = errors.New("caller is not known")
+	ErrUntrusted = errors.New("caller named by an untrusted client")
+)
+
+// Caller is the user a request is made on behalf of.
+type Caller struct {
+	Id          uuid.UUID
+	Permissions []string
+}
+
+// FromContext returns the principal authenticated by authclient's
+// interceptor or, when the service does not check tokens itself, the caller
+// the gateway named in the incoming gRPC metadata. The metadata is only
+// taken from a trusted client marked by mtls.UnaryServerInterceptor;
+// otherwise naming a caller fails with ErrUntrusted.
+func FromContext(ctx context.Context) (Caller, error) {
+	if principal, ok := authclient.FromContext(ctx); ok {
+		return Caller{
+			Id:          principal.Id,
+			Permissions: principal.Permissions,
+		}, nil
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return Caller{}, ErrNoCaller
+	}
+
+	ids := md.Get(IdKey)
+	if len(ids) == 0 {
+		return Caller{}, ErrNoCaller
+	}
+	if _, ok := mtls.TrustedClient(ctx); !ok {
+		return Caller{}, ErrUntrusted
+	}
+	if len(ids) != 1 {
+		return Caller{}, ErrNoCaller
+	}
+
+	id, err := uuid.Parse(ids[0])
+	if err != nil {
+		return Caller{}, ErrNoCaller
+	}
+
+	return Caller{
+		Id:          id,
+		Permissions: md.Get(PermissionsKey),
+	}, nil
+}
+
+// Can reports whether the caller holds permission.
+func (c Caller) Can(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
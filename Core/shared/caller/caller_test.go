@@ -0,0 +1,94 @@
+package caller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+
+	"github.com/chas3air/shared/authclient"
+	"github.com/chas3air/shared/mtls"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// from returns a context of a call made over mutual TLS by client, which
+// is trusted when it is "api-gateway".
+func from(ctx context.Context, client string) context.Context {
+	ctx = peer.NewContext(ctx, &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: client}}}},
+		}},
+	})
+
+	interceptor := mtls.UnaryServerInterceptor([]string{"api-gateway"})
+	_, _ = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(marked context.Context, req any) (any, error) {
+		ctx = marked
+		return nil, nil
+	})
+	return ctx
+}
+
+func TestFromContext(t *testing.T) {
+	id := uuid.New()
+	named := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		IdKey, id.String(),
+		PermissionsKey, "articles.moderate",
+	))
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+		wantId  uuid.UUID
+	}{
+		{
+			name:   "principal",
+			ctx:    authclient.NewContext(context.Background(), authclient.Principal{Id: id}),
+			wantId: id,
+		},
+		{
+			name:   "named by the gateway",
+			ctx:    from(named, "api-gateway"),
+			wantId: id,
+		},
+		{
+			name:    "named by another client",
+			ctx:     from(named, "article_service"),
+			wantErr: ErrUntrusted,
+		},
+		{
+			name:    "named without tls",
+			ctx:     named,
+			wantErr: ErrUntrusted,
+		},
+		{
+			name:    "not named",
+			ctx:     from(metadata.NewIncomingContext(context.Background(), metadata.MD{}), "api-gateway"),
+			wantErr: ErrNoCaller,
+		},
+		{
+			name:    "invalid id",
+			ctx:     from(metadata.NewIncomingContext(context.Background(), metadata.Pairs(IdKey, "me")), "api-gateway"),
+			wantErr: ErrNoCaller,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			who, err := FromContext(tt.ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if who.Id != tt.wantId {
+				t.Errorf("caller %v, want %v", who.Id, tt.wantId)
+			}
+		})
+	}
+}
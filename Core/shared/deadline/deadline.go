@@ -0,0 +1,33 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor gives every call at most limit to complete. The
+// shorter deadline set by the caller is kept. A call cut off by the
+// deadline fails with DeadlineExceeded whatever error its handler made of
+// it. A zero limit leaves calls unbounded.
+func UnaryServerInterceptor(limit time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if limit <= 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, limit)
+		defer cancel()
+
+		resp, err := handler(ctx, req)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && status.Code(err) != codes.DeadlineExceeded {
+			return nil, status.Errorf(codes.DeadlineExceeded, "%s: deadline exceeded", info.FullMethod)
+		}
+
+		return resp, err
+	}
+}
@@ -0,0 +1,130 @@
+package grpcconn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+)
+
+var ErrClosed = errors.New("connection is closed")
+
+// Conn is a gRPC client connection shared by every call of a storage. It
+// is established on first use, kept open with keepalive pings and
+// re-established after failures until Close is called.
+type Conn struct {
+	log    *slog.Logger
+	target string
+	opts   []grpc.DialOption
+
+	mu     sync.Mutex
+	conn   *grpc.ClientConn
+	cancel context.CancelFunc
+	closed bool
+}
+
+// New describes a connection to target; nothing is dialed until Get.
+func New(log *slog.Logger, target string, params keepalive.ClientParameters, opts ...grpc.DialOption) *Conn {
+	return &Conn{
+		log:    log,
+		target: target,
+		opts: append([]grpc.DialOption{
+			grpc.WithKeepaliveParams(params),
+			grpc.WithConnectParams(grpc.ConnectParams{
+				Backoff: backoff.Config{
+					BaseDelay:  time.Second,
+					Multiplier: 1.6,
+					Jitter:     0.2,
+					MaxDelay:   30 * time.Second,
+				},
+				MinConnectTimeout: 5 * time.Second,
+			}),
+		}, opts...),
+	}
+}
+
+// Get returns the connection, establishing it on the first call.
+func (c *Conn) Get() (*grpc.ClientConn, error) {
+	const op = "grpcconn.get"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, fmt.Errorf("%s: %w", op, ErrClosed)
+	}
+	if c.conn != nil {
+		return c.conn, nil
+	}
+
+	conn, err := grpc.NewClient(c.target, c.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	conn.Connect()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go c.monitor(ctx, conn)
+
+	c.conn = conn
+	c.cancel = cancel
+
+	return conn, nil
+}
+
+// Close closes the connection; later calls to Get fail with ErrClosed.
+func (c *Conn) Close() error {
+	const op = "grpcconn.close"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	if c.conn == nil {
+		return nil
+	}
+
+	c.cancel()
+	if err := c.conn.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// monitor logs the state changes of conn and reconnects it as soon as it
+// drops to idle, so that the next call does not wait for the handshake.
+func (c *Conn) monitor(ctx context.Context, conn *grpc.ClientConn) {
+	const op = "grpcconn.monitor"
+	log := c.log.With(slog.String("op", op), slog.String("target", c.target))
+
+	state := conn.GetState()
+	for conn.WaitForStateChange(ctx, state) {
+		state = conn.GetState()
+
+		switch state {
+		case connectivity.Ready:
+			log.Info("Connection ready")
+		case connectivity.TransientFailure:
+			log.Warn("Connection failed, reconnecting")
+		case connectivity.Idle:
+			log.Debug("Connection idle, reconnecting")
+			conn.Connect()
+		case connectivity.Shutdown:
+			return
+		default:
+			log.Debug("Connection state changed", slog.String("state", state.String()))
+		}
+	}
+}
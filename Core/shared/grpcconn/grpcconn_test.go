@@ -0,0 +1,95 @@
+package grpcconn
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+)
+
+func TestGetAfterClose(t *testing.T) {
+	c := New(slog.New(slog.NewTextHandler(io.Discard, nil)), "127.0.0.1:0", keepalive.ClientParameters{},
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+
+	if _, err := c.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Get(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Get after Close: %v, want ErrClosed", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+// BenchmarkCall compares a call over the shared connection with a call that
+// dials its own connection first, as the storages did before Conn.
+func BenchmarkCall(b *testing.B) {
+	target := serve(b)
+	creds := grpc.WithTransportCredentials(insecure.NewCredentials())
+
+	b.Run("shared connection", func(b *testing.B) {
+		c := New(slog.New(slog.NewTextHandler(io.Discard, nil)), target, keepalive.ClientParameters{Time: time.Minute}, creds)
+		defer c.Close()
+
+		for i := 0; i < b.N; i++ {
+			conn, err := c.Get()
+			if err != nil {
+				b.Fatal(err)
+			}
+			check(b, conn)
+		}
+	})
+
+	b.Run("connection per call", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			conn, err := grpc.NewClient(target, creds)
+			if err != nil {
+				b.Fatal(err)
+			}
+			check(b, conn)
+			conn.Close()
+		}
+	})
+}
+
+func check(b *testing.B, conn *grpc.ClientConn) {
+	b.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := healthv1.NewHealthClient(conn).Check(ctx, &healthv1.HealthCheckRequest{}); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// serve starts a health server on a loopback port and returns its address.
+func serve(b *testing.B) string {
+	b.Helper()
+
+	server := grpc.NewServer()
+	healthv1.RegisterHealthServer(server, health.NewServer())
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	go server.Serve(lis)
+	b.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
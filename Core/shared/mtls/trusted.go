@@ -0,0 +1,51 @@
+package mtls
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+type trustedKey struct{}
+
+// VerifiesClients reports whether a server with cfg can tell its trusted
+// clients from others: TLS is enabled, clients must present a certificate
+// signed by CA and TrustedClients names at least one of them.
+func (cfg Config) VerifiesClients() bool {
+	return cfg.Enabled && cfg.CA != "" && len(cfg.TrustedClients) > 0
+}
+
+// UnaryServerInterceptor marks the calls made by one of the trusted
+// identities. A client counts as one only if it presented a certificate the
+// server verified against its CA, so without TLS no call is marked.
+func UnaryServerInterceptor(trusted []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if client, ok := verifiedClient(ctx, trusted); ok {
+			ctx = context.WithValue(ctx, trustedKey{}, client)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// TrustedClient returns the identity of the trusted client that made the
+// call, if the interceptor found one.
+func TrustedClient(ctx context.Context) (string, bool) {
+	client, ok := ctx.Value(trustedKey{}).(string)
+	return client, ok
+}
+
+func verifiedClient(ctx context.Context, trusted []string) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+
+	return identity(info.State.VerifiedChains[0][0], trusted)
+}
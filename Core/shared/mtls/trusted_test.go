@@ -0,0 +1,196 @@
+package mtls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// pki issues certificates for the tests from a CA it keeps in dir.
+type pki struct {
+	dir    string
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	serial int64
+}
+
+func newPKI(t *testing.T) *pki {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &pki{dir: t.TempDir(), caCert: cert, caKey: key, serial: 1}
+	p.write(t, "ca.crt", "CERTIFICATE", der)
+	return p
+}
+
+func (p *pki) write(t *testing.T, name, kind string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(p.dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// issue returns a config presenting a certificate for identity.
+func (p *pki) issue(t *testing.T, identity string) Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(p.serial),
+		Subject:      pkix.Name{CommonName: identity},
+		DNSNames:     []string{identity},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, p.caCert, &key.PublicKey, p.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return Config{
+		Enabled: true,
+		Cert:    p.write(t, identity+".crt", "CERTIFICATE", der),
+		Key:     p.write(t, identity+".key", "EC PRIVATE KEY", keyDer),
+		CA:      filepath.Join(p.dir, "ca.crt"),
+	}
+}
+
+// serve starts a server with cfg and returns its address and the trusted
+// client each call was seen from.
+func serve(t *testing.T, cfg Config) (string, chan string) {
+	t.Helper()
+
+	creds, err := ServerCredentials(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(chan string, 1)
+	server := grpc.NewServer(grpc.Creds(creds), grpc.ChainUnaryInterceptor(
+		UnaryServerInterceptor(cfg.TrustedClients),
+		func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			client, _ := TrustedClient(ctx)
+			seen <- client
+			return handler(ctx, req)
+		},
+	))
+	healthv1.RegisterHealthServer(server, health.NewServer())
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String(), seen
+}
+
+func TestTrustedClient(t *testing.T) {
+	p := newPKI(t)
+	serverCfg := p.issue(t, "localhost")
+	serverCfg.TrustedClients = []string{"api-gateway", "auth"}
+
+	tests := []struct {
+		name      string
+		server    Config
+		client    Config
+		wantTrust string
+	}{
+		{name: "trusted client", server: serverCfg, client: p.issue(t, "api-gateway"), wantTrust: "api-gateway"},
+		{name: "other client", server: serverCfg, client: p.issue(t, "article_service")},
+		{name: "without tls", server: Config{TrustedClients: serverCfg.TrustedClients}, client: Config{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, seen := serve(t, tt.server)
+
+			creds, err := ClientCredentials(tt.client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds), grpc.WithAuthority("localhost"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			if _, err := healthv1.NewHealthClient(conn).Check(context.Background(), &healthv1.HealthCheckRequest{}); err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if got := <-seen; got != tt.wantTrust {
+				t.Errorf("trusted client %q, want %q", got, tt.wantTrust)
+			}
+		})
+	}
+}
+
+func TestVerifiesClients(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want bool
+	}{
+		{name: "mutual tls", cfg: Config{Enabled: true, CA: "ca.crt", TrustedClients: []string{"api-gateway"}}, want: true},
+		{name: "without tls", cfg: Config{CA: "ca.crt", TrustedClients: []string{"api-gateway"}}},
+		{name: "without ca", cfg: Config{Enabled: true, TrustedClients: []string{"api-gateway"}}},
+		{name: "without trusted clients", cfg: Config{Enabled: true, CA: "ca.crt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.VerifiesClients(); got != tt.want {
+				t.Errorf("VerifiesClients = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
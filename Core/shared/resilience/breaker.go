@@ -0,0 +1,115 @@
+package resilience
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type state int
+
+const (
+	closed state = iota
+	open
+	halfOpen
+)
+
+// breaker counts consecutive failures of a service. Once there are
+// threshold of them it opens and refuses calls for openTimeout, then lets
+// one call through: its success closes the breaker, its failure opens it
+// again. A zero threshold never opens.
+type breaker struct {
+	log         *slog.Logger
+	name        string
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	state    state
+	failures int
+	openedAt time.Time
+}
+
+func newBreaker(log *slog.Logger, name string, threshold int, openTimeout time.Duration) *breaker {
+	return &breaker{
+		log:         log,
+		name:        name,
+		threshold:   threshold,
+		openTimeout: openTimeout,
+	}
+}
+
+// allow reports whether a call may be made now.
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case open:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.state = halfOpen
+		return true
+	case halfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// record takes the outcome of a call allow let through.
+func (b *breaker) record(failed bool) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case halfOpen:
+		if failed {
+			b.trip()
+			return
+		}
+		b.state = closed
+		b.failures = 0
+		b.log.Info("Circuit breaker closed", slog.String("service", b.name))
+	case closed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.threshold {
+			b.trip()
+		}
+	}
+}
+
+// abandon gives back a call allow let through whose outcome tells nothing,
+// so that a half-open breaker can let the next call probe instead.
+func (b *breaker) abandon() {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == halfOpen {
+		b.state = open
+	}
+}
+
+func (b *breaker) trip() {
+	b.state = open
+	b.openedAt = time.Now()
+	b.failures = 0
+	b.log.Warn("Circuit breaker opened", slog.String("service", b.name), slog.Duration("open_timeout", b.openTimeout))
+}
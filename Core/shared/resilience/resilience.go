@@ -0,0 +1,135 @@
+package resilience
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Policy controls how calls to one downstream service survive its
+// failures. Reads failing with Unavailable are tried up to MaxAttempts
+// times, waiting a random time of up to BaseDelay, doubled on every retry
+// and capped at MaxDelay. After FailureThreshold failures in a row the
+// circuit breaker opens and calls fail at once for OpenTimeout, then a
+// single call probes the service. Zero MaxAttempts or FailureThreshold
+// turns retries or the breaker off.
+type Policy struct {
+	MaxAttempts      int           `yaml:"max_attempts" env-default:"3"`
+	BaseDelay        time.Duration `yaml:"base_delay" env-default:"100ms"`
+	MaxDelay         time.Duration `yaml:"max_delay" env-default:"1s"`
+	FailureThreshold int           `yaml:"failure_threshold" env-default:"5"`
+	OpenTimeout      time.Duration `yaml:"open_timeout" env-default:"10s"`
+}
+
+// Client applies a Policy to the calls made to one downstream service: it
+// retries reads and keeps a circuit breaker.
+type Client struct {
+	log        *slog.Logger
+	name       string
+	policy     Policy
+	idempotent map[string]bool
+	breaker    *breaker
+}
+
+// New builds the client for the service called name. Only the methods in
+// idempotent, given by full method name, are retried.
+func New(log *slog.Logger, name string, policy Policy, idempotent ...string) *Client {
+	methods := make(map[string]bool, len(idempotent))
+	for _, method := range idempotent {
+		methods[method] = true
+	}
+
+	return &Client{
+		log:        log,
+		name:       name,
+		policy:     policy,
+		idempotent: methods,
+		breaker:    newBreaker(log, name, policy.FailureThreshold, policy.OpenTimeout),
+	}
+}
+
+// UnaryClientInterceptor fails fast while the breaker is open and retries
+// idempotent calls that failed with Unavailable.
+func (c *Client) UnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	const op = "resilience.unaryClientInterceptor"
+	log := c.log.With(slog.String("op", op), slog.String("service", c.name), slog.String("method", method))
+
+	attempts := 1
+	if c.idempotent[method] && c.policy.MaxAttempts > 1 {
+		attempts = c.policy.MaxAttempts
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			delay := c.backoff(attempt)
+			log.Warn("Retrying call", slog.Int("attempt", attempt+1), slog.Duration("delay", delay))
+			if !sleep(ctx, delay) {
+				return err
+			}
+		}
+
+		if !c.breaker.allow() {
+			return status.Errorf(codes.Unavailable, "%s is unavailable: circuit breaker is open", c.name)
+		}
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		if ctx.Err() != nil {
+			// The caller's own deadline or cancellation ended the call, which
+			// says nothing about the service.
+			c.breaker.abandon()
+			return err
+		}
+		c.breaker.record(failed(err))
+
+		if status.Code(err) != codes.Unavailable {
+			return err
+		}
+	}
+
+	return err
+}
+
+// backoff returns the wait before the given retry: a random duration of up
+// to BaseDelay doubled for every earlier retry, capped at MaxDelay.
+func (c *Client) backoff(attempt int) time.Duration {
+	limit := c.policy.BaseDelay << (attempt - 1)
+	if limit <= 0 || limit > c.policy.MaxDelay {
+		limit = c.policy.MaxDelay
+	}
+	if limit <= 0 {
+		return 0
+	}
+
+	return rand.N(limit)
+}
+
+// failed reports whether err says the service is in trouble, as opposed to
+// an answer it gave on purpose. It is only asked while the caller's context
+// is alive, so DeadlineExceeded is the deadline the service gave up on.
+func failed(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleep waits for d and reports whether ctx is still alive afterwards.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,120 @@
+package resilience
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const method = "/svc.Service/Get"
+
+func newClient(threshold int) *Client {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(log, "svc", Policy{
+		MaxAttempts:      1,
+		FailureThreshold: threshold,
+		OpenTimeout:      time.Hour,
+	})
+}
+
+// answer is an invoker that fails with code, or waits for the caller's
+// context to end when code is DeadlineExceeded and expire is set.
+func answer(code codes.Code, expire bool) grpc.UnaryInvoker {
+	return func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		if expire {
+			<-ctx.Done()
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		if code == codes.OK {
+			return nil
+		}
+		return status.Error(code, code.String())
+	}
+}
+
+func TestBreakerCountsOnlyServiceFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     codes.Code
+		expire   bool
+		wantOpen bool
+	}{
+		{name: "unavailable", code: codes.Unavailable, wantOpen: true},
+		{name: "deadline set by the service", code: codes.DeadlineExceeded, wantOpen: true},
+		{name: "deadline of the caller", code: codes.DeadlineExceeded, expire: true, wantOpen: false},
+		{name: "not found", code: codes.NotFound, wantOpen: false},
+		{name: "invalid argument", code: codes.InvalidArgument, wantOpen: false},
+		{name: "ok", code: codes.OK, wantOpen: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const threshold = 3
+			client := newClient(threshold)
+
+			for range threshold {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+				_ = client.UnaryClientInterceptor(ctx, method, nil, nil, nil, answer(tt.code, tt.expire))
+				cancel()
+			}
+
+			err := client.UnaryClientInterceptor(context.Background(), method, nil, nil, nil, answer(codes.OK, false))
+			if open := err != nil; open != tt.wantOpen {
+				t.Errorf("breaker open = %v, want %v (err %v)", open, tt.wantOpen, err)
+			}
+		})
+	}
+}
+
+func TestAbandonedProbeLetsNextCallThrough(t *testing.T) {
+	client := newClient(1)
+	client.breaker.openTimeout = 0
+
+	_ = client.UnaryClientInterceptor(context.Background(), method, nil, nil, nil, answer(codes.Unavailable, false))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	_ = client.UnaryClientInterceptor(ctx, method, nil, nil, nil, answer(codes.DeadlineExceeded, true))
+	cancel()
+
+	if err := client.UnaryClientInterceptor(context.Background(), method, nil, nil, nil, answer(codes.OK, false)); err != nil {
+		t.Fatalf("call after abandoned probe: %v", err)
+	}
+}
+
+func TestRetriesOnlyIdempotentMethods(t *testing.T) {
+	tests := []struct {
+		name       string
+		idempotent bool
+		want       int
+	}{
+		{name: "idempotent", idempotent: true, want: 3},
+		{name: "not idempotent", idempotent: false, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var idempotent []string
+			if tt.idempotent {
+				idempotent = append(idempotent, method)
+			}
+			client := New(slog.New(slog.NewTextHandler(io.Discard, nil)), "svc", Policy{MaxAttempts: 3}, idempotent...)
+
+			calls := 0
+			_ = client.UnaryClientInterceptor(context.Background(), method, nil, nil, nil,
+				func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+					calls++
+					return status.Error(codes.Unavailable, "down")
+				})
+
+			if calls != tt.want {
+				t.Errorf("made %d attempts, want %d", calls, tt.want)
+			}
+		})
+	}
+}
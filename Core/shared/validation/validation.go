@@ -0,0 +1,130 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validate checks structs against the rules in the `validate` tags of
+// their fields and names fields after their JSON keys, so the gateway and
+// the services report a broken rule the same way.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	// notfuture accepts times that are not after now, such as birthdays.
+	_ = v.RegisterValidation("notfuture", func(fl validator.FieldLevel) bool {
+		t, ok := fl.Field().Interface().(time.Time)
+		return !ok || !t.After(time.Now())
+	})
+
+	return v
+}
+
+// Violation is a broken rule of one field, named by its JSON key.
+type Violation struct {
+	Field   string
+	Message string
+}
+
+// Check checks every rule of s and returns all violations, or nil when s
+// is valid.
+func Check(s any) []Violation {
+	return violations(validate.Struct(s))
+}
+
+// CheckPartial checks only the rules of the named fields of s, for
+// requests that change just those fields.
+func CheckPartial(s any, fields ...string) []Violation {
+	return violations(validate.StructPartial(s, fields...))
+}
+
+// Struct is Check for gRPC services. It returns nil when s is valid and
+// otherwise an InvalidArgument status listing all violations as
+// BadRequest field violations.
+func Struct(s any, message string) error {
+	return statusError(Check(s), message)
+}
+
+// Partial is CheckPartial for gRPC services.
+func Partial(s any, message string, fields ...string) error {
+	return statusError(CheckPartial(s, fields...), message)
+}
+
+func violations(err error) []Violation {
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return []Violation{{Message: err.Error()}}
+	}
+
+	violations := make([]Violation, 0, len(fieldErrors))
+	for _, fieldError := range fieldErrors {
+		violations = append(violations, Violation{
+			Field:   fieldError.Field(),
+			Message: message(fieldError),
+		})
+	}
+	return violations
+}
+
+func statusError(violations []Violation, message string) error {
+	if violations == nil {
+		return nil
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, violation := range violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       violation.Field,
+			Description: violation.Message,
+		})
+	}
+
+	st, err := status.New(codes.InvalidArgument, message).WithDetails(badRequest)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, message)
+	}
+	return st.Err()
+}
+
+// message describes a broken rule the way a client can act on.
+func message(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required", "required_with":
+		return "is required"
+	case "max":
+		return fmt.Sprintf("must be at most %s characters long", fieldError.Param())
+	case "min":
+		if fieldError.Param() == "1" {
+			return "must not be empty"
+		}
+		return fmt.Sprintf("must be at least %s characters long", fieldError.Param())
+	case "email":
+		return "must be a valid email address"
+	case "notfuture":
+		return "must not be in the future"
+	default:
+		return "is invalid"
+	}
+}
@@ -0,0 +1,128 @@
+package validation
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type profile struct {
+	Email    string    `json:"email" validate:"required,email"`
+	Nick     string    `json:"nick" validate:"min=3,max=8"`
+	Title    string    `json:"title" validate:"min=1"`
+	Birthday time.Time `json:"birthday" validate:"notfuture"`
+	Internal string    `json:"-" validate:"required"`
+	Secret   string    `validate:"oneof=a b"`
+}
+
+func valid() profile {
+	return profile{
+		Email:    "user@example.com",
+		Nick:     "user",
+		Title:    "title",
+		Birthday: time.Now().AddDate(-20, 0, 0),
+		Internal: "set",
+		Secret:   "a",
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(p *profile)
+		want   []Violation
+	}{
+		{name: "valid", change: func(p *profile) {}},
+		{name: "required", change: func(p *profile) { p.Email = "" }, want: []Violation{{Field: "email", Message: "is required"}}},
+		{name: "email", change: func(p *profile) { p.Email = "user" }, want: []Violation{{Field: "email", Message: "must be a valid email address"}}},
+		{name: "too short", change: func(p *profile) { p.Nick = "ab" }, want: []Violation{{Field: "nick", Message: "must be at least 3 characters long"}}},
+		{name: "too long", change: func(p *profile) { p.Nick = strings.Repeat("a", 9) }, want: []Violation{{Field: "nick", Message: "must be at most 8 characters long"}}},
+		{name: "empty", change: func(p *profile) { p.Title = "" }, want: []Violation{{Field: "title", Message: "must not be empty"}}},
+		{name: "future", change: func(p *profile) { p.Birthday = time.Now().Add(time.Hour) }, want: []Violation{{Field: "birthday", Message: "must not be in the future"}}},
+		{name: "other rule", change: func(p *profile) { p.Secret = "c" }, want: []Violation{{Field: "Secret", Message: "is invalid"}}},
+		{name: "field without a json key", change: func(p *profile) { p.Internal = "" }, want: []Violation{{Field: "Internal", Message: "is required"}}},
+		{
+			name:   "all violations",
+			change: func(p *profile) { p.Email, p.Nick = "", "ab" },
+			want:   []Violation{{Field: "email", Message: "is required"}, {Field: "nick", Message: "must be at least 3 characters long"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid()
+			tt.change(&p)
+
+			if got := Check(p); !slices.Equal(got, tt.want) {
+				t.Errorf("Check = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPartial(t *testing.T) {
+	p := valid()
+	p.Email, p.Nick = "", "ab"
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   []Violation
+	}{
+		{name: "broken field", fields: []string{"Nick"}, want: []Violation{{Field: "nick", Message: "must be at least 3 characters long"}}},
+		{name: "valid field", fields: []string{"Title"}},
+		{name: "broken field left out", fields: []string{"Title", "Email"}, want: []Violation{{Field: "email", Message: "is required"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckPartial(p, tt.fields...); !slices.Equal(got, tt.want) {
+				t.Errorf("CheckPartial(%v) = %v, want %v", tt.fields, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStruct(t *testing.T) {
+	if err := Struct(valid(), "invalid profile"); err != nil {
+		t.Fatalf("valid profile: %v", err)
+	}
+
+	p := valid()
+	p.Email, p.Nick = "user", "ab"
+	st := status.Convert(Struct(p, "invalid profile"))
+
+	if st.Code() != codes.InvalidArgument || st.Message() != "invalid profile" {
+		t.Fatalf("status %v %q, want InvalidArgument %q", st.Code(), st.Message(), "invalid profile")
+	}
+
+	var got []Violation
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				got = append(got, Violation{Field: violation.GetField(), Message: violation.GetDescription()})
+			}
+		}
+	}
+	want := []Violation{{Field: "email", Message: "must be a valid email address"}, {Field: "nick", Message: "must be at least 3 characters long"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("field violations %v, want %v", got, want)
+	}
+}
+
+func TestPartial(t *testing.T) {
+	p := valid()
+	p.Email = ""
+
+	if err := Partial(p, "invalid profile", "Nick"); err != nil {
+		t.Errorf("Partial of a valid field: %v", err)
+	}
+	if err := Partial(p, "invalid profile", "Email"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Partial of a broken field: %v, want InvalidArgument", err)
+	}
+}